@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/gin-gonic/gin"
+	"github.com/reconquest/karma-go"
+	"github.com/reconquest/pkg/log"
+)
+
+const (
+	ADMIN_ACTIONS_DEFAULT_LIMIT = 100
+)
+
+type EventRulesResponse struct {
+	EventRules []database.EventRule `json:"eventRules"`
+}
+
+type AdminActionsResponse struct {
+	AdminActions []database.AdminAction `json:"adminActions"`
+}
+
+type AccessCodesResponse struct {
+	AccessCodes []database.AccessCode `json:"accessCodes"`
+}
+
+type CreateAccessCodeRequest struct {
+	AccessDays int `json:"access_days"`
+	MaxUses    int `json:"max_uses"`
+}
+
+func (handler *Handler) EventRules(context *gin.Context) {
+	rules, err := handler.database.GetEventRules()
+	if err != nil {
+		log.Error(karma.Format(
+			err,
+			"unable to get event rules from database",
+		))
+		context.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	responseBytes, err := json.Marshal(EventRulesResponse{EventRules: rules})
+	if err != nil {
+		log.Error("unable to decode to bytes event rules")
+	}
+
+	context.Data(
+		http.StatusOK,
+		"text/plain; charset=UTF-8",
+		responseBytes,
+	)
+}
+
+func (handler *Handler) AdminActions(context *gin.Context) {
+	limit := ADMIN_ACTIONS_DEFAULT_LIMIT
+	if context.Query("limit") != "" {
+		var err error
+		limit, err = strconv.Atoi(context.Query("limit"))
+		if err != nil || limit <= 0 {
+			context.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+	}
+
+	actions, err := handler.database.GetAdminActions(limit)
+	if err != nil {
+		log.Error(karma.Format(
+			err,
+			"unable to get admin actions from database",
+		))
+		context.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	responseBytes, err := json.Marshal(AdminActionsResponse{AdminActions: actions})
+	if err != nil {
+		log.Error("unable to decode to bytes admin actions")
+	}
+
+	context.Data(
+		http.StatusOK,
+		"text/plain; charset=UTF-8",
+		responseBytes,
+	)
+}
+
+func (handler *Handler) AccessCodes(context *gin.Context) {
+	codes, err := handler.database.GetAccessCodes()
+	if err != nil {
+		log.Error(karma.Format(
+			err,
+			"unable to get access codes from database",
+		))
+		context.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	responseBytes, err := json.Marshal(AccessCodesResponse{AccessCodes: codes})
+	if err != nil {
+		log.Error("unable to decode to bytes access codes")
+	}
+
+	context.Data(
+		http.StatusOK,
+		"text/plain; charset=UTF-8",
+		responseBytes,
+	)
+}
+
+func (handler *Handler) CreateAccessCode(context *gin.Context) {
+	var request CreateAccessCodeRequest
+	err := context.BindJSON(&request)
+	if err != nil {
+		return
+	}
+
+	if request.AccessDays <= 0 || request.MaxUses < 0 {
+		context.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	code, err := database.NewAccessCode(
+		request.AccessDays,
+		request.MaxUses,
+		handler.config.Access.CodeValidity,
+		0,
+	)
+	if err != nil {
+		log.Error(err)
+		context.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	err = handler.database.InsertAccessCode(code)
+	if err != nil {
+		log.Error(err)
+		context.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	responseBytes, err := json.Marshal(code)
+	if err != nil {
+		log.Error("unable to decode to bytes access code")
+	}
+
+	log.Infof(nil, "access code created by admin, code: %s", code.Code)
+	context.Data(
+		http.StatusCreated,
+		"text/plain; charset=UTF-8",
+		responseBytes,
+	)
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/statistics"
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+	"github.com/gin-gonic/gin"
+	"github.com/reconquest/karma-go"
+	"github.com/reconquest/pkg/log"
+)
+
+const (
+	CHARTS_DATE_FORMAT = "2006-01-02"
+)
+
+// Chart renders chart with given name as PNG image, period is set by from and
+// to dates inclusively, the last seven days by default.
+func (handler *Handler) Chart(context *gin.Context) {
+	if !statistics.IsKnownChart(context.Param("name")) {
+		context.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		log.Error(err)
+		context.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	to := tools.BeginningOfDay(timeNow).AddDate(0, 0, 1)
+	if context.Query("to") != "" {
+		to, err = time.ParseInLocation(CHARTS_DATE_FORMAT, context.Query("to"), timeNow.Location())
+		if err != nil {
+			context.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+
+		to = to.AddDate(0, 0, 1)
+	}
+
+	from := to.AddDate(0, 0, -7)
+	if context.Query("from") != "" {
+		from, err = time.ParseInLocation(CHARTS_DATE_FORMAT, context.Query("from"), timeNow.Location())
+		if err != nil {
+			context.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+	}
+
+	if !from.Before(to) || to.Sub(from) > statistics.CHART_MAX_DAYS*24*time.Hour {
+		context.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	results, err := handler.database.GetLiveEventsResults(from, to)
+	if err != nil {
+		log.Error(karma.Format(
+			err,
+			"unable to get live events results for chart from database",
+		))
+		context.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	chart, err := statistics.GetChart(
+		context.Param("name"),
+		results,
+		from,
+		to,
+		timeNow.Location(),
+	)
+	if err != nil {
+		log.Error(err)
+		context.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	context.Header("Content-Type", "image/png")
+	context.Data(http.StatusOK, "image/png", chart.Image)
+}
@@ -1,18 +1,30 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"net/http"
+	"time"
 
 	"github.com/daniilsolovey/BetBotGo/internal/config"
+	"github.com/daniilsolovey/BetBotGo/internal/constants"
 	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
 	"github.com/gin-gonic/gin"
 	"github.com/reconquest/karma-go"
 	"github.com/reconquest/pkg/log"
 )
 
+const (
+	HEADER_ADMIN_TOKEN = "X-Admin-Token"
+	DAY_TOMORROW       = "tomorrow"
+)
+
 type Handler struct {
 	database *database.Database
 	config   *config.Config
+	server   *http.Server
+	webhook  http.Handler
 }
 
 type ResultEventsHandler struct {
@@ -40,6 +52,7 @@ func NewHandler(
 	return &Handler{
 		database: database,
 		config:   config,
+		server:   &http.Server{Addr: config.Handler.Port},
 	}
 }
 
@@ -50,24 +63,85 @@ func JSONMiddleware() gin.HandlerFunc {
 	}
 }
 
-func (handler *Handler) StartServer(config *config.Config) {
-	router := gin.Default()
+func AdminMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" || c.GetHeader(HEADER_ADMIN_TOKEN) != token {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// SetWebhook enables receiving of telegram updates on the secret path
+// telegram.webhook.path.
+func (handler *Handler) SetWebhook(webhook http.Handler) {
+	handler.webhook = webhook
+}
+
+func (handler *Handler) StartServer(config *config.Config) error {
+	router := gin.New()
+	if handler.webhook != nil {
+		// the path is secret, so it is not written to the access log
+		router.Use(
+			gin.LoggerWithConfig(gin.LoggerConfig{
+				SkipPaths: []string{config.Telegram.Webhook.Path},
+			}),
+			gin.Recovery(),
+		)
+		router.POST(config.Telegram.Webhook.Path, gin.WrapH(handler.webhook))
+	} else {
+		router.Use(gin.Logger(), gin.Recovery())
+	}
+
 	router.GET("/", handler.ActionIndex)
 	router.Use(JSONMiddleware())
 	router.GET("/upcoming_events", handler.UpcomingEvents)
+	router.GET("/leagues", handler.Leagues)
+
+	admin := router.Group("/", AdminMiddleware(handler.config.Handler.AdminToken))
+	admin.POST("/leagues/:league_id", handler.UpdateLeague)
+	admin.GET("/event_rules", handler.EventRules)
+	admin.GET("/admin_actions", handler.AdminActions)
+	admin.GET("/access_codes", handler.AccessCodes)
+	admin.POST("/access_codes", handler.CreateAccessCode)
+	admin.GET("/outbox", handler.Outbox)
+	admin.GET("/roles", handler.Roles)
+	admin.GET("/charts/:name", handler.Chart)
+
+	handler.server.Handler = router
+	return handler.server.ListenAndServe()
+}
 
-	router.Run(handler.config.Handler.Port)
+func (handler *Handler) Shutdown(ctx context.Context) error {
+	return handler.server.Shutdown(ctx)
 }
 
 func (handler *Handler) UpcomingEvents(context *gin.Context) {
-	events, err := handler.database.GetUpcomingEventsForToday()
+	day, err := tools.GetCurrentTime()
+	if err != nil {
+		log.Error(err)
+	}
+
+	if context.Query("day") == DAY_TOMORROW {
+		day = day.AddDate(0, 0, 1)
+	}
+
+	events, err := handler.database.GetUpcomingEventsForDay(day)
 	if err != nil {
 		log.Error(karma.Format(
 			err,
-			"unable to get upcoming_events_handler for today from database",
+			"unable to get upcoming_events_handler for day from database",
 		))
 
 	}
+	location, err := tools.GetLocation()
+	if err != nil {
+		log.Error(err)
+		location = time.UTC
+	}
+
 	var resultsEvents []ResultEventsHandler
 	for _, event := range events {
 		var resultEventsHandler ResultEventsHandler
@@ -78,7 +152,7 @@ func (handler *Handler) UpcomingEvents(context *gin.Context) {
 		resultEventsHandler.HomeOdd = event.HomeOdd
 		resultEventsHandler.AwayOdd = event.AwayOdd
 		resultEventsHandler.Favorite = event.Favorite
-		resultEventsHandler.EventStartTime = event.EventStartTime.Format("02 Jan 06 15:04 MSK")
+		resultEventsHandler.EventStartTime = event.EventStartTime.In(location).Format(constants.TIME_FORMAT)
 		resultsEvents = append(resultsEvents, resultEventsHandler)
 	}
 	var response Response
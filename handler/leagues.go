@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+
+	"github.com/daniilsolovey/BetBotGo/internal/constants"
+	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/gin-gonic/gin"
+	"github.com/reconquest/karma-go"
+	"github.com/reconquest/pkg/log"
+)
+
+var countryCodeRegexp = regexp.MustCompile(`^([a-z]{2})?$`)
+
+type LeaguesResponse struct {
+	Leagues []database.League `json:"leagues"`
+}
+
+type UpdateLeagueRequest struct {
+	CC     string `json:"cc"`
+	Gender string `json:"gender"`
+	Tier   int    `json:"tier"`
+	Youth  bool   `json:"youth"`
+}
+
+func (handler *Handler) Leagues(context *gin.Context) {
+	leagues, err := handler.database.GetLeagues()
+	if err != nil {
+		log.Error(karma.Format(
+			err,
+			"unable to get leagues from database",
+		))
+		context.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	responseBytes, err := json.Marshal(LeaguesResponse{Leagues: leagues})
+	if err != nil {
+		log.Error("unable to decode to bytes leagues")
+	}
+
+	context.Data(
+		http.StatusOK,
+		"text/plain; charset=UTF-8",
+		responseBytes,
+	)
+}
+
+func (handler *Handler) UpdateLeague(context *gin.Context) {
+	var request UpdateLeagueRequest
+	err := context.BindJSON(&request)
+	if err != nil {
+		return
+	}
+
+	if !countryCodeRegexp.MatchString(request.CC) ||
+		(request.Gender != constants.GENDER_MEN && request.Gender != constants.GENDER_WOMEN) ||
+		request.Tier < 0 {
+		context.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	league := database.League{
+		ID:     context.Param("league_id"),
+		CC:     request.CC,
+		Gender: request.Gender,
+		Tier:   request.Tier,
+		Youth:  request.Youth,
+	}
+
+	updated, err := handler.database.UpdateLeague(league)
+	if err != nil {
+		log.Error(err)
+		context.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	if !updated {
+		context.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	log.Infof(nil, "league updated by admin, league: %v", league)
+	context.Status(http.StatusOK)
+}
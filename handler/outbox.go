@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/gin-gonic/gin"
+	"github.com/reconquest/karma-go"
+	"github.com/reconquest/pkg/log"
+)
+
+type OutboxResponse struct {
+	Pending int `json:"pending"`
+	Sent    int `json:"sent"`
+	Failed  int `json:"failed"`
+}
+
+func (handler *Handler) Outbox(context *gin.Context) {
+	counts, err := handler.database.CountOutboxMessages()
+	if err != nil {
+		log.Error(karma.Format(
+			err,
+			"unable to count outbox messages in database",
+		))
+		context.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	responseBytes, err := json.Marshal(OutboxResponse{
+		Pending: counts[database.OUTBOX_STATUS_PENDING],
+		Sent:    counts[database.OUTBOX_STATUS_SENT],
+		Failed:  counts[database.OUTBOX_STATUS_FAILED],
+	})
+	if err != nil {
+		log.Error("unable to decode to bytes outbox queue depth")
+	}
+
+	context.Data(
+		http.StatusOK,
+		"text/plain; charset=UTF-8",
+		responseBytes,
+	)
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/gin-gonic/gin"
+	"github.com/reconquest/karma-go"
+	"github.com/reconquest/pkg/log"
+)
+
+type RolesResponse struct {
+	Roles []database.UserRole `json:"roles"`
+}
+
+func (handler *Handler) Roles(context *gin.Context) {
+	roles, err := handler.database.GetUserRoles()
+	if err != nil {
+		log.Error(karma.Format(
+			err,
+			"unable to get user roles from database",
+		))
+		context.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	responseBytes, err := json.Marshal(RolesResponse{Roles: roles})
+	if err != nil {
+		log.Error("unable to decode to bytes user roles")
+	}
+
+	context.Data(
+		http.StatusOK,
+		"text/plain; charset=UTF-8",
+		responseBytes,
+	)
+}
@@ -0,0 +1,424 @@
+package charts
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+)
+
+const (
+	KIND_LINE            = "line"
+	KIND_BARS            = "bars"
+	KIND_HORIZONTAL_BARS = "horizontal_bars"
+
+	WIDTH  = 800
+	HEIGHT = 480
+
+	MARGIN        = 20
+	LABEL_PADDING = 8
+	FONT_SCALE    = 2
+	LINE_WIDTH    = 3
+	TICKS         = 4
+
+	MAX_LABEL_LENGTH = 22
+)
+
+var (
+	COLOR_BACKGROUND = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	COLOR_GRID       = color.RGBA{0xe0, 0xe0, 0xe0, 0xff}
+	COLOR_AXIS       = color.RGBA{0x60, 0x60, 0x60, 0xff}
+	COLOR_TEXT       = color.RGBA{0x30, 0x30, 0x30, 0xff}
+
+	COLOR_GREEN = color.RGBA{0x2e, 0xa0, 0x43, 0xff}
+	COLOR_RED   = color.RGBA{0xd9, 0x3f, 0x3f, 0xff}
+	COLOR_BLUE  = color.RGBA{0x2f, 0x6f, 0xd6, 0xff}
+)
+
+type Series struct {
+	Color  color.RGBA
+	Values []float64
+}
+
+// Chart has one value of every series for every label. Values are formatted
+// with ValueFormat and axis ticks are multiples of MinStep at least, so counts
+// are not labelled with fractions.
+type Chart struct {
+	Kind        string
+	Labels      []string
+	Series      []Series
+	ValueFormat string
+	MinStep     float64
+}
+
+// Render draws the chart as PNG image. Image has no title or legend, so it
+// doesn't depend on language of the reader.
+func Render(chart Chart) ([]byte, error) {
+	for i, series := range chart.Series {
+		if len(series.Values) != len(chart.Labels) {
+			return nil, fmt.Errorf(
+				"series %d has %d values but chart has %d labels",
+				i,
+				len(series.Values),
+				len(chart.Labels),
+			)
+		}
+	}
+
+	if chart.ValueFormat == "" {
+		chart.ValueFormat = "%g"
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, WIDTH, HEIGHT))
+	draw.Draw(img, img.Bounds(), &image.Uniform{COLOR_BACKGROUND}, image.Point{}, draw.Src)
+
+	switch chart.Kind {
+	case KIND_LINE, KIND_BARS:
+		drawVertical(img, chart)
+	case KIND_HORIZONTAL_BARS:
+		drawHorizontal(img, chart)
+	default:
+		return nil, fmt.Errorf("unknown kind of chart: %q", chart.Kind)
+	}
+
+	var buffer bytes.Buffer
+	err := png.Encode(&buffer, img)
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// axis maps values to pixels between from and to.
+type axis struct {
+	min  float64
+	max  float64
+	step float64
+	from int
+	to   int
+}
+
+func newAxis(chart Chart) axis {
+	min, max := 0.0, 0.0
+	for _, series := range chart.Series {
+		for _, value := range series.Values {
+			min = math.Min(min, value)
+			max = math.Max(max, value)
+		}
+	}
+
+	step := getStep((max-min)/TICKS, chart.MinStep)
+
+	return axis{
+		min:  math.Floor(min/step) * step,
+		max:  math.Max(math.Ceil(max/step)*step, math.Floor(min/step)*step+step),
+		step: step,
+	}
+}
+
+func (axis axis) getPosition(value float64) int {
+	ratio := (value - axis.min) / (axis.max - axis.min)
+	return axis.from + int(math.Round(ratio*float64(axis.to-axis.from)))
+}
+
+func (axis axis) getTicks() []float64 {
+	var ticks []float64
+	for i := 0; ; i++ {
+		tick := axis.min + float64(i)*axis.step
+		if tick > axis.max+axis.step/2 {
+			return ticks
+		}
+
+		ticks = append(ticks, tick)
+	}
+}
+
+func (axis axis) getTickLabels(format string) ([]string, int) {
+	var labels []string
+	var width int
+	for _, tick := range axis.getTicks() {
+		label := fmt.Sprintf(format, tick)
+		labels = append(labels, label)
+		if getTextWidth(label, FONT_SCALE) > width {
+			width = getTextWidth(label, FONT_SCALE)
+		}
+	}
+
+	return labels, width
+}
+
+// getStep rounds step up to 1, 2 or 5 multiplied by power of ten.
+func getStep(raw float64, minStep float64) float64 {
+	if raw <= 0 {
+		raw = 1
+	}
+
+	power := math.Pow(10, math.Floor(math.Log10(raw)))
+	step := 10 * power
+	for _, factor := range []float64{1, 2, 5} {
+		if raw <= factor*power {
+			step = factor * power
+			break
+		}
+	}
+
+	return math.Max(step, minStep)
+}
+
+func drawVertical(img *image.RGBA, chart Chart) {
+	textHeight := GLYPH_HEIGHT * FONT_SCALE
+
+	values := newAxis(chart)
+	tickLabels, tickWidth := values.getTickLabels(chart.ValueFormat)
+
+	plot := image.Rect(
+		MARGIN+tickWidth+LABEL_PADDING,
+		MARGIN+textHeight/2,
+		WIDTH-MARGIN,
+		HEIGHT-MARGIN-textHeight-LABEL_PADDING,
+	)
+	values.from = plot.Max.Y
+	values.to = plot.Min.Y
+
+	for i, tick := range values.getTicks() {
+		y := values.getPosition(tick)
+		drawHorizontalLine(img, plot.Min.X, plot.Max.X, y, COLOR_GRID)
+		drawText(
+			img,
+			plot.Min.X-LABEL_PADDING-getTextWidth(tickLabels[i], FONT_SCALE),
+			y-textHeight/2,
+			tickLabels[i],
+			FONT_SCALE,
+			COLOR_TEXT,
+		)
+	}
+
+	zero := values.getPosition(0)
+	drawHorizontalLine(img, plot.Min.X, plot.Max.X, zero, COLOR_AXIS)
+	drawVerticalLine(img, plot.Min.X, plot.Min.Y, plot.Max.Y, COLOR_AXIS)
+
+	if len(chart.Labels) == 0 {
+		return
+	}
+
+	slot := float64(plot.Dx()) / float64(len(chart.Labels))
+	getCenter := func(i int) int {
+		return plot.Min.X + int(slot*(float64(i)+0.5))
+	}
+
+	labelEvery := getLabelEvery(chart.Labels, slot)
+	for i, label := range chart.Labels {
+		if i%labelEvery != 0 {
+			continue
+		}
+
+		label = truncate(label)
+		drawText(
+			img,
+			getCenter(i)-getTextWidth(label, FONT_SCALE)/2,
+			plot.Max.Y+LABEL_PADDING,
+			label,
+			FONT_SCALE,
+			COLOR_TEXT,
+		)
+	}
+
+	if chart.Kind == KIND_LINE {
+		for _, series := range chart.Series {
+			for i := range series.Values {
+				point := image.Pt(getCenter(i), values.getPosition(series.Values[i]))
+				if i > 0 {
+					previous := image.Pt(getCenter(i-1), values.getPosition(series.Values[i-1]))
+					drawLine(img, previous, point, LINE_WIDTH, series.Color)
+				}
+
+				fillRect(img, image.Rect(point.X-3, point.Y-3, point.X+4, point.Y+4), series.Color)
+			}
+		}
+
+		return
+	}
+
+	if len(chart.Series) == 0 {
+		return
+	}
+
+	barWidth := int(slot * 0.8 / float64(len(chart.Series)))
+	if barWidth < 1 {
+		barWidth = 1
+	}
+
+	for index, series := range chart.Series {
+		for i, value := range series.Values {
+			x := getCenter(i) - barWidth*len(chart.Series)/2 + barWidth*index
+			fillRect(
+				img,
+				image.Rect(x, values.getPosition(value), x+barWidth, zero).Canon(),
+				series.Color,
+			)
+		}
+	}
+}
+
+func drawHorizontal(img *image.RGBA, chart Chart) {
+	textHeight := GLYPH_HEIGHT * FONT_SCALE
+
+	var labelWidth int
+	for _, label := range chart.Labels {
+		width := getTextWidth(truncate(label), FONT_SCALE)
+		if width > labelWidth {
+			labelWidth = width
+		}
+	}
+
+	values := newAxis(chart)
+	tickLabels, tickWidth := values.getTickLabels(chart.ValueFormat)
+
+	plot := image.Rect(
+		MARGIN+labelWidth+LABEL_PADDING,
+		MARGIN,
+		WIDTH-MARGIN-tickWidth,
+		HEIGHT-MARGIN-textHeight-LABEL_PADDING,
+	)
+	values.from = plot.Min.X
+	values.to = plot.Max.X
+
+	for i, tick := range values.getTicks() {
+		x := values.getPosition(tick)
+		drawVerticalLine(img, x, plot.Min.Y, plot.Max.Y, COLOR_GRID)
+		drawText(
+			img,
+			x-getTextWidth(tickLabels[i], FONT_SCALE)/2,
+			plot.Max.Y+LABEL_PADDING,
+			tickLabels[i],
+			FONT_SCALE,
+			COLOR_TEXT,
+		)
+	}
+
+	zero := values.getPosition(0)
+	drawVerticalLine(img, zero, plot.Min.Y, plot.Max.Y, COLOR_AXIS)
+	drawHorizontalLine(img, plot.Min.X, plot.Max.X, plot.Max.Y, COLOR_AXIS)
+
+	if len(chart.Labels) == 0 || len(chart.Series) == 0 {
+		return
+	}
+
+	slot := float64(plot.Dy()) / float64(len(chart.Labels))
+	barHeight := int(slot * 0.7 / float64(len(chart.Series)))
+	if barHeight < 1 {
+		barHeight = 1
+	}
+
+	for i, label := range chart.Labels {
+		center := plot.Min.Y + int(slot*(float64(i)+0.5))
+		label = truncate(label)
+		drawText(
+			img,
+			plot.Min.X-LABEL_PADDING-getTextWidth(label, FONT_SCALE),
+			center-textHeight/2,
+			label,
+			FONT_SCALE,
+			COLOR_TEXT,
+		)
+
+		for index, series := range chart.Series {
+			y := center - barHeight*len(chart.Series)/2 + barHeight*index
+			fillRect(
+				img,
+				image.Rect(zero, y, values.getPosition(series.Values[i]), y+barHeight).Canon(),
+				series.Color,
+			)
+		}
+	}
+}
+
+// getLabelEvery returns how often labels of horizontal axis are drawn to
+// avoid overlapping.
+func getLabelEvery(labels []string, slot float64) int {
+	var width int
+	for _, label := range labels {
+		if getTextWidth(truncate(label), FONT_SCALE) > width {
+			width = getTextWidth(truncate(label), FONT_SCALE)
+		}
+	}
+
+	every := int(math.Ceil(float64(width+LABEL_PADDING) / slot))
+	if every < 1 {
+		return 1
+	}
+
+	return every
+}
+
+func truncate(label string) string {
+	runes := []rune(label)
+	if len(runes) <= MAX_LABEL_LENGTH {
+		return label
+	}
+
+	return string(runes[:MAX_LABEL_LENGTH-2]) + ".."
+}
+
+func fillRect(img *image.RGBA, rect image.Rectangle, fillColor color.RGBA) {
+	draw.Draw(img, rect.Intersect(img.Bounds()), &image.Uniform{fillColor}, image.Point{}, draw.Src)
+}
+
+func drawHorizontalLine(img *image.RGBA, fromX int, toX int, y int, lineColor color.RGBA) {
+	fillRect(img, image.Rect(fromX, y, toX+1, y+1), lineColor)
+}
+
+func drawVerticalLine(img *image.RGBA, x int, fromY int, toY int, lineColor color.RGBA) {
+	fillRect(img, image.Rect(x, fromY, x+1, toY+1), lineColor)
+}
+
+// drawLine draws line between points with Bresenham's algorithm, every point
+// is a square of given width.
+func drawLine(img *image.RGBA, from image.Point, to image.Point, width int, lineColor color.RGBA) {
+	dx := abs(to.X - from.X)
+	dy := -abs(to.Y - from.Y)
+	stepX, stepY := 1, 1
+	if from.X > to.X {
+		stepX = -1
+	}
+
+	if from.Y > to.Y {
+		stepY = -1
+	}
+
+	point := from
+	errorValue := dx + dy
+	for {
+		fillRect(
+			img,
+			image.Rect(point.X-width/2, point.Y-width/2, point.X-width/2+width, point.Y-width/2+width),
+			lineColor,
+		)
+		if point == to {
+			return
+		}
+
+		doubled := 2 * errorValue
+		if doubled >= dy {
+			errorValue += dy
+			point.X += stepX
+		}
+
+		if doubled <= dx {
+			errorValue += dx
+			point.Y += stepY
+		}
+	}
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
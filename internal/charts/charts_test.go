@@ -0,0 +1,123 @@
+package charts
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func decode(t *testing.T, data []byte) image.Image {
+	img, err := png.Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+
+	return img
+}
+
+func countPixels(img image.Image, expected [4]uint32) int {
+	var count int
+	bounds := img.Bounds()
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			if [4]uint32{r, g, b, a} == expected {
+				count++
+			}
+		}
+	}
+
+	return count
+}
+
+func getRGBA(series Series) [4]uint32 {
+	r, g, b, a := series.Color.RGBA()
+	return [4]uint32{r, g, b, a}
+}
+
+func TestCharts_Render_AllKinds(
+	t *testing.T,
+) {
+	win := Series{Color: COLOR_GREEN, Values: []float64{3, 0, 5}}
+	lose := Series{Color: COLOR_RED, Values: []float64{1, 2, 0}}
+
+	for _, chart := range []Chart{
+		{Kind: KIND_LINE, Labels: []string{"14.10", "15.10", "16.10"}, Series: []Series{
+			{Color: COLOR_BLUE, Values: []float64{0.85, -0.15, 1.7}},
+		}, ValueFormat: "%+.1f"},
+		{Kind: KIND_BARS, Labels: []string{"14.10", "15.10", "16.10"}, Series: []Series{win, lose}, MinStep: 1},
+		{Kind: KIND_HORIZONTAL_BARS, Labels: []string{"Russia Superleague", "Poland PlusLiga", "Italy Serie A1 Women"}, Series: []Series{
+			{Color: COLOR_BLUE, Values: []float64{75, 0, 100}},
+		}, ValueFormat: "%.0f%%", MinStep: 1},
+	} {
+		data, err := Render(chart)
+		assert.NoError(t, err, chart.Kind)
+
+		img := decode(t, data)
+		assert.Equal(t, image.Rect(0, 0, WIDTH, HEIGHT), img.Bounds(), chart.Kind)
+
+		for _, series := range chart.Series {
+			assert.True(t, countPixels(img, getRGBA(series)) > 0, chart.Kind)
+		}
+	}
+}
+
+func TestCharts_Render_BarsAreProportional(
+	t *testing.T,
+) {
+	small := Series{Color: COLOR_GREEN, Values: []float64{1, 0}}
+	big := Series{Color: COLOR_RED, Values: []float64{0, 4}}
+
+	data, err := Render(Chart{
+		Kind:    KIND_BARS,
+		Labels:  []string{"a", "b"},
+		Series:  []Series{small, big},
+		MinStep: 1,
+	})
+	assert.NoError(t, err)
+
+	img := decode(t, data)
+	ratio := float64(countPixels(img, getRGBA(big))) / float64(countPixels(img, getRGBA(small)))
+	assert.True(t, ratio > 3.8 && ratio < 4.2, ratio)
+}
+
+func TestCharts_Render_WithoutData(
+	t *testing.T,
+) {
+	data, err := Render(Chart{Kind: KIND_LINE})
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, WIDTH, HEIGHT), decode(t, data).Bounds())
+}
+
+func TestCharts_Render_InvalidChart(
+	t *testing.T,
+) {
+	_, err := Render(Chart{Kind: "pie"})
+	assert.Error(t, err)
+
+	_, err = Render(Chart{
+		Kind:   KIND_BARS,
+		Labels: []string{"a", "b"},
+		Series: []Series{{Values: []float64{1}}},
+	})
+	assert.Error(t, err)
+}
+
+func TestCharts_GetStep(
+	t *testing.T,
+) {
+	assert.Equal(t, 0.5, getStep(0.35, 0))
+	assert.Equal(t, 1.0, getStep(0.35, 1))
+	assert.Equal(t, 2.0, getStep(1.2, 0))
+	assert.Equal(t, 20.0, getStep(12.5, 1))
+	assert.Equal(t, 1.0, getStep(0, 0))
+}
+
+func TestCharts_GetTextWidth(
+	t *testing.T,
+) {
+	assert.Equal(t, 0, getTextWidth("", FONT_SCALE))
+	assert.Equal(t, 10, getTextWidth("1", FONT_SCALE))
+	assert.Equal(t, 34, getTextWidth("1.5", FONT_SCALE))
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/kovetskiy/ko"
 	"gopkg.in/yaml.v2"
 )
@@ -13,8 +15,21 @@ type Database struct {
 	Password string `yaml:"password" required:"true"`
 }
 
+// Webhook is registered in telegram as PublicURL + Path, updates are
+// accepted only with SecretToken in X-Telegram-Bot-Api-Secret-Token header.
+type Webhook struct {
+	PublicURL   string `yaml:"public_url"`
+	Path        string `yaml:"path" env:"TELEGRAM_WEBHOOK_PATH"`
+	SecretToken string `yaml:"secret_token" env:"TELEGRAM_WEBHOOK_SECRET_TOKEN"`
+}
+
 type Telegram struct {
-	Token string `yaml:"token" required:"true" env:"TELEGRAM_TOKEN"`
+	Token          string        `yaml:"token" required:"true" env:"TELEGRAM_TOKEN"`
+	Owner          int64         `yaml:"owner" env:"TELEGRAM_OWNER"`
+	Admins         []int64       `yaml:"admins"`
+	Mode           string        `yaml:"mode" default:"polling"`
+	PollingTimeout time.Duration `yaml:"polling_timeout" default:"10s"`
+	Webhook        Webhook       `yaml:"webhook"`
 }
 
 type BetApi struct {
@@ -26,13 +41,99 @@ type BetApi struct {
 type Handler struct {
 	ApiVersion string `yaml:"api_version" required:"true"`
 	Port       string `yaml:"port" required:"true"`
+	AdminToken string `yaml:"admin_token" env:"HANDLER_ADMIN_TOKEN"`
+}
+
+type OddsDrift struct {
+	Threshold float64 `yaml:"threshold" default:"0.15"`
+}
+
+type Signals struct {
+	InvalidationWindow time.Duration `yaml:"invalidation_window" default:"15m"`
+	UpdateThreshold    float64       `yaml:"update_threshold" default:"0.2"`
+	EditInterval       time.Duration `yaml:"edit_interval" default:"30s"`
+}
+
+type Discovery struct {
+	Lookahead         time.Duration `yaml:"lookahead" default:"24h"`
+	UntilHour         int           `yaml:"until_hour"`
+	MonitoringHorizon time.Duration `yaml:"monitoring_horizon" default:"6h"`
+}
+
+// Digest of today's candidates is sent every day not earlier than Hour of the
+// chat timezone, later candidates are sent as updates.
+type Digest struct {
+	Enabled bool `yaml:"enabled"`
+	Hour    int  `yaml:"hour" default:"9"`
+}
+
+type Leader struct {
+	LockID        int64         `yaml:"lock_id" default:"91001"`
+	RenewInterval time.Duration `yaml:"renew_interval" default:"10s"`
+}
+
+type Access struct {
+	CodesRequired  bool          `yaml:"codes_required"`
+	CodeValidity   time.Duration `yaml:"code_validity" default:"720h"`
+	ReminderBefore time.Duration `yaml:"reminder_before" default:"72h"`
+}
+
+type Templates struct {
+	Directory string `yaml:"directory"`
+}
+
+type Outbox struct {
+	PollInterval    time.Duration `yaml:"poll_interval" default:"1s"`
+	BatchSize       int           `yaml:"batch_size" default:"100"`
+	PerChatInterval time.Duration `yaml:"per_chat_interval" default:"1s"`
+	GlobalPerSecond int           `yaml:"global_per_second" default:"30"`
+	MaxAttempts     int           `yaml:"max_attempts" default:"5"`
+	RetryBackoff    time.Duration `yaml:"retry_backoff" default:"5s"`
+	MaxRetryBackoff time.Duration `yaml:"max_retry_backoff" default:"10m"`
+	Retention       time.Duration `yaml:"retention" default:"168h"`
+}
+
+// NotificationChannel type is one of discord, slack or webhook, telegram chats
+// are configured in Broadcast.
+type NotificationChannel struct {
+	Name    string            `yaml:"name"`
+	Type    string            `yaml:"type"`
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+}
+
+type Notifications struct {
+	Channels []NotificationChannel `yaml:"channels"`
+	Routes   map[string][]string   `yaml:"routes"`
+}
+
+// Broadcast chats receive messages by category in addition to subscribers,
+// chat with zero id is disabled.
+type Broadcast struct {
+	ChannelID         int64               `yaml:"channel_id"`
+	AdminChatID       int64               `yaml:"admin_chat_id"`
+	DiscussionGroupID int64               `yaml:"discussion_group_id"`
+	Routes            map[string][]string `yaml:"routes"`
 }
 
 type Config struct {
-	Database Database `yaml:"database" required:"true"`
-	Telegram Telegram `yaml:"telegram" required:"true"`
-	BetApi   BetApi   `yaml:"bet_api" required:"true"`
-	Handler  Handler  `yaml:"handler" required:"true"`
+	Timezone        string        `yaml:"timezone" default:"Europe/Moscow"`
+	Language        string        `yaml:"language" default:"ru"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" default:"30s"`
+	Database        Database      `yaml:"database" required:"true"`
+	Telegram        Telegram      `yaml:"telegram" required:"true"`
+	BetApi          BetApi        `yaml:"bet_api" required:"true"`
+	Handler         Handler       `yaml:"handler" required:"true"`
+	OddsDrift       OddsDrift     `yaml:"odds_drift"`
+	Signals         Signals       `yaml:"signals"`
+	Discovery       Discovery     `yaml:"discovery"`
+	Digest          Digest        `yaml:"digest"`
+	Leader          Leader        `yaml:"leader"`
+	Access          Access        `yaml:"access"`
+	Templates       Templates     `yaml:"templates"`
+	Outbox          Outbox        `yaml:"outbox"`
+	Notifications   Notifications `yaml:"notifications"`
+	Broadcast       Broadcast     `yaml:"broadcast"`
 }
 
 func Load(path string) (*Config, error) {
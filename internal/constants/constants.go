@@ -1,15 +1,36 @@
 package constants
 
 const (
-	ODD_FAVORITE_MAX      = float64(1.31)
-	LIVE_ODD_FAVORITE_MAX = float64(1.25)
-	FAVORITE_IS_HOME      = "home"
-	FAVORITE_IS_AWAY      = "away"
-	WINNER_HOME           = "home"
-	WINNER_AWAY           = "away"
-	COUNTRIES             = "Italy,Poland,Russia,Germany,Greece,Portugal,Romania,Serbia,Turkey,Ukraine,France,Croatia,Sweden,Spain,Finland"
-	WOMEN                 = "Women"
-	SPECIFIC_COUNTRIES    = "Serbia,Ukraine,Spain,Finland"
-	LEAGUES               = "Italy Cup,Italy A1,Italy A2,Russia Super League,Russia Cup,France Pro A,France Super League,Germany Bundesliga,Poland Plus Liga,Champions League,Turkey Efeler League,Turkey Cup"
-	SPECIFIC_LEAGUES      = "Italy A2,Russia Cup,Poland Plus Liga,Turkey Efeler League,Turkey Cup"
+	ODD_FAVORITE_MAX       = float64(1.31)
+	LIVE_ODD_FAVORITE_MAX  = float64(1.25)
+	LIVE_ODD_VALUE_MIN     = float64(1.5)
+	FAVORITE_IS_HOME       = "home"
+	FAVORITE_IS_AWAY       = "away"
+	WINNER_HOME            = "home"
+	WINNER_AWAY            = "away"
+	COUNTRY_CODES          = "it,pl,ru,de,gr,pt,ro,rs,tr,ua,fr,hr,se,es,fi"
+	MEN_ONLY_COUNTRY_CODES = "rs,ua,es,fi"
+	GENDER_MEN             = "men"
+	GENDER_WOMEN           = "women"
+	LEAGUE_TIER_MAX        = 2
+	LEAGUE_TIER_WOMEN_MAX  = 1
+	WOMEN                  = "Women"
+	YOUTH_MARKERS          = "U17,U18,U19,U20,U21,U23,Youth,Junior"
+	LEAGUES_TIER_1         = "Italy A1,Russia Super League,France Pro A,France Super League,Germany Bundesliga,Poland Plus Liga,Champions League,Turkey Efeler League"
+	LEAGUES_TIER_2         = "Italy Cup,Italy A2,Russia Cup,Turkey Cup"
+	STRATEGY_SECOND_SET    = "second_set"
+	SIGNAL_TYPE_BET        = "bet"
+	SIGNAL_TYPE_DRIFT      = "odds_drift"
+	SIGNAL_TYPE_FLIP       = "odds_flip"
+	SIGNAL_TYPE_CANCEL     = "cancel"
+	SIGNAL_TYPE_UPDATE     = "update"
+	TIME_FORMAT            = "02 Jan 06 15:04 MST"
+	RULE_MONITOR_EVENT     = "monitor_event"
+	RULE_SKIP_EVENT        = "skip_event"
+	RULE_BAN_TEAM          = "ban_team"
+	RULE_BAN_LEAGUE        = "ban_league"
+	REPORT_DAILY           = "daily"
+	REPORT_WEEKLY          = "weekly"
+	REPORT_DIGEST          = "digest"
+	REPORT_TYPES           = "daily,weekly,digest"
 )
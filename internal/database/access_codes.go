@@ -0,0 +1,343 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+	"github.com/jackc/pgx/v4"
+	"github.com/reconquest/karma-go"
+)
+
+const (
+	ACCESS_CODE_BYTES = 10
+)
+
+var (
+	ErrAccessCodeNotFound = errors.New("access code not found")
+	ErrAccessCodeExpired  = errors.New("access code expired")
+	ErrAccessCodeUsedUp   = errors.New("access code has been used maximum number of times")
+)
+
+// AccessCode with MaxUses equal to zero can be redeemed unlimited number of
+// times until it expires. Access given by the code lasts AccessDays since
+// redemption.
+type AccessCode struct {
+	Code        string                 `json:"code"`
+	MaxUses     int                    `json:"max_uses"`
+	AccessDays  int                    `json:"access_days"`
+	ExpiresAt   time.Time              `json:"expires_at"`
+	CreatedBy   int64                  `json:"created_by"`
+	CreatedAt   time.Time              `json:"created_at"`
+	Redemptions []AccessCodeRedemption `json:"redemptions"`
+}
+
+type AccessCodeRedemption struct {
+	Code       string    `json:"code"`
+	ChatID     int64     `json:"chat_id"`
+	RedeemedAt time.Time `json:"redeemed_at"`
+}
+
+// NewAccessCode generates code which can be redeemed during validity.
+func NewAccessCode(
+	accessDays int,
+	maxUses int,
+	validity time.Duration,
+	createdBy int64,
+) (AccessCode, error) {
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return AccessCode{}, karma.Format(
+			err,
+			"unable to get current time before creating access code",
+		)
+	}
+
+	data := make([]byte, ACCESS_CODE_BYTES)
+	_, err = rand.Read(data)
+	if err != nil {
+		return AccessCode{}, karma.Format(
+			err,
+			"unable to generate access code",
+		)
+	}
+
+	return AccessCode{
+		Code:       base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(data),
+		MaxUses:    maxUses,
+		AccessDays: accessDays,
+		ExpiresAt:  timeNow.Add(validity),
+		CreatedBy:  createdBy,
+	}, nil
+}
+
+func (database *Database) InsertAccessCode(code AccessCode) error {
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to get current time before inserting access code",
+		)
+	}
+
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_INSERT_ACCESS_CODE,
+		code.Code,
+		code.MaxUses,
+		code.AccessDays,
+		code.ExpiresAt,
+		code.CreatedBy,
+		timeNow,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to add access code to the database",
+		)
+	}
+
+	return nil
+}
+
+// RedeemAccessCode checks the code and activates subscriber until access end
+// in one transaction, so multi-use codes can't be redeemed more times than
+// allowed. Subscriber who redeemed the code before is activated again until
+// the same access end without using it up.
+func (database *Database) RedeemAccessCode(code string, subscriber Subscriber) (time.Time, error) {
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return time.Time{}, karma.Format(
+			err,
+			"unable to get current time before redeeming access code",
+		)
+	}
+
+	ctx := context.Background()
+	tx, err := database.client.Begin(ctx)
+	if err != nil {
+		return time.Time{}, karma.Format(
+			err,
+			"unable to begin transaction for access code",
+		)
+	}
+
+	defer tx.Rollback(ctx)
+
+	var accessCode AccessCode
+	err = tx.QueryRow(ctx, SQL_SELECT_ACCESS_CODE_FOR_UPDATE, code).Scan(
+		&accessCode.Code,
+		&accessCode.MaxUses,
+		&accessCode.AccessDays,
+		&accessCode.ExpiresAt,
+		&accessCode.CreatedBy,
+		&accessCode.CreatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return time.Time{}, ErrAccessCodeNotFound
+		}
+
+		return time.Time{}, karma.Format(
+			err,
+			"unable to get access code from the database",
+		)
+	}
+
+	redemptions, err := scanAccessCodeRedemptions(
+		tx.Query(ctx, SQL_SELECT_ACCESS_CODE_REDEMPTIONS_BY_CODE, code),
+	)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	err = CheckAccessCode(accessCode, redemptions, subscriber.ChatID, timeNow)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	expiresAt := GetAccessExpiry(accessCode, redemptions, subscriber.ChatID, timeNow)
+
+	_, err = tx.Exec(ctx, SQL_INSERT_ACCESS_CODE_REDEMPTION, code, subscriber.ChatID, timeNow)
+	if err != nil {
+		return time.Time{}, karma.Format(
+			err,
+			"unable to add access code redemption to the database",
+		)
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		SQL_UPSERT_TELEGRAM_SUBSCRIBER,
+		subscriber.ChatID,
+		subscriber.Type,
+		subscriber.Username,
+		timeNow,
+	)
+	if err != nil {
+		return time.Time{}, karma.Format(
+			err,
+			"unable to add subscriber to the database, chat_id: %d",
+			subscriber.ChatID,
+		)
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		SQL_UPDATE_TELEGRAM_SUBSCRIBER_SECRET_KEY,
+		subscriber.ChatID,
+		code,
+		expiresAt,
+		timeNow,
+	)
+	if err != nil {
+		return time.Time{}, karma.Format(
+			err,
+			"unable to update secret key of subscriber, chat_id: %d",
+			subscriber.ChatID,
+		)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return time.Time{}, karma.Format(
+			err,
+			"unable to commit access code redemption",
+		)
+	}
+
+	return expiresAt, nil
+}
+
+func (database *Database) GetAccessCodes() ([]AccessCode, error) {
+	ctx := context.Background()
+	rows, err := database.client.Query(ctx, SQL_SELECT_ACCESS_CODES)
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to get access codes from the database",
+		)
+	}
+
+	defer rows.Close()
+
+	var codes []AccessCode
+	for rows.Next() {
+		var code AccessCode
+		err := rows.Scan(
+			&code.Code,
+			&code.MaxUses,
+			&code.AccessDays,
+			&code.ExpiresAt,
+			&code.CreatedBy,
+			&code.CreatedAt,
+		)
+		if err != nil {
+			return nil, karma.Format(
+				err,
+				"error during scaning access codes from database rows",
+			)
+		}
+
+		codes = append(codes, code)
+	}
+
+	redemptions, err := scanAccessCodeRedemptions(
+		database.client.Query(ctx, SQL_SELECT_ACCESS_CODE_REDEMPTIONS),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range codes {
+		for _, redemption := range redemptions {
+			if redemption.Code == codes[i].Code {
+				codes[i].Redemptions = append(codes[i].Redemptions, redemption)
+			}
+		}
+	}
+
+	return codes, nil
+}
+
+// CheckAccessCode returns nil if code can be redeemed by the chat.
+func CheckAccessCode(
+	code AccessCode,
+	redemptions []AccessCodeRedemption,
+	chatID int64,
+	timeNow time.Time,
+) error {
+	for _, redemption := range redemptions {
+		if redemption.ChatID != chatID {
+			continue
+		}
+
+		if !GetAccessExpiry(code, redemptions, chatID, timeNow).After(timeNow) {
+			return ErrAccessCodeExpired
+		}
+
+		return nil
+	}
+
+	if !code.ExpiresAt.After(timeNow) {
+		return ErrAccessCodeExpired
+	}
+
+	if code.MaxUses > 0 && len(redemptions) >= code.MaxUses {
+		return ErrAccessCodeUsedUp
+	}
+
+	return nil
+}
+
+// GetAccessExpiry returns end of access given by the code to the chat, access
+// starts at the first redemption of the code by the chat.
+func GetAccessExpiry(
+	code AccessCode,
+	redemptions []AccessCodeRedemption,
+	chatID int64,
+	timeNow time.Time,
+) time.Time {
+	start := timeNow
+	for _, redemption := range redemptions {
+		if redemption.ChatID == chatID && redemption.RedeemedAt.Before(start) {
+			start = redemption.RedeemedAt
+		}
+	}
+
+	return start.AddDate(0, 0, code.AccessDays)
+}
+
+func scanAccessCodeRedemptions(rows pgx.Rows, err error) ([]AccessCodeRedemption, error) {
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to get access code redemptions from the database",
+		)
+	}
+
+	defer rows.Close()
+
+	var redemptions []AccessCodeRedemption
+	for rows.Next() {
+		var redemption AccessCodeRedemption
+		err := rows.Scan(
+			&redemption.Code,
+			&redemption.ChatID,
+			&redemption.RedeemedAt,
+		)
+		if err != nil {
+			return nil, karma.Format(
+				err,
+				"error during scaning access code redemptions from database rows",
+			)
+		}
+
+		redemptions = append(redemptions, redemption)
+	}
+
+	return redemptions, rows.Err()
+}
@@ -0,0 +1,239 @@
+package database
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/requester"
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+	"github.com/jackc/pgx/v4"
+	"github.com/reconquest/karma-go"
+)
+
+const (
+	BET_STATUS_OPEN = "open"
+	BET_STATUS_WON  = "won"
+	BET_STATUS_LOST = "lost"
+
+	BET_DEFAULT_STAKE = 1
+)
+
+// Bet is a bet on the favorite placed by user after signal, stake is
+// measured in units chosen by user.
+type Bet struct {
+	ID        int64      `json:"id"`
+	ChatID    int64      `json:"chat_id"`
+	UserID    int64      `json:"user_id"`
+	EventID   string     `json:"event_id"`
+	Favorite  string     `json:"favorite"`
+	Stake     float64    `json:"stake"`
+	Odd       float64    `json:"odd"`
+	Status    string     `json:"status"`
+	Profit    float64    `json:"profit"`
+	CreatedAt time.Time  `json:"created_at"`
+	SettledAt *time.Time `json:"settled_at"`
+}
+
+// SettleBet applies the same rules as SQL_SETTLE_OPEN_BETS query.
+func SettleBet(bet Bet, winner string, settledAt time.Time) Bet {
+	if bet.Favorite == winner {
+		bet.Status = BET_STATUS_WON
+		bet.Profit = bet.Stake * (bet.Odd - 1)
+	} else {
+		bet.Status = BET_STATUS_LOST
+		bet.Profit = -bet.Stake
+	}
+
+	bet.SettledAt = &settledAt
+	return bet
+}
+
+func (database *Database) GetLiveEventResult(eventID string) (*requester.LiveEventResult, error) {
+	var (
+		result      requester.LiveEventResult
+		lastHomeOdd string
+		lastAwayOdd string
+		winner      *string
+	)
+	err := database.client.QueryRow(
+		context.Background(),
+		SQL_SELECT_LIVE_EVENT_RESULT_BY_EVENT_ID,
+		eventID,
+	).Scan(
+		&result.EventID,
+		&lastHomeOdd,
+		&lastAwayOdd,
+		&result.Score,
+		&winner,
+		&result.Favorite,
+		&result.CreatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, karma.Format(
+			err,
+			"unable to get live event result, event_id: %s",
+			eventID,
+		)
+	}
+
+	if winner != nil {
+		result.WinnerInSecondSet = *winner
+	}
+
+	result.LastHomeOdd, err = strconv.ParseFloat(lastHomeOdd, 64)
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to parse home odd",
+		)
+	}
+
+	result.LastAwayOdd, err = strconv.ParseFloat(lastAwayOdd, 64)
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to parse away odd",
+		)
+	}
+
+	return &result, nil
+}
+
+func (database *Database) InsertBet(bet Bet) (bool, error) {
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return false, karma.Format(
+			err,
+			"unable to get current time before inserting bet",
+		)
+	}
+
+	tag, err := database.client.Exec(
+		context.Background(),
+		SQL_INSERT_BET,
+		bet.ChatID,
+		bet.UserID,
+		bet.EventID,
+		bet.Favorite,
+		bet.Stake,
+		bet.Odd,
+		BET_STATUS_OPEN,
+		timeNow,
+	)
+	if err != nil {
+		return false, karma.Format(
+			err,
+			"unable to add bet to the database, user_id: %d, event_id: %s",
+			bet.UserID,
+			bet.EventID,
+		)
+	}
+
+	return tag.RowsAffected() != 0, nil
+}
+
+func (database *Database) UpdateBetStakeAndOdd(
+	userID int64,
+	eventID string,
+	stake float64,
+	odd float64,
+) error {
+	_, err := database.client.Exec(
+		context.Background(),
+		SQL_UPDATE_BET_STAKE_AND_ODD,
+		userID,
+		eventID,
+		stake,
+		odd,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to update bet, user_id: %d, event_id: %s",
+			userID,
+			eventID,
+		)
+	}
+
+	return nil
+}
+
+// SettleOpenBets settles open bets of all events which have winner of the
+// second set in live_events_results.
+func (database *Database) SettleOpenBets() ([]Bet, error) {
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to get current time before settling bets",
+		)
+	}
+
+	rows, err := database.client.Query(
+		context.Background(),
+		SQL_SETTLE_OPEN_BETS,
+		timeNow,
+	)
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to settle open bets",
+		)
+	}
+
+	return scanBets(rows)
+}
+
+func (database *Database) GetBets(userID int64) ([]Bet, error) {
+	rows, err := database.client.Query(
+		context.Background(),
+		SQL_SELECT_BETS_BY_USER_ID,
+		userID,
+	)
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to get bets of the user: %d",
+			userID,
+		)
+	}
+
+	return scanBets(rows)
+}
+
+func scanBets(rows pgx.Rows) ([]Bet, error) {
+	defer rows.Close()
+
+	var bets []Bet
+	for rows.Next() {
+		var bet Bet
+		err := rows.Scan(
+			&bet.ID,
+			&bet.ChatID,
+			&bet.UserID,
+			&bet.EventID,
+			&bet.Favorite,
+			&bet.Stake,
+			&bet.Odd,
+			&bet.Status,
+			&bet.Profit,
+			&bet.CreatedAt,
+			&bet.SettledAt,
+		)
+		if err != nil {
+			return nil, karma.Format(
+				err,
+				"error during scaning bets from database rows",
+			)
+		}
+
+		bets = append(bets, bet)
+	}
+
+	return bets, rows.Err()
+}
@@ -0,0 +1,107 @@
+package database
+
+import (
+	"context"
+
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+	"github.com/jackc/pgx/v4"
+	"github.com/reconquest/karma-go"
+)
+
+func (database *Database) SetChatTimezone(chatID int64, timezone string) error {
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to get current time before updating chat timezone",
+		)
+	}
+
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_UPSERT_CHAT_TIMEZONE,
+		chatID,
+		timezone,
+		timeNow,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to update timezone of the chat: %d",
+			chatID,
+		)
+	}
+
+	return nil
+}
+
+func (database *Database) GetChatTimezone(chatID int64) (string, error) {
+	var timezone string
+	err := database.client.QueryRow(
+		context.Background(),
+		SQL_SELECT_CHAT_TIMEZONE,
+		chatID,
+	).Scan(&timezone)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return "", nil
+		}
+
+		return "", karma.Format(
+			err,
+			"unable to get timezone of the chat: %d",
+			chatID,
+		)
+	}
+
+	return timezone, nil
+}
+
+func (database *Database) SetChatLanguage(chatID int64, language string) error {
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to get current time before updating chat language",
+		)
+	}
+
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_UPSERT_CHAT_LANGUAGE,
+		chatID,
+		language,
+		timeNow,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to update language of the chat: %d",
+			chatID,
+		)
+	}
+
+	return nil
+}
+
+func (database *Database) GetChatLanguage(chatID int64) (string, error) {
+	var language string
+	err := database.client.QueryRow(
+		context.Background(),
+		SQL_SELECT_CHAT_LANGUAGE,
+		chatID,
+	).Scan(&language)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return "", nil
+		}
+
+		return "", karma.Format(
+			err,
+			"unable to get language of the chat: %d",
+			chatID,
+		)
+	}
+
+	return language, nil
+}
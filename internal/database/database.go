@@ -10,6 +10,8 @@ import (
 
 	"github.com/daniilsolovey/BetBotGo/internal/requester"
 	"github.com/daniilsolovey/BetBotGo/internal/tools"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 
 	"github.com/reconquest/karma-go"
@@ -18,15 +20,98 @@ import (
 
 const (
 	ERR_CODE_TABLE_ALREADY_EXISTS = "#42P07"
+	LEGACY_TIMEZONE               = "Europe/Moscow"
 )
 
+var TIMESTAMP_COLUMNS = [][2]string{
+	{"events_volleyball", "event_time"},
+	{"live_events_results", "created_at"},
+	{"statistic_on_previous_day", "created_at"},
+	{"events_odds_history", "created_at"},
+	{"signals", "created_at"},
+	{"leagues", "created_at"},
+	{"leagues", "updated_at"},
+}
+
+type DatabaseInterface interface {
+	InsertEventsForToday([]requester.EventWithOdds) error
+	GetEventByID(string) (*requester.EventWithOdds, error)
+	GetUpcomingEventsForDay(time.Time) ([]requester.EventWithOdds, error)
+	InsertEventOddsHistory(string, float64, float64) error
+	SendSignalOnce(string, string, string, func(DatabaseInterface) error) (bool, error)
+	InTransaction(func(DatabaseInterface) error) error
+	InsertLiveEventResult(requester.EventWithOdds) error
+	UpdateLiveEventsResultsScoreAndWinnerFields(string, string, string) error
+	GetLiveEventsResultsOnPreviousDate() ([]requester.LiveEventResult, error)
+	InsertEventsResultsToStatistic([]requester.LiveEventResult) error
+	GetStatisticOnPreviousWeek() ([]StatisticResultOfPreviousDay, error)
+	InsertLeagues([]League) error
+	GetLeagues() ([]League, error)
+	SetChatTimezone(int64, string) error
+	GetChatTimezone(int64) (string, error)
+	SetChatLanguage(int64, string) error
+	GetChatLanguage(int64) (string, error)
+	InsertEventRule(EventRule) error
+	DeleteEventRule(string, string) error
+	GetEventRules() ([]EventRule, error)
+	InsertAdminAction(AdminAction) error
+	UpsertSubscriber(Subscriber) error
+	DeactivateSubscriber(int64) (bool, error)
+	GetActiveSubscribers() ([]Subscriber, error)
+	GetSubscriber(int64) (*Subscriber, error)
+	GetSubscribersToRemind(time.Time) ([]Subscriber, error)
+	MarkSubscriberReminded(int64) error
+	DeactivateExpiredSubscribers() ([]Subscriber, error)
+	InsertAccessCode(AccessCode) error
+	RedeemAccessCode(string, Subscriber) (time.Time, error)
+	GetSubscriberPreferences(int64) (Preferences, error)
+	SetSubscriberPreferences(Preferences) error
+	InsertSignalDelivery(SignalDelivery) error
+	CountSignalDeliveries(int64, time.Time) (int, error)
+	GetLiveEventResult(string) (*requester.LiveEventResult, error)
+	InsertBet(Bet) (bool, error)
+	UpdateBetStakeAndOdd(int64, string, float64, float64) error
+	SettleOpenBets() ([]Bet, error)
+	GetBets(int64) ([]Bet, error)
+	GetLiveEventsResults(time.Time, time.Time) ([]requester.LiveEventResult, error)
+	GetLastLiveEventsResults(int) ([]requester.LiveEventResult, error)
+	EnqueueOutboxMessage(OutboxMessage) (int64, error)
+	GetPendingOutboxMessages(time.Time, int) ([]OutboxMessage, error)
+	MarkOutboxMessageSent(int64, int, time.Time) error
+	MarkOutboxMessageRetry(int64, time.Time, string) error
+	MarkOutboxMessageFailed(int64, string) error
+	CountOutboxMessages() (map[string]int, error)
+	DeleteOldOutboxMessages(time.Time) (int64, error)
+	InsertOutboxPhoto([]byte, time.Time) (int64, error)
+	SetOutboxPhotoFileID(int64, string) error
+	DeleteUnusedOutboxPhotos(time.Time) (int64, error)
+	GetUserRole(int64) (string, error)
+	SetUserRole(UserRole) error
+	AddUserRole(UserRole) error
+	SetOwner(int64) error
+	GetUserRoles() ([]UserRole, error)
+	GetDigestEventIDs(int64, time.Time) ([]string, error)
+	InsertDigestEvents(int64, []string, time.Time) error
+	InsertReportDelivery(string, time.Time, time.Time) (bool, error)
+}
+
 type Database struct {
 	name     string
 	host     string
 	port     string
 	user     string
 	password string
-	client   *pgxpool.Pool
+	pool     *pgxpool.Pool
+	client   client
+}
+
+// client runs queries either on the pool or in the transaction, see
+// InTransaction.
+type client interface {
+	Begin(context.Context) (pgx.Tx, error)
+	Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error)
+	Query(context.Context, string, ...interface{}) (pgx.Rows, error)
+	QueryRow(context.Context, string, ...interface{}) pgx.Row
 }
 
 func NewDatabase(
@@ -44,6 +129,7 @@ func NewDatabase(
 		log.Fatal(err)
 	}
 
+	database.pool = connection
 	database.client = connection
 
 	return database
@@ -73,7 +159,7 @@ func (database *Database) connect() (*pgxpool.Pool, error) {
 }
 
 func (database *Database) Close() error {
-	database.client.Close()
+	database.pool.Close()
 	return nil
 }
 
@@ -122,6 +208,289 @@ func (database *Database) CreateTables() error {
 	}
 
 	log.Info("statistic_on_current_day table successfully created")
+
+	log.Info("creating events_odds_history table")
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_CREATE_TABLE_EVENTS_ODDS_HISTORY,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to create events_odds_history table in the database",
+		)
+	}
+
+	log.Info("events_odds_history table successfully created")
+
+	log.Info("creating signals table")
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_CREATE_TABLE_SIGNALS,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to create signals table in the database",
+		)
+	}
+
+	log.Info("signals table successfully created")
+
+	log.Info("creating leagues table")
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_CREATE_TABLE_LEAGUES,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to create leagues table in the database",
+		)
+	}
+
+	log.Info("leagues table successfully created")
+
+	log.Info("creating chat_settings table")
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_CREATE_TABLE_CHAT_SETTINGS,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to create chat_settings table in the database",
+		)
+	}
+
+	log.Info("chat_settings table successfully created")
+
+	log.Info("creating event_rules table")
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_CREATE_TABLE_EVENT_RULES,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to create event_rules table in the database",
+		)
+	}
+
+	log.Info("event_rules table successfully created")
+
+	log.Info("creating telegram_subscribers table")
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_CREATE_TABLE_TELEGRAM_SUBSCRIBERS,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to create telegram_subscribers table in the database",
+		)
+	}
+
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_ALTER_TABLE_TELEGRAM_SUBSCRIBERS_ADD_SECRET_KEY,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to add secret_key columns to telegram_subscribers table",
+		)
+	}
+
+	log.Info("telegram_subscribers table successfully created")
+
+	log.Info("creating access_codes tables")
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_CREATE_TABLE_ACCESS_CODES,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to create access_codes table in the database",
+		)
+	}
+
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_CREATE_TABLE_ACCESS_CODE_REDEMPTIONS,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to create access_code_redemptions table in the database",
+		)
+	}
+
+	log.Info("access_codes tables successfully created")
+
+	log.Info("creating subscriber_preferences table")
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_CREATE_TABLE_SUBSCRIBER_PREFERENCES,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to create subscriber_preferences table in the database",
+		)
+	}
+
+	log.Info("subscriber_preferences table successfully created")
+
+	log.Info("creating signal_deliveries table")
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_CREATE_TABLE_SIGNAL_DELIVERIES,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to create signal_deliveries table in the database",
+		)
+	}
+
+	log.Info("signal_deliveries table successfully created")
+
+	log.Info("creating outbox table")
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_CREATE_TABLE_OUTBOX,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to create outbox table in the database",
+		)
+	}
+
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_CREATE_TABLE_OUTBOX_PHOTOS,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to create outbox_photos table in the database",
+		)
+	}
+
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_CREATE_INDEX_OUTBOX_CHAT_PENDING,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to create index of pending messages by chat in outbox table",
+		)
+	}
+
+	log.Info("outbox table successfully created")
+
+	log.Info("creating bets table")
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_CREATE_TABLE_BETS,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to create bets table in the database",
+		)
+	}
+
+	log.Info("bets table successfully created")
+
+	log.Info("creating admin_actions table")
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_CREATE_TABLE_ADMIN_ACTIONS,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to create admin_actions table in the database",
+		)
+	}
+
+	log.Info("admin_actions table successfully created")
+
+	log.Info("creating user_roles table")
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_CREATE_TABLE_USER_ROLES,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to create user_roles table in the database",
+		)
+	}
+
+	log.Info("user_roles table successfully created")
+
+	log.Info("creating digest_deliveries table")
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_CREATE_TABLE_DIGEST_DELIVERIES,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to create digest_deliveries table in the database",
+		)
+	}
+
+	log.Info("digest_deliveries table successfully created")
+
+	log.Info("creating report_deliveries table")
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_CREATE_TABLE_REPORT_DELIVERIES,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to create report_deliveries table in the database",
+		)
+	}
+
+	log.Info("report_deliveries table successfully created")
+
+	err = database.migrateTimestampColumns()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (database *Database) migrateTimestampColumns() error {
+	for _, column := range TIMESTAMP_COLUMNS {
+		_, err := database.client.Exec(
+			context.Background(),
+			fmt.Sprintf(
+				SQL_MIGRATE_COLUMN_TO_TIMESTAMPTZ,
+				column[0],
+				column[1],
+				LEGACY_TIMEZONE,
+			),
+		)
+		if err != nil {
+			return karma.Format(
+				err,
+				"unable to migrate column %s.%s to timestamptz",
+				column[0], column[1],
+			)
+		}
+	}
+
 	return nil
 }
 
@@ -196,11 +565,11 @@ func (database *Database) InsertEventsResultsToStatistic(events []requester.Live
 		return nil
 	}
 
-	timeNow, err := tools.GetCurrentMoscowTime()
+	timeNow, err := tools.GetCurrentTime()
 	if err != nil {
 		return karma.Format(
 			err,
-			"unable to get current moscow time before inserting events results to statistic",
+			"unable to get current time before inserting events results to statistic",
 		)
 	}
 
@@ -275,7 +644,7 @@ func (database *Database) InsertLiveEventResult(event requester.EventWithOdds) e
 		"inserting live event result in database",
 	)
 
-	timeNow, err := tools.GetCurrentMoscowTime()
+	timeNow, err := tools.GetCurrentTime()
 	if err != nil {
 		return karma.Format(
 			err,
@@ -312,17 +681,20 @@ func (database *Database) InsertLiveEventResult(event requester.EventWithOdds) e
 
 func (database *Database) GetLiveEventsResultsOnPreviousDate() ([]requester.LiveEventResult, error) {
 	log.Info("receiving live events results on previous date before inserting it to statistic")
-	timeNow, err := tools.GetCurrentMoscowTime()
+	timeNow, err := tools.GetCurrentTime()
 	if err != nil {
 		return nil, karma.Format(
 			err,
-			"unable to get current moscow time for receive events results on previous date",
+			"unable to get current time for receive events results on previous date",
 		)
 	}
+
+	previousDay := tools.BeginningOfDay(timeNow.AddDate(0, 0, -1))
 	rows, err := database.client.Query(
 		context.Background(),
 		SQL_SELECT_LIVE_EVENTS_AT_END_OF_DAY,
-		timeNow.Add(-24*time.Hour),
+		previousDay,
+		previousDay.AddDate(0, 0, 1),
 	)
 
 	if err != nil {
@@ -392,18 +764,18 @@ func (database *Database) GetLiveEventsResultsOnPreviousDate() ([]requester.Live
 
 func (database *Database) GetStatisticOnPreviousWeek() ([]StatisticResultOfPreviousDay, error) {
 	log.Info("receiving live events results on previous week")
-	timeNow, err := tools.GetCurrentMoscowTime()
+	timeNow, err := tools.GetCurrentTime()
 	if err != nil {
 		return nil, karma.Format(
 			err,
-			"unable to get current moscow time for receive statistic on previous week",
+			"unable to get current time for receive statistic on previous week",
 		)
 	}
 
 	rows, err := database.client.Query(
 		context.Background(),
 		SQL_SELECT_STATISTICS_OF_PREVIOUS_WEEK,
-		timeNow.Add(-24*time.Hour*7),
+		tools.BeginningOfDay(timeNow.AddDate(0, 0, -7)),
 	)
 
 	if err != nil {
@@ -451,21 +823,26 @@ func (database *Database) GetStatisticOnPreviousWeek() ([]StatisticResultOfPrevi
 
 func (database *Database) GetUpcomingEventsForToday() ([]requester.EventWithOdds, error) {
 	log.Info("receiving upcoming events for today for viewing in handler")
-	timeNow, err := tools.GetCurrentMoscowTime()
+	timeNow, err := tools.GetCurrentTime()
 	if err != nil {
 		return nil, karma.Format(
 			err,
-			"unable to get current moscow time for receive upcoming_events_handler for today",
+			"unable to get current time for receive upcoming_events_handler for today",
 		)
 	}
 
+	return database.GetUpcomingEventsForDay(timeNow)
+}
+
+func (database *Database) GetUpcomingEventsForDay(day time.Time) ([]requester.EventWithOdds, error) {
+	beginningOfDay := tools.BeginningOfDay(day)
 	rows, err := database.client.Query(
 		context.Background(),
-		SQL_SELECT_UPCOMING_EVENTS_FOR_CURRENT_DAY,
-		timeNow,
+		SQL_SELECT_UPCOMING_EVENTS_FOR_DAY,
+		beginningOfDay,
+		beginningOfDay.AddDate(0, 0, 1),
 	)
 
-	log.Warning("timeNOw ", timeNow)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -473,7 +850,8 @@ func (database *Database) GetUpcomingEventsForToday() ([]requester.EventWithOdds
 
 		return nil, karma.Format(
 			err,
-			"unable to get upcoming_events_handler for current day from the database",
+			"unable to get upcoming_events_handler for day %s from the database",
+			beginningOfDay.Format("2006-01-02"),
 		)
 	}
 
@@ -500,7 +878,7 @@ func (database *Database) GetUpcomingEventsForToday() ([]requester.EventWithOdds
 		if err != nil {
 			return nil, karma.Format(
 				err,
-				"error during scaning upcoming_events_handler for current day from database rows",
+				"error during scaning upcoming_events_handler for day from database rows",
 			)
 		}
 
@@ -511,3 +889,159 @@ func (database *Database) GetUpcomingEventsForToday() ([]requester.EventWithOdds
 
 	return results, nil
 }
+
+func (database *Database) GetEventByID(eventID string) (*requester.EventWithOdds, error) {
+	var event requester.EventWithOdds
+	err := database.client.QueryRow(
+		context.Background(),
+		SQL_SELECT_EVENT_BY_ID,
+		eventID,
+	).Scan(
+		&event.EventID,
+		&event.EventStartTime,
+		&event.League.ID,
+		&event.League.Name,
+		&event.Favorite,
+		&event.HomeCommandName,
+		&event.AwayCommandName,
+		&event.HomeOdd,
+		&event.AwayOdd,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, karma.Format(
+			err,
+			"unable to get event from the database, event_id: %s",
+			eventID,
+		)
+	}
+
+	return &event, nil
+}
+
+func (database *Database) InsertEventOddsHistory(eventID string, homeOdd, awayOdd float64) error {
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to get current time before inserting data to events_odds_history",
+		)
+	}
+
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_INSERT_EVENTS_ODDS_HISTORY,
+		eventID,
+		homeOdd,
+		awayOdd,
+		timeNow,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to add odds history to the database, event_id: %s",
+			eventID,
+		)
+	}
+
+	return nil
+}
+
+// InTransaction runs queries of the given function in one transaction, so
+// e.g. signal and outbox messages are committed or rolled back together.
+// Transaction is rolled back when function returns error.
+func (database *Database) InTransaction(do func(DatabaseInterface) error) error {
+	return database.inTransaction(func(tx *Database) error {
+		return do(tx)
+	})
+}
+
+func (database *Database) inTransaction(do func(*Database) error) error {
+	ctx := context.Background()
+	tx, err := database.client.Begin(ctx)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to begin transaction",
+		)
+	}
+
+	defer tx.Rollback(ctx)
+
+	err = do(&Database{
+		name:   database.name,
+		pool:   database.pool,
+		client: tx,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to commit transaction",
+		)
+	}
+
+	return nil
+}
+
+// SendSignalOnce reserves signal in the same transaction in which send queues
+// messages to outbox, so concurrent instances wait for the unique key and skip
+// signal which has been sent already. Reservation and queued messages are
+// rolled back if sending is failed, send must not do anything except queries
+// of the given database.
+func (database *Database) SendSignalOnce(
+	eventID, strategy, signalType string,
+	send func(DatabaseInterface) error,
+) (bool, error) {
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return false, karma.Format(
+			err,
+			"unable to get current time before inserting signal",
+		)
+	}
+
+	var sent bool
+	err = database.inTransaction(func(tx *Database) error {
+		var id int
+		err := tx.client.QueryRow(
+			context.Background(),
+			SQL_INSERT_SIGNAL,
+			eventID,
+			strategy,
+			signalType,
+			timeNow,
+		).Scan(&id)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return nil
+			}
+
+			return karma.Format(
+				err,
+				"unable to add signal to the database, event_id: %s, signal_type: %s",
+				eventID, signalType,
+			)
+		}
+
+		err = send(tx)
+		if err != nil {
+			return err
+		}
+
+		sent = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return sent, nil
+}
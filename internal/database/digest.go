@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/reconquest/karma-go"
+)
+
+// GetDigestEventIDs returns events which have been sent in digest to the chat
+// since the given time.
+func (database *Database) GetDigestEventIDs(chatID int64, since time.Time) ([]string, error) {
+	rows, err := database.client.Query(
+		context.Background(),
+		SQL_SELECT_DIGEST_EVENT_IDS,
+		chatID,
+		since,
+	)
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to get events sent in digest to chat %d since %s",
+			chatID,
+			since,
+		)
+	}
+
+	defer rows.Close()
+
+	var eventIDs []string
+	for rows.Next() {
+		var eventID string
+		err := rows.Scan(&eventID)
+		if err != nil {
+			return nil, karma.Format(
+				err,
+				"error during scaning digest events from database rows",
+			)
+		}
+
+		eventIDs = append(eventIDs, eventID)
+	}
+
+	return eventIDs, rows.Err()
+}
+
+// InsertDigestEvents marks events as sent in digest to the chat, so they are
+// not sent again in updates.
+func (database *Database) InsertDigestEvents(
+	chatID int64,
+	eventIDs []string,
+	sentAt time.Time,
+) error {
+	_, err := database.client.Exec(
+		context.Background(),
+		SQL_INSERT_DIGEST_DELIVERIES,
+		chatID,
+		eventIDs,
+		sentAt,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to mark %d events as sent in digest to chat %d",
+			len(eventIDs),
+			chatID,
+		)
+	}
+
+	return nil
+}
+
+// InsertReportDelivery marks the report on the period as sent, false is
+// returned when it has been sent already.
+func (database *Database) InsertReportDelivery(
+	report string,
+	periodStart time.Time,
+	sentAt time.Time,
+) (bool, error) {
+	var inserted string
+	err := database.client.QueryRow(
+		context.Background(),
+		SQL_INSERT_REPORT_DELIVERY,
+		report,
+		periodStart,
+		sentAt,
+	).Scan(&inserted)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+
+		return false, karma.Format(
+			err,
+			"unable to mark %s report on period since %s as sent",
+			report,
+			periodStart,
+		)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,180 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+	"github.com/reconquest/karma-go"
+)
+
+type EventRule struct {
+	ID        int       `json:"id"`
+	Kind      string    `json:"kind"`
+	Value     string    `json:"value"`
+	CreatedBy int64     `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AdminAction struct {
+	ID        int       `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Username  string    `json:"username"`
+	Command   string    `json:"command"`
+	Argument  string    `json:"argument"`
+	Result    string    `json:"result"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (database *Database) InsertEventRule(rule EventRule) error {
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to get current time before inserting event rule",
+		)
+	}
+
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_INSERT_EVENT_RULE,
+		rule.Kind,
+		rule.Value,
+		rule.CreatedBy,
+		timeNow,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to add event rule to the database, kind: %s, value: %s",
+			rule.Kind, rule.Value,
+		)
+	}
+
+	return nil
+}
+
+func (database *Database) DeleteEventRule(kind, value string) error {
+	_, err := database.client.Exec(
+		context.Background(),
+		SQL_DELETE_EVENT_RULE,
+		kind,
+		value,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to delete event rule from the database, kind: %s, value: %s",
+			kind, value,
+		)
+	}
+
+	return nil
+}
+
+func (database *Database) GetEventRules() ([]EventRule, error) {
+	rows, err := database.client.Query(
+		context.Background(),
+		SQL_SELECT_EVENT_RULES,
+	)
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to get event rules from the database",
+		)
+	}
+
+	defer rows.Close()
+
+	var rules []EventRule
+	for rows.Next() {
+		var rule EventRule
+		err := rows.Scan(
+			&rule.ID,
+			&rule.Kind,
+			&rule.Value,
+			&rule.CreatedBy,
+			&rule.CreatedAt,
+		)
+		if err != nil {
+			return nil, karma.Format(
+				err,
+				"error during scaning event rules from database rows",
+			)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func (database *Database) InsertAdminAction(action AdminAction) error {
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to get current time before inserting admin action",
+		)
+	}
+
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_INSERT_ADMIN_ACTION,
+		action.UserID,
+		action.Username,
+		action.Command,
+		action.Argument,
+		action.Result,
+		timeNow,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to add admin action to the database, command: %s",
+			action.Command,
+		)
+	}
+
+	return nil
+}
+
+func (database *Database) GetAdminActions(limit int) ([]AdminAction, error) {
+	rows, err := database.client.Query(
+		context.Background(),
+		SQL_SELECT_ADMIN_ACTIONS,
+		limit,
+	)
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to get admin actions from the database",
+		)
+	}
+
+	defer rows.Close()
+
+	var actions []AdminAction
+	for rows.Next() {
+		var action AdminAction
+		err := rows.Scan(
+			&action.ID,
+			&action.UserID,
+			&action.Username,
+			&action.Command,
+			&action.Argument,
+			&action.Result,
+			&action.CreatedAt,
+		)
+		if err != nil {
+			return nil, karma.Format(
+				err,
+				"error during scaning admin actions from database rows",
+			)
+		}
+
+		actions = append(actions, action)
+	}
+
+	return actions, nil
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/reconquest/karma-go"
+)
+
+type LeaderLock struct {
+	id   int64
+	conn *pgxpool.Conn
+}
+
+// TryLeaderLock takes session advisory lock on the dedicated connection, lock
+// is held while the connection is alive, so connection is not returned to the
+// pool until lock is released.
+func (database *Database) TryLeaderLock(id int64) (*LeaderLock, error) {
+	ctx := context.Background()
+	conn, err := database.pool.Acquire(ctx)
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to acquire connection for leader lock",
+		)
+	}
+
+	var acquired bool
+	err = conn.QueryRow(ctx, SQL_TRY_ADVISORY_LOCK, id).Scan(&acquired)
+	if err != nil {
+		conn.Release()
+		return nil, karma.Format(
+			err,
+			"unable to try advisory lock: %d",
+			id,
+		)
+	}
+
+	if !acquired {
+		conn.Release()
+		return nil, nil
+	}
+
+	return &LeaderLock{id: id, conn: conn}, nil
+}
+
+func (lock *LeaderLock) Renew() error {
+	var held bool
+	err := lock.conn.QueryRow(
+		context.Background(),
+		SQL_IS_ADVISORY_LOCK_HELD,
+		lock.id,
+	).Scan(&held)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to check advisory lock: %d",
+			lock.id,
+		)
+	}
+
+	if !held {
+		return fmt.Errorf("advisory lock is not held anymore: %d", lock.id)
+	}
+
+	return nil
+}
+
+func (lock *LeaderLock) Release() error {
+	defer lock.conn.Release()
+
+	_, err := lock.conn.Exec(context.Background(), SQL_ADVISORY_UNLOCK, lock.id)
+	if err != nil {
+		// session is closed, so lock is released by postgres together with it
+		lock.conn.Conn().Close(context.Background())
+		return karma.Format(
+			err,
+			"unable to release advisory lock: %d",
+			lock.id,
+		)
+	}
+
+	return nil
+}
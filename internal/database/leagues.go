@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+	"github.com/reconquest/karma-go"
+	"github.com/reconquest/pkg/log"
+)
+
+type League struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	CC     string `json:"cc"`
+	Gender string `json:"gender"`
+	Tier   int    `json:"tier"`
+	Youth  bool   `json:"youth"`
+}
+
+func (database *Database) InsertLeagues(leagues []League) error {
+	if len(leagues) == 0 {
+		return nil
+	}
+
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to get current time before inserting leagues",
+		)
+	}
+
+	for _, league := range leagues {
+		_, err := database.client.Exec(
+			context.Background(),
+			SQL_INSERT_LEAGUE,
+			league.ID,
+			league.Name,
+			league.CC,
+			league.Gender,
+			league.Tier,
+			league.Youth,
+			timeNow,
+		)
+		if err != nil {
+			return karma.Format(
+				err,
+				"unable to add league to the database, league_id: %s",
+				league.ID,
+			)
+		}
+	}
+
+	return nil
+}
+
+func (database *Database) UpdateLeague(league League) (bool, error) {
+	log.Infof(
+		karma.Describe("database", database.name),
+		"updating league in database, league_id: %s",
+		league.ID,
+	)
+
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return false, karma.Format(
+			err,
+			"unable to get current time before updating league",
+		)
+	}
+
+	tag, err := database.client.Exec(
+		context.Background(),
+		SQL_UPDATE_LEAGUE,
+		league.ID,
+		league.CC,
+		league.Gender,
+		league.Tier,
+		league.Youth,
+		timeNow,
+	)
+	if err != nil {
+		return false, karma.Format(
+			err,
+			"unable to update league in the database, league_id: %s",
+			league.ID,
+		)
+	}
+
+	return tag.RowsAffected() != 0, nil
+}
+
+func (database *Database) GetLeagues() ([]League, error) {
+	rows, err := database.client.Query(
+		context.Background(),
+		SQL_SELECT_LEAGUES,
+	)
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to get leagues from the database",
+		)
+	}
+
+	defer rows.Close()
+
+	var leagues []League
+	for rows.Next() {
+		var league League
+		err := rows.Scan(
+			&league.ID,
+			&league.Name,
+			&league.CC,
+			&league.Gender,
+			&league.Tier,
+			&league.Youth,
+		)
+		if err != nil {
+			return nil, karma.Format(
+				err,
+				"error during scaning leagues from database rows",
+			)
+		}
+
+		leagues = append(leagues, league)
+	}
+
+	return leagues, rows.Err()
+}
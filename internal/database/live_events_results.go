@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/requester"
+	"github.com/jackc/pgx/v4"
+	"github.com/reconquest/karma-go"
+)
+
+// GetLiveEventsResults returns results of signalled events which were settled
+// within given period.
+func (database *Database) GetLiveEventsResults(
+	from time.Time,
+	to time.Time,
+) ([]requester.LiveEventResult, error) {
+	rows, err := database.client.Query(
+		context.Background(),
+		SQL_SELECT_SETTLED_LIVE_EVENTS_RESULTS,
+		from,
+		to,
+	)
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to get live events results from %s to %s",
+			from,
+			to,
+		)
+	}
+
+	defer rows.Close()
+
+	return scanLiveEventsResults(rows)
+}
+
+// GetLastLiveEventsResults returns results of last signalled events including
+// the ones which are not settled yet, newest first.
+func (database *Database) GetLastLiveEventsResults(limit int) ([]requester.LiveEventResult, error) {
+	rows, err := database.client.Query(
+		context.Background(),
+		SQL_SELECT_LAST_LIVE_EVENTS_RESULTS,
+		limit,
+	)
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to get last live events results",
+		)
+	}
+
+	defer rows.Close()
+
+	return scanLiveEventsResults(rows)
+}
+
+func scanLiveEventsResults(rows pgx.Rows) ([]requester.LiveEventResult, error) {
+	var results []requester.LiveEventResult
+	for rows.Next() {
+		var (
+			result      requester.LiveEventResult
+			lastHomeOdd string
+			lastAwayOdd string
+		)
+		err := rows.Scan(
+			&result.EventID,
+			&lastHomeOdd,
+			&lastAwayOdd,
+			&result.Score,
+			&result.WinnerInSecondSet,
+			&result.Favorite,
+			&result.CreatedAt,
+			&result.HomeCommandName,
+			&result.AwayCommandName,
+			&result.LeagueName,
+		)
+		if err != nil {
+			return nil, karma.Format(
+				err,
+				"unable to scan live event result",
+			)
+		}
+
+		result.LastHomeOdd, err = strconv.ParseFloat(lastHomeOdd, 64)
+		if err != nil {
+			return nil, karma.Format(
+				err,
+				"unable to parse home odd",
+			)
+		}
+
+		result.LastAwayOdd, err = strconv.ParseFloat(lastAwayOdd, 64)
+		if err != nil {
+			return nil, karma.Format(
+				err,
+				"unable to parse away odd",
+			)
+		}
+
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
@@ -0,0 +1,354 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/reconquest/karma-go"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+const (
+	OUTBOX_STATUS_PENDING = "pending"
+	OUTBOX_STATUS_SENT    = "sent"
+	OUTBOX_STATUS_FAILED  = "failed"
+)
+
+// OutboxMessage is a message queued for sending to telegram, ReplyToMessageID
+// and ReplyToStatus describe the queued message with ReplyToID. Message with
+// EditOfID replaces text of the queued message with EditOfID once it is sent.
+// Message with PhotoID is sent as the stored photo, Photo is loaded only until
+// the photo is uploaded and PhotoFileID is known.
+type OutboxMessage struct {
+	ID               int64               `json:"id"`
+	ChatID           int64               `json:"chatId"`
+	Text             string              `json:"text"`
+	HTML             bool                `json:"html"`
+	Buttons          [][]tb.InlineButton `json:"buttons,omitempty"`
+	PhotoID          int64               `json:"photoId,omitempty"`
+	Photo            []byte              `json:"-"`
+	PhotoFileID      string              `json:"-"`
+	ReplyToID        int64               `json:"replyToId,omitempty"`
+	ReplyToMessageID int                 `json:"replyToMessageId,omitempty"`
+	ReplyToStatus    string              `json:"-"`
+	EditOfID         int64               `json:"editOfId,omitempty"`
+	EditOfMessageID  int                 `json:"editOfMessageId,omitempty"`
+	EditOfStatus     string              `json:"-"`
+	Status           string              `json:"status"`
+	Attempts         int                 `json:"attempts"`
+	NextAttemptAt    time.Time           `json:"nextAttemptAt"`
+	LastError        string              `json:"lastError,omitempty"`
+	MessageID        int                 `json:"messageId,omitempty"`
+	CreatedAt        time.Time           `json:"createdAt"`
+	SentAt           *time.Time          `json:"sentAt,omitempty"`
+}
+
+// EnqueueOutboxMessage adds message to outbox, edit replaces text of pending
+// edit of the same message, so only the latest edit is sent.
+func (database *Database) EnqueueOutboxMessage(message OutboxMessage) (int64, error) {
+	var buttons string
+	if len(message.Buttons) != 0 {
+		data, err := json.Marshal(message.Buttons)
+		if err != nil {
+			return 0, karma.Format(
+				err,
+				"unable to encode buttons of outbox message",
+			)
+		}
+
+		buttons = string(data)
+	}
+
+	var id int64
+	if message.EditOfID != 0 {
+		err := database.client.QueryRow(
+			context.Background(),
+			SQL_UPDATE_PENDING_OUTBOX_EDIT,
+			message.EditOfID,
+			message.Text,
+			message.HTML,
+			buttons,
+		).Scan(&id)
+		if err == nil {
+			return id, nil
+		}
+
+		if err != pgx.ErrNoRows {
+			return 0, karma.Format(
+				err,
+				"unable to update pending edit of outbox message: %d",
+				message.EditOfID,
+			)
+		}
+	}
+
+	err := database.client.QueryRow(
+		context.Background(),
+		SQL_INSERT_OUTBOX_MESSAGE,
+		message.ChatID,
+		message.Text,
+		message.HTML,
+		buttons,
+		message.ReplyToID,
+		message.EditOfID,
+		OUTBOX_STATUS_PENDING,
+		message.CreatedAt,
+		message.PhotoID,
+	).Scan(&id)
+	if err != nil {
+		return 0, karma.Format(
+			err,
+			"unable to add message to outbox, chat_id: %d",
+			message.ChatID,
+		)
+	}
+
+	return id, nil
+}
+
+// GetPendingOutboxMessages returns messages which are due for sending at the
+// given time, oldest first. Only the oldest pending message of every chat is
+// returned, so messages of one chat are sent in the queue order even if one of
+// them is retried.
+func (database *Database) GetPendingOutboxMessages(
+	timeNow time.Time,
+	limit int,
+) ([]OutboxMessage, error) {
+	rows, err := database.client.Query(
+		context.Background(),
+		SQL_SELECT_PENDING_OUTBOX_MESSAGES,
+		timeNow,
+		limit,
+	)
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to get pending outbox messages",
+		)
+	}
+
+	defer rows.Close()
+
+	var messages []OutboxMessage
+	for rows.Next() {
+		var (
+			message OutboxMessage
+			buttons string
+		)
+		err := rows.Scan(
+			&message.ID,
+			&message.ChatID,
+			&message.Text,
+			&message.HTML,
+			&buttons,
+			&message.PhotoID,
+			&message.Photo,
+			&message.PhotoFileID,
+			&message.ReplyToID,
+			&message.ReplyToMessageID,
+			&message.ReplyToStatus,
+			&message.EditOfID,
+			&message.EditOfMessageID,
+			&message.EditOfStatus,
+			&message.Status,
+			&message.Attempts,
+			&message.NextAttemptAt,
+			&message.LastError,
+			&message.MessageID,
+			&message.CreatedAt,
+			&message.SentAt,
+		)
+		if err != nil {
+			return nil, karma.Format(
+				err,
+				"unable to scan outbox message",
+			)
+		}
+
+		if buttons != "" {
+			err = json.Unmarshal([]byte(buttons), &message.Buttons)
+			if err != nil {
+				return nil, karma.Format(
+					err,
+					"unable to decode buttons of outbox message: %d",
+					message.ID,
+				)
+			}
+		}
+
+		messages = append(messages, message)
+	}
+
+	return messages, rows.Err()
+}
+
+func (database *Database) MarkOutboxMessageSent(id int64, messageID int, sentAt time.Time) error {
+	_, err := database.client.Exec(
+		context.Background(),
+		SQL_UPDATE_OUTBOX_MESSAGE_SENT,
+		id,
+		messageID,
+		sentAt,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to mark outbox message as sent: %d",
+			id,
+		)
+	}
+
+	return nil
+}
+
+func (database *Database) MarkOutboxMessageRetry(id int64, nextAttemptAt time.Time, lastError string) error {
+	_, err := database.client.Exec(
+		context.Background(),
+		SQL_UPDATE_OUTBOX_MESSAGE_RETRY,
+		id,
+		nextAttemptAt,
+		lastError,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to reschedule outbox message: %d",
+			id,
+		)
+	}
+
+	return nil
+}
+
+func (database *Database) MarkOutboxMessageFailed(id int64, lastError string) error {
+	_, err := database.client.Exec(
+		context.Background(),
+		SQL_UPDATE_OUTBOX_MESSAGE_FAILED,
+		id,
+		lastError,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to mark outbox message as failed: %d",
+			id,
+		)
+	}
+
+	return nil
+}
+
+// DeleteOldOutboxMessages deletes sent and failed messages created before the
+// given time unless pending reply or edit refers to them.
+func (database *Database) DeleteOldOutboxMessages(before time.Time) (int64, error) {
+	result, err := database.client.Exec(
+		context.Background(),
+		SQL_DELETE_OLD_OUTBOX_MESSAGES,
+		before,
+	)
+	if err != nil {
+		return 0, karma.Format(
+			err,
+			"unable to delete outbox messages created before %s",
+			before,
+		)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// InsertOutboxPhoto stores image once for all messages which refer to it by
+// PhotoID.
+func (database *Database) InsertOutboxPhoto(data []byte, createdAt time.Time) (int64, error) {
+	var id int64
+	err := database.client.QueryRow(
+		context.Background(),
+		SQL_INSERT_OUTBOX_PHOTO,
+		data,
+		createdAt,
+	).Scan(&id)
+	if err != nil {
+		return 0, karma.Format(
+			err,
+			"unable to add photo to outbox",
+		)
+	}
+
+	return id, nil
+}
+
+// SetOutboxPhotoFileID saves telegram file id of uploaded photo, so the photo
+// is not uploaded again for other chats.
+func (database *Database) SetOutboxPhotoFileID(id int64, fileID string) error {
+	_, err := database.client.Exec(
+		context.Background(),
+		SQL_UPDATE_OUTBOX_PHOTO_FILE_ID,
+		id,
+		fileID,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to save file id of outbox photo: %d",
+			id,
+		)
+	}
+
+	return nil
+}
+
+// DeleteUnusedOutboxPhotos deletes photos created before the given time which
+// no outbox message refers to.
+func (database *Database) DeleteUnusedOutboxPhotos(before time.Time) (int64, error) {
+	result, err := database.client.Exec(
+		context.Background(),
+		SQL_DELETE_UNUSED_OUTBOX_PHOTOS,
+		before,
+	)
+	if err != nil {
+		return 0, karma.Format(
+			err,
+			"unable to delete unused outbox photos created before %s",
+			before,
+		)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// CountOutboxMessages returns number of outbox messages by status.
+func (database *Database) CountOutboxMessages() (map[string]int, error) {
+	rows, err := database.client.Query(
+		context.Background(),
+		SQL_COUNT_OUTBOX_MESSAGES_BY_STATUS,
+	)
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to count outbox messages",
+		)
+	}
+
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var (
+			status string
+			count  int
+		)
+		err := rows.Scan(&status, &count)
+		if err != nil {
+			return nil, karma.Format(
+				err,
+				"unable to scan number of outbox messages",
+			)
+		}
+
+		counts[status] = count
+	}
+
+	return counts, rows.Err()
+}
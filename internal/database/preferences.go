@@ -0,0 +1,169 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/constants"
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+	"github.com/jackc/pgx/v4"
+	"github.com/reconquest/karma-go"
+)
+
+// Preferences with empty Leagues or Countries allow all of them, zero
+// MaxSignalsPerDay means no limit and equal quiet hours mean no quiet hours.
+type Preferences struct {
+	ChatID           int64    `json:"chat_id"`
+	Leagues          []string `json:"leagues"`
+	Countries        []string `json:"countries"`
+	MinLiveOdd       float64  `json:"min_live_odd"`
+	MaxSignalsPerDay int      `json:"max_signals_per_day"`
+	QuietHoursFrom   int      `json:"quiet_hours_from"`
+	QuietHoursTo     int      `json:"quiet_hours_to"`
+	Reports          []string `json:"reports"`
+}
+
+// SignalDelivery refers to the queued signal message by OutboxID, telegram
+// MessageID is not known until the message is sent.
+type SignalDelivery struct {
+	ChatID    int64
+	EventID   string
+	MessageID int
+	OutboxID  int64
+}
+
+func GetDefaultPreferences(chatID int64) Preferences {
+	return Preferences{
+		ChatID:  chatID,
+		Reports: strings.Split(constants.REPORT_TYPES, ","),
+	}
+}
+
+func (database *Database) GetSubscriberPreferences(chatID int64) (Preferences, error) {
+	var (
+		preferences = Preferences{ChatID: chatID}
+		leagues     string
+		countries   string
+		reports     string
+	)
+
+	err := database.client.QueryRow(
+		context.Background(),
+		SQL_SELECT_SUBSCRIBER_PREFERENCES,
+		chatID,
+	).Scan(
+		&leagues,
+		&countries,
+		&preferences.MinLiveOdd,
+		&preferences.MaxSignalsPerDay,
+		&preferences.QuietHoursFrom,
+		&preferences.QuietHoursTo,
+		&reports,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return GetDefaultPreferences(chatID), nil
+		}
+
+		return preferences, karma.Format(
+			err,
+			"unable to get preferences of the chat: %d",
+			chatID,
+		)
+	}
+
+	preferences.Leagues = splitList(leagues)
+	preferences.Countries = splitList(countries)
+	preferences.Reports = splitList(reports)
+	return preferences, nil
+}
+
+func (database *Database) SetSubscriberPreferences(preferences Preferences) error {
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to get current time before updating preferences",
+		)
+	}
+
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_UPSERT_SUBSCRIBER_PREFERENCES,
+		preferences.ChatID,
+		strings.Join(preferences.Leagues, ","),
+		strings.Join(preferences.Countries, ","),
+		preferences.MinLiveOdd,
+		preferences.MaxSignalsPerDay,
+		preferences.QuietHoursFrom,
+		preferences.QuietHoursTo,
+		strings.Join(preferences.Reports, ","),
+		timeNow,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to update preferences of the chat: %d",
+			preferences.ChatID,
+		)
+	}
+
+	return nil
+}
+
+func (database *Database) InsertSignalDelivery(delivery SignalDelivery) error {
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to get current time before inserting signal delivery",
+		)
+	}
+
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_INSERT_SIGNAL_DELIVERY,
+		delivery.ChatID,
+		delivery.EventID,
+		delivery.MessageID,
+		delivery.OutboxID,
+		timeNow,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to add signal delivery to the database, chat_id: %d",
+			delivery.ChatID,
+		)
+	}
+
+	return nil
+}
+
+func (database *Database) CountSignalDeliveries(chatID int64, from time.Time) (int, error) {
+	var count int
+	err := database.client.QueryRow(
+		context.Background(),
+		SQL_COUNT_SIGNAL_DELIVERIES,
+		chatID,
+		from,
+	).Scan(&count)
+	if err != nil {
+		return 0, karma.Format(
+			err,
+			"unable to count signal deliveries of the chat: %d",
+			chatID,
+		)
+	}
+
+	return count, nil
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	return strings.Split(value, ",")
+}
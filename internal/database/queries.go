@@ -5,7 +5,7 @@ const (
 	CREATE TABLE IF NOT EXISTS
 	events_volleyball(
 		event_id VARCHAR(50) UNIQUE NOT NULL PRIMARY KEY,
-		event_time TIMESTAMP,
+		event_time TIMESTAMPTZ,
 		league_id VARCHAR(50),
 		league_name VARCHAR(50),
 		favorite_name VARCHAR(50),
@@ -40,7 +40,7 @@ const (
 			score VARCHAR(50),
 			winner_in_second_set VARCHAR(20),
 			favorite VARCHAR(20),
-    		created_at TIMESTAMP,
+    		created_at TIMESTAMPTZ,
     		FOREIGN KEY (event_id) REFERENCES events_volleyball (event_id)
 	);
 `
@@ -52,7 +52,7 @@ const (
 			player_is_win VARCHAR(20),
 			score VARCHAR(50),
 			winner_in_second_set VARCHAR(20),
-    		created_at TIMESTAMP,
+    		created_at TIMESTAMPTZ,
     		FOREIGN KEY (event_id) REFERENCES events_volleyball (event_id)
 	);
 `
@@ -95,23 +95,940 @@ const (
 	CREATE TABLE IF NOT EXISTS
 	telegram_subscribers(
 		id serial PRIMARY KEY,
+		chat_id BIGINT UNIQUE NOT NULL,
+		chat_type VARCHAR(20),
+		username VARCHAR(255),
+		is_active BOOLEAN NOT NULL DEFAULT TRUE,
+		joined_at TIMESTAMPTZ,
+		updated_at TIMESTAMPTZ,
 		secret_key VARCHAR(50),
-		secret_key_expired_at TIMESTAMP,
-		created_at TIMESTAMP
+		secret_key_expired_at TIMESTAMPTZ,
+		reminded_at TIMESTAMPTZ
 	);
 `
 
+	SQL_ALTER_TABLE_TELEGRAM_SUBSCRIBERS_ADD_SECRET_KEY = `
+	ALTER TABLE telegram_subscribers
+		ADD COLUMN IF NOT EXISTS secret_key VARCHAR(50),
+		ADD COLUMN IF NOT EXISTS secret_key_expired_at TIMESTAMPTZ,
+		ADD COLUMN IF NOT EXISTS reminded_at TIMESTAMPTZ;
+`
+
+	SQL_UPSERT_TELEGRAM_SUBSCRIBER = `
+	INSERT INTO
+	telegram_subscribers(
+		chat_id,
+		chat_type,
+		username,
+		is_active,
+		joined_at,
+		updated_at
+	)
+	VALUES($1, $2, $3, TRUE, $4, $4)
+	ON CONFLICT (chat_id) DO UPDATE
+		SET
+			chat_type = EXCLUDED.chat_type,
+			username = EXCLUDED.username,
+			is_active = TRUE,
+			secret_key = NULL,
+			secret_key_expired_at = NULL,
+			reminded_at = NULL,
+			updated_at = EXCLUDED.updated_at;
+`
+
+	SQL_DEACTIVATE_TELEGRAM_SUBSCRIBER = `
+	UPDATE telegram_subscribers
+		SET
+			is_active = FALSE,
+			updated_at = $2
+	WHERE chat_id = $1 AND is_active;
+`
+
+	SQL_SELECT_ACTIVE_TELEGRAM_SUBSCRIBERS = `
+	SELECT ` + SQL_TELEGRAM_SUBSCRIBER_COLUMNS + ` FROM telegram_subscribers
+	WHERE is_active AND (secret_key_expired_at IS NULL OR secret_key_expired_at > $1)
+	ORDER BY joined_at;
+`
+
+	SQL_TELEGRAM_SUBSCRIBER_COLUMNS = `
+	chat_id, chat_type, username, is_active, joined_at,
+	COALESCE(secret_key, ''), secret_key_expired_at`
+
+	SQL_SELECT_TELEGRAM_SUBSCRIBER = `
+	SELECT ` + SQL_TELEGRAM_SUBSCRIBER_COLUMNS + ` FROM telegram_subscribers
+	WHERE chat_id = $1;
+`
+
+	SQL_SELECT_TELEGRAM_SUBSCRIBERS_TO_REMIND = `
+	SELECT ` + SQL_TELEGRAM_SUBSCRIBER_COLUMNS + ` FROM telegram_subscribers
+	WHERE is_active
+		AND reminded_at IS NULL
+		AND secret_key_expired_at > $1
+		AND secret_key_expired_at <= $2;
+`
+
+	SQL_UPDATE_TELEGRAM_SUBSCRIBER_REMINDED_AT = `
+	UPDATE telegram_subscribers
+		SET reminded_at = $2
+	WHERE chat_id = $1;
+`
+
+	SQL_DEACTIVATE_EXPIRED_TELEGRAM_SUBSCRIBERS = `
+	UPDATE telegram_subscribers
+		SET
+			is_active = FALSE,
+			updated_at = $1
+	WHERE is_active AND secret_key_expired_at <= $1
+	RETURNING ` + SQL_TELEGRAM_SUBSCRIBER_COLUMNS + `;
+`
+
+	SQL_UPDATE_TELEGRAM_SUBSCRIBER_SECRET_KEY = `
+	UPDATE telegram_subscribers
+		SET
+			secret_key = $2,
+			secret_key_expired_at = $3,
+			reminded_at = NULL,
+			updated_at = $4
+	WHERE chat_id = $1;
+`
+
+	SQL_CREATE_TABLE_ACCESS_CODES = `
+	CREATE TABLE IF NOT EXISTS
+	access_codes(
+		code VARCHAR(50) UNIQUE NOT NULL PRIMARY KEY,
+		max_uses INTEGER NOT NULL,
+		access_days INTEGER NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL,
+		created_by BIGINT,
+		created_at TIMESTAMPTZ
+	);
+`
+
+	SQL_CREATE_TABLE_ACCESS_CODE_REDEMPTIONS = `
+	CREATE TABLE IF NOT EXISTS
+	access_code_redemptions(
+		id serial PRIMARY KEY,
+		code VARCHAR(50) NOT NULL REFERENCES access_codes(code),
+		chat_id BIGINT NOT NULL,
+		redeemed_at TIMESTAMPTZ,
+		UNIQUE (code, chat_id)
+	);
+`
+
+	SQL_INSERT_ACCESS_CODE = `
+	INSERT INTO
+	access_codes(
+		code,
+		max_uses,
+		access_days,
+		expires_at,
+		created_by,
+		created_at
+	)
+	VALUES($1, $2, $3, $4, $5, $6);
+`
+
+	SQL_SELECT_ACCESS_CODE_FOR_UPDATE = `
+	SELECT code, max_uses, access_days, expires_at, created_by, created_at FROM access_codes
+	WHERE code = $1
+	FOR UPDATE;
+`
+
+	SQL_SELECT_ACCESS_CODES = `
+	SELECT code, max_uses, access_days, expires_at, created_by, created_at FROM access_codes
+	ORDER BY created_at DESC;
+`
+
+	SQL_SELECT_ACCESS_CODE_REDEMPTIONS = `
+	SELECT code, chat_id, redeemed_at FROM access_code_redemptions
+	ORDER BY redeemed_at;
+`
+
+	SQL_SELECT_ACCESS_CODE_REDEMPTIONS_BY_CODE = `
+	SELECT code, chat_id, redeemed_at FROM access_code_redemptions
+	WHERE code = $1;
+`
+
+	SQL_INSERT_ACCESS_CODE_REDEMPTION = `
+	INSERT INTO
+	access_code_redemptions(
+		code,
+		chat_id,
+		redeemed_at
+	)
+	VALUES($1, $2, $3)
+	ON CONFLICT (code, chat_id) DO NOTHING;
+`
+
 	SQL_SELECT_LIVE_EVENTS_AT_END_OF_DAY = `
 	SELECT * FROM live_events_results
-	WHERE CAST($1 AS Date) = CAST(live_events_results.created_at AS Date);
+	WHERE live_events_results.created_at >= $1
+		AND live_events_results.created_at < $2;
 `
 
 	SQL_SELECT_STATISTICS_OF_PREVIOUS_WEEK = `
 	SELECT * FROM statistic_on_previous_day
-	WHERE (created_at >= CAST($1 AS Date));
+	WHERE (created_at >= $1);
 	`
-	SQL_SELECT_UPCOMING_EVENTS_FOR_CURRENT_DAY = `
+	SQL_SELECT_EVENT_BY_ID = `
 	SELECT * FROM events_volleyball
-	WHERE (event_time >= CAST($1 AS Date)) ORDER BY event_time DESC;
+	WHERE event_id = $1;
 	`
+
+	SQL_CREATE_TABLE_EVENTS_ODDS_HISTORY = `
+	CREATE TABLE IF NOT EXISTS
+	events_odds_history(
+		id serial PRIMARY KEY,
+		event_id VARCHAR(50),
+		odd_home DECIMAL,
+		odd_away DECIMAL,
+		created_at TIMESTAMPTZ,
+		FOREIGN KEY (event_id) REFERENCES events_volleyball (event_id)
+	);
+`
+
+	SQL_INSERT_EVENTS_ODDS_HISTORY = `
+	INSERT INTO
+	events_odds_history(
+		event_id,
+		odd_home,
+		odd_away,
+		created_at
+	)
+	VALUES($1, $2, $3, $4);
+`
+
+	SQL_CREATE_TABLE_SIGNALS = `
+	CREATE TABLE IF NOT EXISTS
+	signals(
+		id serial PRIMARY KEY,
+		event_id VARCHAR(50) NOT NULL,
+		strategy VARCHAR(50) NOT NULL,
+		signal_type VARCHAR(50) NOT NULL,
+		created_at TIMESTAMPTZ,
+		UNIQUE (event_id, strategy, signal_type)
+	);
+`
+
+	SQL_INSERT_SIGNAL = `
+	INSERT INTO
+	signals(
+		event_id,
+		strategy,
+		signal_type,
+		created_at
+	)
+	VALUES($1, $2, $3, $4)
+	ON CONFLICT (event_id, strategy, signal_type) DO NOTHING
+	RETURNING id;
+`
+
+	SQL_CREATE_TABLE_LEAGUES = `
+	CREATE TABLE IF NOT EXISTS
+	leagues(
+		league_id VARCHAR(50) UNIQUE NOT NULL PRIMARY KEY,
+		name VARCHAR(100),
+		cc VARCHAR(10),
+		gender VARCHAR(20),
+		tier INTEGER,
+		youth BOOLEAN,
+		created_at TIMESTAMPTZ,
+		updated_at TIMESTAMPTZ
+	);
+`
+
+	SQL_INSERT_LEAGUE = `
+	INSERT INTO
+	leagues(
+		league_id,
+		name,
+		cc,
+		gender,
+		tier,
+		youth,
+		created_at,
+		updated_at
+	)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $7)
+	ON CONFLICT (league_id) DO NOTHING;
+`
+
+	SQL_UPDATE_LEAGUE = `
+	UPDATE leagues
+		SET
+			cc = $2,
+			gender = $3,
+			tier = $4,
+			youth = $5,
+			updated_at = $6
+	WHERE leagues.league_id = $1;
+`
+
+	SQL_SELECT_LEAGUES = `
+	SELECT league_id, name, cc, gender, tier, youth FROM leagues
+	ORDER BY name;
+`
+
+	SQL_SELECT_UPCOMING_EVENTS_FOR_DAY = `
+	SELECT * FROM events_volleyball
+	WHERE (event_time >= $1 AND event_time < $2) ORDER BY event_time DESC;
+	`
+
+	// columns were created as TIMESTAMP and contain wall clock of the legacy
+	// timezone, so they are converted only once
+	SQL_MIGRATE_COLUMN_TO_TIMESTAMPTZ = `
+	DO $$
+	BEGIN
+		IF EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_name = '%[1]s'
+				AND column_name = '%[2]s'
+				AND data_type = 'timestamp without time zone'
+		) THEN
+			ALTER TABLE %[1]s
+				ALTER COLUMN %[2]s TYPE TIMESTAMPTZ
+				USING %[2]s AT TIME ZONE '%[3]s';
+		END IF;
+	END $$;
+`
+
+	SQL_CREATE_TABLE_CHAT_SETTINGS = `
+	CREATE TABLE IF NOT EXISTS
+	chat_settings(
+		chat_id BIGINT UNIQUE NOT NULL PRIMARY KEY,
+		timezone VARCHAR(64),
+		language VARCHAR(10),
+		updated_at TIMESTAMPTZ
+	);
+`
+
+	SQL_UPSERT_CHAT_TIMEZONE = `
+	INSERT INTO
+	chat_settings(
+		chat_id,
+		timezone,
+		updated_at
+	)
+	VALUES($1, $2, $3)
+	ON CONFLICT (chat_id) DO UPDATE
+		SET
+			timezone = EXCLUDED.timezone,
+			updated_at = EXCLUDED.updated_at;
+`
+
+	SQL_SELECT_CHAT_TIMEZONE = `
+	SELECT COALESCE(timezone, '') FROM chat_settings
+	WHERE chat_id = $1;
+`
+
+	SQL_UPSERT_CHAT_LANGUAGE = `
+	INSERT INTO
+	chat_settings(
+		chat_id,
+		language,
+		updated_at
+	)
+	VALUES($1, $2, $3)
+	ON CONFLICT (chat_id) DO UPDATE
+		SET
+			language = EXCLUDED.language,
+			updated_at = EXCLUDED.updated_at;
+`
+
+	SQL_SELECT_CHAT_LANGUAGE = `
+	SELECT COALESCE(language, '') FROM chat_settings
+	WHERE chat_id = $1;
+`
+
+	SQL_TRY_ADVISORY_LOCK = `
+	SELECT pg_try_advisory_lock($1);
+`
+
+	SQL_IS_ADVISORY_LOCK_HELD = `
+	SELECT EXISTS (
+		SELECT 1 FROM pg_locks
+		WHERE locktype = 'advisory'
+			AND granted
+			AND pid = pg_backend_pid()
+			AND ((classid::bigint << 32) | objid::bigint) = $1
+	);
+`
+
+	SQL_ADVISORY_UNLOCK = `
+	SELECT pg_advisory_unlock($1);
+`
+
+	SQL_CREATE_TABLE_EVENT_RULES = `
+	CREATE TABLE IF NOT EXISTS
+	event_rules(
+		id serial PRIMARY KEY,
+		kind VARCHAR(20) NOT NULL,
+		value VARCHAR(255) NOT NULL,
+		created_by BIGINT,
+		created_at TIMESTAMPTZ,
+		UNIQUE (kind, value)
+	);
+`
+
+	SQL_INSERT_EVENT_RULE = `
+	INSERT INTO
+	event_rules(
+		kind,
+		value,
+		created_by,
+		created_at
+	)
+	VALUES($1, $2, $3, $4)
+	ON CONFLICT (kind, value) DO NOTHING;
+`
+
+	SQL_DELETE_EVENT_RULE = `
+	DELETE FROM event_rules
+	WHERE kind = $1 AND value = $2;
+`
+
+	SQL_SELECT_EVENT_RULES = `
+	SELECT id, kind, value, created_by, created_at FROM event_rules
+	ORDER BY id;
+`
+
+	SQL_CREATE_TABLE_ADMIN_ACTIONS = `
+	CREATE TABLE IF NOT EXISTS
+	admin_actions(
+		id serial PRIMARY KEY,
+		user_id BIGINT,
+		username VARCHAR(255),
+		command VARCHAR(50),
+		argument VARCHAR(255),
+		result TEXT,
+		created_at TIMESTAMPTZ
+	);
+`
+
+	SQL_INSERT_ADMIN_ACTION = `
+	INSERT INTO
+	admin_actions(
+		user_id,
+		username,
+		command,
+		argument,
+		result,
+		created_at
+	)
+	VALUES($1, $2, $3, $4, $5, $6);
+`
+
+	SQL_SELECT_ADMIN_ACTIONS = `
+	SELECT id, user_id, username, command, argument, result, created_at FROM admin_actions
+	ORDER BY id DESC
+	LIMIT $1;
+`
+
+	SQL_CREATE_TABLE_SUBSCRIBER_PREFERENCES = `
+	CREATE TABLE IF NOT EXISTS
+	subscriber_preferences(
+		chat_id BIGINT UNIQUE NOT NULL PRIMARY KEY,
+		leagues TEXT NOT NULL DEFAULT '',
+		countries TEXT NOT NULL DEFAULT '',
+		min_live_odd DOUBLE PRECISION NOT NULL DEFAULT 0,
+		max_signals_per_day INTEGER NOT NULL DEFAULT 0,
+		quiet_hours_from INTEGER NOT NULL DEFAULT 0,
+		quiet_hours_to INTEGER NOT NULL DEFAULT 0,
+		reports TEXT NOT NULL DEFAULT '',
+		updated_at TIMESTAMPTZ
+	);
+`
+
+	SQL_UPSERT_SUBSCRIBER_PREFERENCES = `
+	INSERT INTO
+	subscriber_preferences(
+		chat_id,
+		leagues,
+		countries,
+		min_live_odd,
+		max_signals_per_day,
+		quiet_hours_from,
+		quiet_hours_to,
+		reports,
+		updated_at
+	)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	ON CONFLICT (chat_id) DO UPDATE
+		SET
+			leagues = EXCLUDED.leagues,
+			countries = EXCLUDED.countries,
+			min_live_odd = EXCLUDED.min_live_odd,
+			max_signals_per_day = EXCLUDED.max_signals_per_day,
+			quiet_hours_from = EXCLUDED.quiet_hours_from,
+			quiet_hours_to = EXCLUDED.quiet_hours_to,
+			reports = EXCLUDED.reports,
+			updated_at = EXCLUDED.updated_at;
+`
+
+	SQL_SELECT_SUBSCRIBER_PREFERENCES = `
+	SELECT
+		leagues,
+		countries,
+		min_live_odd,
+		max_signals_per_day,
+		quiet_hours_from,
+		quiet_hours_to,
+		reports
+	FROM subscriber_preferences
+	WHERE chat_id = $1;
+`
+
+	SQL_CREATE_TABLE_SIGNAL_DELIVERIES = `
+	CREATE TABLE IF NOT EXISTS
+	signal_deliveries(
+		id serial PRIMARY KEY,
+		chat_id BIGINT NOT NULL,
+		event_id VARCHAR(50) NOT NULL,
+		message_id INTEGER,
+		outbox_id BIGINT,
+		delivered_at TIMESTAMPTZ
+	);
+`
+
+	SQL_INSERT_SIGNAL_DELIVERY = `
+	INSERT INTO
+	signal_deliveries(
+		chat_id,
+		event_id,
+		message_id,
+		outbox_id,
+		delivered_at
+	)
+	VALUES($1, $2, $3, $4, $5);
+`
+
+	SQL_COUNT_SIGNAL_DELIVERIES = `
+	SELECT COUNT(*) FROM signal_deliveries
+	WHERE chat_id = $1 AND delivered_at >= $2;
+`
+
+	SQL_SELECT_LIVE_EVENT_RESULT_BY_EVENT_ID = `
+	SELECT
+		event_id,
+		last_odd_home,
+		last_odd_away,
+		score,
+		winner_in_second_set,
+		favorite,
+		created_at
+	FROM live_events_results
+	WHERE event_id = $1
+	ORDER BY created_at DESC
+	LIMIT 1;
+`
+
+	SQL_LIVE_EVENTS_RESULTS_WITH_EVENTS_COLUMNS = `
+		live_events_results.event_id,
+		live_events_results.last_odd_home,
+		live_events_results.last_odd_away,
+		COALESCE(live_events_results.score, ''),
+		COALESCE(live_events_results.winner_in_second_set, ''),
+		live_events_results.favorite,
+		live_events_results.created_at,
+		COALESCE(events_volleyball.home_command_name, ''),
+		COALESCE(events_volleyball.away_command_name, ''),
+		COALESCE(events_volleyball.league_name, '')
+`
+
+	SQL_SELECT_SETTLED_LIVE_EVENTS_RESULTS = `
+	SELECT` + SQL_LIVE_EVENTS_RESULTS_WITH_EVENTS_COLUMNS + `
+	FROM live_events_results
+	LEFT JOIN events_volleyball
+		ON events_volleyball.event_id = live_events_results.event_id
+	WHERE live_events_results.created_at >= $1
+		AND live_events_results.created_at < $2
+		AND COALESCE(live_events_results.winner_in_second_set, '') <> ''
+	ORDER BY live_events_results.created_at;
+`
+
+	SQL_SELECT_LAST_LIVE_EVENTS_RESULTS = `
+	SELECT` + SQL_LIVE_EVENTS_RESULTS_WITH_EVENTS_COLUMNS + `
+	FROM live_events_results
+	LEFT JOIN events_volleyball
+		ON events_volleyball.event_id = live_events_results.event_id
+	ORDER BY live_events_results.created_at DESC
+	LIMIT $1;
+`
+
+	SQL_CREATE_TABLE_BETS = `
+	CREATE TABLE IF NOT EXISTS
+	bets(
+		id serial PRIMARY KEY,
+		chat_id BIGINT NOT NULL,
+		user_id BIGINT NOT NULL,
+		event_id VARCHAR(50) NOT NULL,
+		favorite VARCHAR(20) NOT NULL,
+		stake DOUBLE PRECISION NOT NULL DEFAULT 1,
+		odd DOUBLE PRECISION NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		profit DOUBLE PRECISION NOT NULL DEFAULT 0,
+		created_at TIMESTAMPTZ,
+		settled_at TIMESTAMPTZ,
+		UNIQUE (user_id, event_id)
+	);
+`
+
+	SQL_BETS_COLUMNS = `
+		id,
+		chat_id,
+		user_id,
+		event_id,
+		favorite,
+		stake,
+		odd,
+		status,
+		profit,
+		created_at,
+		settled_at
+`
+
+	SQL_INSERT_BET = `
+	INSERT INTO
+	bets(
+		chat_id,
+		user_id,
+		event_id,
+		favorite,
+		stake,
+		odd,
+		status,
+		created_at
+	)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+	ON CONFLICT (user_id, event_id) DO NOTHING;
+`
+
+	SQL_UPDATE_BET_STAKE_AND_ODD = `
+	UPDATE bets
+	SET
+		stake = $3,
+		odd = $4
+	WHERE user_id = $1 AND event_id = $2 AND status = 'open';
+`
+
+	SQL_SETTLE_OPEN_BETS = `
+	WITH results AS (
+		SELECT DISTINCT event_id, winner_in_second_set AS winner
+		FROM live_events_results
+		WHERE winner_in_second_set IS NOT NULL AND winner_in_second_set != ''
+	)
+	UPDATE bets
+	SET
+		status = CASE WHEN favorite = results.winner THEN 'won' ELSE 'lost' END,
+		profit = CASE WHEN favorite = results.winner THEN stake * (odd - 1) ELSE -stake END,
+		settled_at = $1
+	FROM results
+	WHERE bets.event_id = results.event_id AND bets.status = 'open'
+	RETURNING
+		bets.id,
+		bets.chat_id,
+		bets.user_id,
+		bets.event_id,
+		bets.favorite,
+		bets.stake,
+		bets.odd,
+		bets.status,
+		bets.profit,
+		bets.created_at,
+		bets.settled_at;
+`
+
+	SQL_SELECT_BETS_BY_USER_ID = `
+	SELECT` + SQL_BETS_COLUMNS + `
+	FROM bets
+	WHERE user_id = $1
+	ORDER BY created_at DESC;
+`
+
+	SQL_CREATE_TABLE_OUTBOX = `
+	CREATE TABLE IF NOT EXISTS
+	outbox(
+		id BIGSERIAL PRIMARY KEY,
+		chat_id BIGINT NOT NULL,
+		text TEXT NOT NULL,
+		html BOOLEAN NOT NULL DEFAULT FALSE,
+		buttons TEXT NOT NULL DEFAULT '',
+		reply_to_outbox_id BIGINT,
+		edit_of_outbox_id BIGINT,
+		photo_id BIGINT,
+		status VARCHAR(20) NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		next_attempt_at TIMESTAMPTZ NOT NULL,
+		last_error TEXT NOT NULL DEFAULT '',
+		message_id INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMPTZ NOT NULL,
+		sent_at TIMESTAMPTZ
+	);
+
+	CREATE INDEX IF NOT EXISTS outbox_pending_idx
+		ON outbox (next_attempt_at, id)
+		WHERE status = 'pending';
+`
+
+	SQL_CREATE_TABLE_OUTBOX_PHOTOS = `
+	CREATE TABLE IF NOT EXISTS
+	outbox_photos(
+		id BIGSERIAL PRIMARY KEY,
+		data BYTEA NOT NULL,
+		file_id TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL
+	);
+`
+
+	SQL_CREATE_INDEX_OUTBOX_CHAT_PENDING = `
+	CREATE INDEX IF NOT EXISTS outbox_chat_pending_idx
+		ON outbox (chat_id, id)
+		WHERE status = 'pending';
+`
+
+	SQL_OUTBOX_COLUMNS = `
+		outbox.id,
+		outbox.chat_id,
+		outbox.text,
+		outbox.html,
+		outbox.buttons,
+		COALESCE(outbox.photo_id, 0),
+		CASE WHEN photo.file_id = '' THEN photo.data END,
+		COALESCE(photo.file_id, ''),
+		COALESCE(outbox.reply_to_outbox_id, 0),
+		COALESCE(reply_to.message_id, 0),
+		COALESCE(reply_to.status, ''),
+		COALESCE(outbox.edit_of_outbox_id, 0),
+		COALESCE(edit_of.message_id, 0),
+		COALESCE(edit_of.status, ''),
+		outbox.status,
+		outbox.attempts,
+		outbox.next_attempt_at,
+		outbox.last_error,
+		outbox.message_id,
+		outbox.created_at,
+		outbox.sent_at
+`
+
+	SQL_INSERT_OUTBOX_MESSAGE = `
+	INSERT INTO
+	outbox(
+		chat_id,
+		text,
+		html,
+		buttons,
+		reply_to_outbox_id,
+		edit_of_outbox_id,
+		status,
+		next_attempt_at,
+		created_at,
+		photo_id
+	)
+	VALUES($1, $2, $3, $4, NULLIF($5, 0), NULLIF($6, 0), $7, $8, $8, NULLIF($9, 0))
+	RETURNING id;
+`
+
+	SQL_UPDATE_PENDING_OUTBOX_EDIT = `
+	UPDATE outbox
+	SET text = $2,
+		html = $3,
+		buttons = $4
+	WHERE edit_of_outbox_id = $1
+		AND status = 'pending'
+	RETURNING id;
+`
+
+	SQL_SELECT_PENDING_OUTBOX_MESSAGES = `
+	SELECT` + SQL_OUTBOX_COLUMNS + `
+	FROM outbox
+	LEFT JOIN outbox AS reply_to
+		ON reply_to.id = outbox.reply_to_outbox_id
+	LEFT JOIN outbox AS edit_of
+		ON edit_of.id = outbox.edit_of_outbox_id
+	LEFT JOIN outbox_photos AS photo
+		ON photo.id = outbox.photo_id
+	WHERE outbox.status = 'pending'
+		AND outbox.next_attempt_at <= $1
+		AND NOT EXISTS (
+			SELECT 1 FROM outbox AS older
+			WHERE older.chat_id = outbox.chat_id
+				AND older.status = 'pending'
+				AND older.id < outbox.id
+		)
+	ORDER BY outbox.next_attempt_at, outbox.id
+	LIMIT $2;
+`
+
+	SQL_UPDATE_OUTBOX_MESSAGE_SENT = `
+	UPDATE outbox
+	SET status = 'sent',
+		attempts = attempts + 1,
+		message_id = $2,
+		sent_at = $3
+	WHERE id = $1;
+`
+
+	SQL_UPDATE_OUTBOX_MESSAGE_RETRY = `
+	UPDATE outbox
+	SET attempts = attempts + 1,
+		next_attempt_at = $2,
+		last_error = $3
+	WHERE id = $1;
+`
+
+	SQL_UPDATE_OUTBOX_MESSAGE_FAILED = `
+	UPDATE outbox
+	SET status = 'failed',
+		attempts = attempts + 1,
+		last_error = $2
+	WHERE id = $1;
+`
+
+	SQL_DELETE_OLD_OUTBOX_MESSAGES = `
+	DELETE FROM outbox
+	WHERE status IN ('sent', 'failed')
+		AND created_at < $1
+		AND NOT EXISTS (
+			SELECT 1 FROM outbox AS pending
+			WHERE pending.status = 'pending'
+				AND (
+					pending.reply_to_outbox_id = outbox.id
+					OR pending.edit_of_outbox_id = outbox.id
+				)
+		);
+`
+
+	SQL_INSERT_OUTBOX_PHOTO = `
+	INSERT INTO
+	outbox_photos(
+		data,
+		created_at
+	)
+	VALUES($1, $2)
+	RETURNING id;
+`
+
+	SQL_UPDATE_OUTBOX_PHOTO_FILE_ID = `
+	UPDATE outbox_photos
+	SET file_id = $2
+	WHERE id = $1;
+`
+
+	SQL_DELETE_UNUSED_OUTBOX_PHOTOS = `
+	DELETE FROM outbox_photos
+	WHERE created_at < $1
+		AND NOT EXISTS (
+			SELECT 1 FROM outbox
+			WHERE outbox.photo_id = outbox_photos.id
+		);
+`
+
+	SQL_COUNT_OUTBOX_MESSAGES_BY_STATUS = `
+	SELECT status, COUNT(*) FROM outbox
+	GROUP BY status;
+`
+
+	SQL_CREATE_TABLE_USER_ROLES = `
+	CREATE TABLE IF NOT EXISTS
+	user_roles(
+		user_id BIGINT PRIMARY KEY,
+		role VARCHAR(16) NOT NULL,
+		granted_by BIGINT NOT NULL DEFAULT 0,
+		updated_at TIMESTAMPTZ NOT NULL
+	);
+`
+
+	SQL_SELECT_USER_ROLE = `
+	SELECT role FROM user_roles
+	WHERE user_id = $1;
+`
+
+	SQL_SELECT_USER_ROLES = `
+	SELECT user_id, role, granted_by, updated_at FROM user_roles
+	ORDER BY user_id;
+`
+
+	SQL_UPSERT_USER_ROLE = `
+	INSERT INTO
+	user_roles(
+		user_id,
+		role,
+		granted_by,
+		updated_at
+	)
+	VALUES($1, $2, $3, $4)
+	ON CONFLICT (user_id) DO UPDATE
+	SET role = EXCLUDED.role,
+		granted_by = EXCLUDED.granted_by,
+		updated_at = EXCLUDED.updated_at;
+`
+
+	SQL_INSERT_USER_ROLE_IF_NOT_EXISTS = `
+	INSERT INTO
+	user_roles(
+		user_id,
+		role,
+		granted_by,
+		updated_at
+	)
+	VALUES($1, $2, $3, $4)
+	ON CONFLICT (user_id) DO NOTHING;
+`
+
+	SQL_DEMOTE_OTHER_OWNERS = `
+	UPDATE user_roles
+	SET role = 'admin',
+		updated_at = $2
+	WHERE role = 'owner'
+		AND user_id <> $1;
+`
+
+	SQL_CREATE_TABLE_DIGEST_DELIVERIES = `
+	CREATE TABLE IF NOT EXISTS
+	digest_deliveries(
+		chat_id BIGINT NOT NULL,
+		event_id VARCHAR(50) NOT NULL,
+		sent_at TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (chat_id, event_id)
+	);
+`
+
+	SQL_SELECT_DIGEST_EVENT_IDS = `
+	SELECT event_id FROM digest_deliveries
+	WHERE chat_id = $1 AND sent_at >= $2
+	ORDER BY event_id;
+`
+
+	SQL_INSERT_DIGEST_DELIVERIES = `
+	INSERT INTO
+	digest_deliveries(
+		chat_id,
+		event_id,
+		sent_at
+	)
+	SELECT $1, unnest($2::VARCHAR[]), $3
+	ON CONFLICT (chat_id, event_id) DO NOTHING;
+`
+
+	SQL_CREATE_TABLE_REPORT_DELIVERIES = `
+	CREATE TABLE IF NOT EXISTS
+	report_deliveries(
+		report VARCHAR(20) NOT NULL,
+		period_start TIMESTAMPTZ NOT NULL,
+		sent_at TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (report, period_start)
+	);
+`
+
+	SQL_INSERT_REPORT_DELIVERY = `
+	INSERT INTO
+	report_deliveries(
+		report,
+		period_start,
+		sent_at
+	)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (report, period_start) DO NOTHING
+	RETURNING report;
+`
 )
@@ -0,0 +1,197 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+	"github.com/jackc/pgx/v4"
+	"github.com/reconquest/karma-go"
+)
+
+const (
+	ROLE_OWNER      = "owner"
+	ROLE_ADMIN      = "admin"
+	ROLE_SUBSCRIBER = "subscriber"
+	ROLE_GUEST      = "guest"
+)
+
+// ROLES are ordered from the least privileged, every role has permissions of
+// the previous ones.
+var ROLES = []string{ROLE_GUEST, ROLE_SUBSCRIBER, ROLE_ADMIN, ROLE_OWNER}
+
+// UserRole is granted to telegram user, GrantedBy is zero when role is
+// bootstrapped from config.
+type UserRole struct {
+	UserID    int64     `json:"user_id"`
+	Role      string    `json:"role"`
+	GrantedBy int64     `json:"granted_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IsRoleAllowed reports whether role has permissions of required role,
+// unknown role is treated as guest and unknown required role is never allowed.
+func IsRoleAllowed(role string, required string) bool {
+	requiredLevel := getRoleLevel(required)
+	if requiredLevel < 0 {
+		return false
+	}
+
+	level := getRoleLevel(role)
+	if level < 0 {
+		level = 0
+	}
+
+	return level >= requiredLevel
+}
+
+func IsKnownRole(role string) bool {
+	return getRoleLevel(role) >= 0
+}
+
+func getRoleLevel(role string) int {
+	for level, known := range ROLES {
+		if known == role {
+			return level
+		}
+	}
+
+	return -1
+}
+
+// GetUserRole returns stored role of the user or empty string.
+func (database *Database) GetUserRole(userID int64) (string, error) {
+	var role string
+	err := database.client.QueryRow(
+		context.Background(),
+		SQL_SELECT_USER_ROLE,
+		userID,
+	).Scan(&role)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return "", nil
+		}
+
+		return "", karma.Format(
+			err,
+			"unable to get role of the user: %d",
+			userID,
+		)
+	}
+
+	return role, nil
+}
+
+func (database *Database) GetUserRoles() ([]UserRole, error) {
+	rows, err := database.client.Query(context.Background(), SQL_SELECT_USER_ROLES)
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to get user roles from the database",
+		)
+	}
+
+	defer rows.Close()
+
+	var roles []UserRole
+	for rows.Next() {
+		var role UserRole
+		err := rows.Scan(&role.UserID, &role.Role, &role.GrantedBy, &role.UpdatedAt)
+		if err != nil {
+			return nil, karma.Format(
+				err,
+				"error during scaning user roles from database rows",
+			)
+		}
+
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+func (database *Database) SetUserRole(role UserRole) error {
+	return database.execUserRole(SQL_UPSERT_USER_ROLE, role)
+}
+
+// AddUserRole keeps the role which has been set before.
+func (database *Database) AddUserRole(role UserRole) error {
+	return database.execUserRole(SQL_INSERT_USER_ROLE_IF_NOT_EXISTS, role)
+}
+
+// SetOwner makes the user the only owner, previous owners become admins.
+func (database *Database) SetOwner(userID int64) error {
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to get current time before setting owner",
+		)
+	}
+
+	ctx := context.Background()
+	tx, err := database.client.Begin(ctx)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to begin transaction for owner",
+		)
+	}
+
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, SQL_DEMOTE_OTHER_OWNERS, userID, timeNow)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to demote previous owners",
+		)
+	}
+
+	_, err = tx.Exec(ctx, SQL_UPSERT_USER_ROLE, userID, ROLE_OWNER, 0, timeNow)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to set owner: %d",
+			userID,
+		)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to commit owner",
+		)
+	}
+
+	return nil
+}
+
+func (database *Database) execUserRole(query string, role UserRole) error {
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to get current time before updating user role",
+		)
+	}
+
+	_, err = database.client.Exec(
+		context.Background(),
+		query,
+		role.UserID,
+		role.Role,
+		role.GrantedBy,
+		timeNow,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to update role of the user: %d",
+			role.UserID,
+		)
+	}
+
+	return nil
+}
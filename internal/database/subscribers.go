@@ -0,0 +1,231 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+	"github.com/jackc/pgx/v4"
+	"github.com/reconquest/karma-go"
+)
+
+type Subscriber struct {
+	ChatID             int64      `json:"chat_id"`
+	Type               string     `json:"type"`
+	Username           string     `json:"username"`
+	IsActive           bool       `json:"is_active"`
+	JoinedAt           time.Time  `json:"joined_at"`
+	SecretKey          string     `json:"secret_key"`
+	SecretKeyExpiredAt *time.Time `json:"secret_key_expired_at"`
+}
+
+// UpsertSubscriber adds new subscriber or activates existing one without
+// access expiry, joined_at of existing subscriber is kept.
+func (database *Database) UpsertSubscriber(subscriber Subscriber) error {
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to get current time before updating subscriber",
+		)
+	}
+
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_UPSERT_TELEGRAM_SUBSCRIBER,
+		subscriber.ChatID,
+		subscriber.Type,
+		subscriber.Username,
+		timeNow,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to add subscriber to the database, chat_id: %d",
+			subscriber.ChatID,
+		)
+	}
+
+	return nil
+}
+
+func (database *Database) DeactivateSubscriber(chatID int64) (bool, error) {
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return false, karma.Format(
+			err,
+			"unable to get current time before deactivating subscriber",
+		)
+	}
+
+	tag, err := database.client.Exec(
+		context.Background(),
+		SQL_DEACTIVATE_TELEGRAM_SUBSCRIBER,
+		chatID,
+		timeNow,
+	)
+	if err != nil {
+		return false, karma.Format(
+			err,
+			"unable to deactivate subscriber in the database, chat_id: %d",
+			chatID,
+		)
+	}
+
+	return tag.RowsAffected() != 0, nil
+}
+
+func (database *Database) GetActiveSubscribers() ([]Subscriber, error) {
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to get current time before receiving subscribers",
+		)
+	}
+
+	rows, err := database.client.Query(
+		context.Background(),
+		SQL_SELECT_ACTIVE_TELEGRAM_SUBSCRIBERS,
+		timeNow,
+	)
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to get active subscribers from the database",
+		)
+	}
+
+	return scanSubscribers(rows)
+}
+
+func (database *Database) GetSubscriber(chatID int64) (*Subscriber, error) {
+	rows, err := database.client.Query(
+		context.Background(),
+		SQL_SELECT_TELEGRAM_SUBSCRIBER,
+		chatID,
+	)
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to get subscriber from the database, chat_id: %d",
+			chatID,
+		)
+	}
+
+	subscribers, err := scanSubscribers(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(subscribers) == 0 {
+		return nil, nil
+	}
+
+	return &subscribers[0], nil
+}
+
+// GetSubscribersToRemind returns active subscribers which access expires
+// before specified time and which have not been reminded yet.
+func (database *Database) GetSubscribersToRemind(until time.Time) ([]Subscriber, error) {
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to get current time before receiving subscribers to remind",
+		)
+	}
+
+	rows, err := database.client.Query(
+		context.Background(),
+		SQL_SELECT_TELEGRAM_SUBSCRIBERS_TO_REMIND,
+		timeNow,
+		until,
+	)
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to get subscribers to remind from the database",
+		)
+	}
+
+	return scanSubscribers(rows)
+}
+
+func (database *Database) MarkSubscriberReminded(chatID int64) error {
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to get current time before marking subscriber reminded",
+		)
+	}
+
+	_, err = database.client.Exec(
+		context.Background(),
+		SQL_UPDATE_TELEGRAM_SUBSCRIBER_REMINDED_AT,
+		chatID,
+		timeNow,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to mark subscriber reminded, chat_id: %d",
+			chatID,
+		)
+	}
+
+	return nil
+}
+
+func (database *Database) DeactivateExpiredSubscribers() ([]Subscriber, error) {
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to get current time before deactivating expired subscribers",
+		)
+	}
+
+	rows, err := database.client.Query(
+		context.Background(),
+		SQL_DEACTIVATE_EXPIRED_TELEGRAM_SUBSCRIBERS,
+		timeNow,
+	)
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to deactivate expired subscribers in the database",
+		)
+	}
+
+	return scanSubscribers(rows)
+}
+
+func scanSubscribers(rows pgx.Rows) ([]Subscriber, error) {
+	defer rows.Close()
+
+	var subscribers []Subscriber
+	for rows.Next() {
+		var subscriber Subscriber
+		err := rows.Scan(
+			&subscriber.ChatID,
+			&subscriber.Type,
+			&subscriber.Username,
+			&subscriber.IsActive,
+			&subscriber.JoinedAt,
+			&subscriber.SecretKey,
+			&subscriber.SecretKeyExpiredAt,
+		)
+		if err != nil {
+			return nil, karma.Format(
+				err,
+				"error during scaning subscribers from database rows",
+			)
+		}
+
+		subscribers = append(subscribers, subscriber)
+	}
+
+	return subscribers, rows.Err()
+}
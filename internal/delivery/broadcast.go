@@ -0,0 +1,201 @@
+package delivery
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/daniilsolovey/BetBotGo/internal/config"
+	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+	"github.com/reconquest/karma-go"
+	"github.com/reconquest/pkg/log"
+)
+
+const (
+	CATEGORY_SIGNAL = "signal"
+	CATEGORY_REPORT = "report"
+	CATEGORY_ALERT  = "alert"
+	CATEGORY_ERROR  = "error"
+
+	TARGET_CHANNEL    = "channel"
+	TARGET_ADMIN      = "admin"
+	TARGET_DISCUSSION = "discussion"
+)
+
+var (
+	CATEGORIES = []string{CATEGORY_SIGNAL, CATEGORY_REPORT, CATEGORY_ALERT, CATEGORY_ERROR}
+	TARGETS    = []string{TARGET_CHANNEL, TARGET_ADMIN, TARGET_DISCUSSION}
+
+	DEFAULT_BROADCAST_ROUTES = map[string][]string{
+		CATEGORY_SIGNAL: {TARGET_CHANNEL},
+		CATEGORY_REPORT: {TARGET_CHANNEL},
+		CATEGORY_ALERT:  {TARGET_ADMIN},
+		CATEGORY_ERROR:  {TARGET_ADMIN},
+	}
+)
+
+// GetBroadcastTargets returns chat ids of configured broadcast targets.
+func GetBroadcastTargets(broadcast config.Broadcast) map[string]int64 {
+	targets := map[string]int64{}
+	for target, chatID := range map[string]int64{
+		TARGET_CHANNEL:    broadcast.ChannelID,
+		TARGET_ADMIN:      broadcast.AdminChatID,
+		TARGET_DISCUSSION: broadcast.DiscussionGroupID,
+	} {
+		if chatID != 0 {
+			targets[target] = chatID
+		}
+	}
+
+	return targets
+}
+
+// GetBroadcastRoutes resolves routes to chat ids by category, categories
+// missing in config are routed by default to targets which are configured.
+func GetBroadcastRoutes(broadcast config.Broadcast) (map[string][]int64, error) {
+	targets := GetBroadcastTargets(broadcast)
+
+	routes := map[string][]int64{}
+	for _, category := range CATEGORIES {
+		for _, target := range DEFAULT_BROADCAST_ROUTES[category] {
+			if chatID, ok := targets[target]; ok {
+				routes[category] = append(routes[category], chatID)
+			}
+		}
+	}
+
+	for category, names := range broadcast.Routes {
+		if !tools.Find(CATEGORIES, category) {
+			return nil, fmt.Errorf(
+				"unknown broadcast category: %s, known: %s",
+				category,
+				strings.Join(CATEGORIES, ", "),
+			)
+		}
+
+		routes[category] = nil
+		for _, name := range names {
+			if !tools.Find(TARGETS, name) {
+				return nil, fmt.Errorf(
+					"unknown broadcast target %s in route %s, known: %s",
+					name,
+					category,
+					strings.Join(TARGETS, ", "),
+				)
+			}
+
+			chatID, ok := targets[name]
+			if !ok {
+				return nil, fmt.Errorf(
+					"broadcast target %s in route %s has no chat id",
+					name,
+					category,
+				)
+			}
+
+			if !containsChat(routes[category], chatID) {
+				routes[category] = append(routes[category], chatID)
+			}
+		}
+	}
+
+	return routes, nil
+}
+
+// SetBroadcastRoutes enables sending messages to broadcast chats, routes are
+// returned by GetBroadcastRoutes.
+func (delivery *Delivery) SetBroadcastRoutes(routes map[string][]int64) {
+	delivery.routes = routes
+}
+
+// IsBroadcastChat returns true when the chat is routed for any category.
+func (delivery *Delivery) IsBroadcastChat(chatID int64) bool {
+	for _, chatIDs := range delivery.routes {
+		if containsChat(chatIDs, chatID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SendAlertFunc queues operational message only to chats routed for alerts,
+// error is returned when it has not been queued for any of them.
+func (delivery *Delivery) SendAlertFunc(getText func(chatID int64) string) error {
+	chatIDs := delivery.routes[CATEGORY_ALERT]
+	messages := delivery.broadcastFunc(CATEGORY_ALERT, nil, func(chatID int64) Message {
+		return Message{Text: getText(chatID)}
+	})
+	if len(messages) == 0 && len(chatIDs) != 0 {
+		return fmt.Errorf("unable to queue alert for any of %d chats", len(chatIDs))
+	}
+
+	return nil
+}
+
+func (delivery *Delivery) Broadcast(category string, message Message) []SentMessage {
+	return delivery.broadcastFunc(category, nil, func(int64) Message {
+		return message
+	})
+}
+
+// broadcastFunc queues message to every chat routed for the category except
+// chats which have already received it as subscribers.
+func (delivery *Delivery) broadcastFunc(
+	category string,
+	received []SentMessage,
+	getMessage func(chatID int64) Message,
+) []SentMessage {
+	var sentMessages []SentMessage
+	for _, chatID := range delivery.routes[category] {
+		if isReceived(received, chatID) {
+			continue
+		}
+
+		message := getMessage(chatID)
+		if message.Text == "" {
+			continue
+		}
+
+		outboxID, err := delivery.enqueue(chatID, message, 0)
+		if err != nil {
+			log.Errorf(
+				karma.Describe("category", category).Reason(err),
+				"unable to queue broadcast message, chat_id: %d",
+				chatID,
+			)
+			continue
+		}
+
+		sentMessages = append(sentMessages, SentMessage{
+			ChatID:   chatID,
+			OutboxID: outboxID,
+		})
+	}
+
+	return sentMessages
+}
+
+func getBroadcastSubscriber(chatID int64) database.Subscriber {
+	return database.Subscriber{ChatID: chatID, IsActive: true}
+}
+
+func isReceived(messages []SentMessage, chatID int64) bool {
+	for _, message := range messages {
+		if message.ChatID == chatID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsChat(chatIDs []int64, chatID int64) bool {
+	for _, item := range chatIDs {
+		if item == chatID {
+			return true
+		}
+	}
+
+	return false
+}
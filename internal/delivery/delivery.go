@@ -0,0 +1,213 @@
+package delivery
+
+import (
+	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+	"github.com/reconquest/karma-go"
+	"github.com/reconquest/pkg/log"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+// Message is sent as HTML when HTML is set, buttons are attached as inline
+// keyboard. Message with PhotoID is sent as image added by AddPhoto and Text
+// is its caption.
+type Message struct {
+	Text    string
+	HTML    bool
+	Buttons [][]tb.InlineButton
+	PhotoID int64
+}
+
+// SentMessage refers to the message queued in outbox for the chat.
+type SentMessage struct {
+	ChatID   int64
+	OutboxID int64
+}
+
+// Delivery fans out messages to all active subscribers and broadcast chats
+// through outbox, the outbox sender delivers them to telegram.
+type Delivery struct {
+	database database.DatabaseInterface
+	routes   map[string][]int64
+}
+
+func NewDelivery(database database.DatabaseInterface) *Delivery {
+	return &Delivery{
+		database: database,
+	}
+}
+
+// WithDatabase returns delivery which queues messages with the given
+// database, e.g. in transaction of database.SendSignalOnce.
+func (delivery *Delivery) WithDatabase(database database.DatabaseInterface) *Delivery {
+	return &Delivery{
+		database: database,
+		routes:   delivery.routes,
+	}
+}
+
+func (delivery *Delivery) SendToSubscribers(text string) ([]SentMessage, error) {
+	return delivery.SendToSubscribersFunc(func(database.Subscriber) string {
+		return text
+	})
+}
+
+// SendToSubscribersFunc queues text prepared for each subscriber, subscribers
+// with empty text are skipped. Error is returned only when message has not
+// been queued for anyone, so the caller is able to retry it.
+func (delivery *Delivery) SendToSubscribersFunc(
+	getText func(database.Subscriber) string,
+) ([]SentMessage, error) {
+	return delivery.SendMessageToSubscribersFunc(func(subscriber database.Subscriber) Message {
+		return Message{Text: getText(subscriber)}
+	})
+}
+
+func (delivery *Delivery) SendMessageToSubscribersFunc(
+	getMessage func(database.Subscriber) Message,
+) ([]SentMessage, error) {
+	subscribers, err := delivery.database.GetActiveSubscribers()
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to get active subscribers",
+		)
+	}
+
+	var sentMessages []SentMessage
+	var enqueueErr error
+	for _, subscriber := range subscribers {
+		message := getMessage(subscriber)
+		if message.Text == "" {
+			continue
+		}
+
+		outboxID, err := delivery.enqueue(subscriber.ChatID, message, 0)
+		if err != nil {
+			enqueueErr = err
+			log.Error(err)
+			continue
+		}
+
+		sentMessages = append(sentMessages, SentMessage{
+			ChatID:   subscriber.ChatID,
+			OutboxID: outboxID,
+		})
+	}
+
+	if len(sentMessages) == 0 && enqueueErr != nil {
+		return nil, karma.Format(
+			enqueueErr,
+			"unable to queue message for any of %d subscribers",
+			len(subscribers),
+		)
+	}
+
+	return sentMessages, nil
+}
+
+func (delivery *Delivery) ReplyToMessages(messages []SentMessage, text string) error {
+	return delivery.ReplyToMessagesFunc(messages, func(SentMessage) string {
+		return text
+	})
+}
+
+// ReplyToMessagesFunc queues replies, the outbox sender sends them only after
+// the messages they reply to.
+func (delivery *Delivery) ReplyToMessagesFunc(
+	messages []SentMessage,
+	getText func(SentMessage) string,
+) error {
+	var replied int
+	var replyErr error
+	for _, message := range messages {
+		_, err := delivery.enqueue(
+			message.ChatID,
+			Message{Text: getText(message)},
+			message.OutboxID,
+		)
+		if err != nil {
+			replyErr = err
+			log.Error(err)
+			continue
+		}
+
+		replied++
+	}
+
+	if replied == 0 && replyErr != nil {
+		return karma.Format(
+			replyErr,
+			"unable to queue reply to any of %d messages",
+			len(messages),
+		)
+	}
+
+	return nil
+}
+
+// EditMessagesFunc queues edits of the messages, the outbox keeps only the
+// latest pending edit of every message.
+func (delivery *Delivery) EditMessagesFunc(
+	messages []SentMessage,
+	getMessage func(SentMessage) Message,
+) error {
+	var edited int
+	var editErr error
+	for _, message := range messages {
+		edit := getMessage(message)
+		_, err := delivery.database.EnqueueOutboxMessage(database.OutboxMessage{
+			ChatID:    message.ChatID,
+			Text:      edit.Text,
+			HTML:      edit.HTML,
+			Buttons:   edit.Buttons,
+			EditOfID:  message.OutboxID,
+			CreatedAt: tools.TimeNow(),
+		})
+		if err != nil {
+			editErr = err
+			log.Error(err)
+			continue
+		}
+
+		edited++
+	}
+
+	if edited == 0 && editErr != nil {
+		return karma.Format(
+			editErr,
+			"unable to queue edit of any of %d messages",
+			len(messages),
+		)
+	}
+
+	return nil
+}
+
+// AddPhoto stores image once for messages to all chats, it is uploaded to
+// telegram once as well.
+func (delivery *Delivery) AddPhoto(image []byte) (int64, error) {
+	return delivery.database.InsertOutboxPhoto(image, tools.TimeNow())
+}
+
+// SendToSubscriber queues message for one subscriber.
+func (delivery *Delivery) SendToSubscriber(chatID int64, text string) error {
+	_, err := delivery.enqueue(chatID, Message{Text: text}, 0)
+	return err
+}
+
+func (delivery *Delivery) enqueue(chatID int64, message Message, replyToID int64) (int64, error) {
+	return delivery.database.EnqueueOutboxMessage(database.OutboxMessage{
+		ChatID:    chatID,
+		Text:      message.Text,
+		HTML:      message.HTML,
+		Buttons:   message.Buttons,
+		PhotoID:   message.PhotoID,
+		ReplyToID: replyToID,
+		CreatedAt: tools.TimeNow(),
+	})
+}
+
+func GetRecipient(chatID int64) tb.Recipient {
+	return &tb.Chat{ID: chatID}
+}
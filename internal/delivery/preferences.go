@@ -0,0 +1,225 @@
+package delivery
+
+import (
+	"strings"
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/daniilsolovey/BetBotGo/internal/i18n"
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+	"github.com/reconquest/karma-go"
+	"github.com/reconquest/pkg/log"
+)
+
+type Signal struct {
+	EventID     string
+	LeagueID    string
+	CountryCode string
+	LiveOdd     float64
+}
+
+// SendSignalToSubscribers queues signal only to subscribers which preferences
+// allow it and records every delivery for the daily limit, broadcast chats
+// receive every signal.
+func (delivery *Delivery) SendSignalToSubscribers(
+	signal Signal,
+	getMessage func(database.Subscriber) Message,
+) ([]SentMessage, error) {
+	messages, err := delivery.SendMessageToSubscribersFunc(func(subscriber database.Subscriber) Message {
+		allowed, reason := delivery.isSignalAllowedForChat(subscriber.ChatID, signal)
+		if !allowed {
+			log.Debugf(
+				karma.Describe("event_id", signal.EventID).Describe("reason", reason),
+				"signal skipped by preferences, chat_id: %d",
+				subscriber.ChatID,
+			)
+			return Message{}
+		}
+
+		return getMessage(subscriber)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, message := range messages {
+		err := delivery.database.InsertSignalDelivery(database.SignalDelivery{
+			ChatID:   message.ChatID,
+			EventID:  signal.EventID,
+			OutboxID: message.OutboxID,
+		})
+		if err != nil {
+			log.Error(err)
+		}
+	}
+
+	messages = append(
+		messages,
+		delivery.broadcastFunc(CATEGORY_SIGNAL, messages, func(chatID int64) Message {
+			return getMessage(getBroadcastSubscriber(chatID))
+		})...,
+	)
+
+	return messages, nil
+}
+
+func (delivery *Delivery) SendReportToSubscribers(reportType string, text string) error {
+	return delivery.SendReportToSubscribersFunc(reportType, func(database.Subscriber) string {
+		return text
+	})
+}
+
+func (delivery *Delivery) SendReportToSubscribersFunc(
+	reportType string,
+	getText func(database.Subscriber) string,
+) error {
+	return delivery.SendReportMessageToSubscribersFunc(
+		reportType,
+		func(subscriber database.Subscriber) Message {
+			return Message{Text: getText(subscriber)}
+		},
+	)
+}
+
+// SendReportMessageToSubscribersFunc works as SendMessageToSubscribersFunc
+// but skips subscribers which have disabled given report type.
+func (delivery *Delivery) SendReportMessageToSubscribersFunc(
+	reportType string,
+	getMessage func(database.Subscriber) Message,
+) error {
+	messages, err := delivery.SendMessageToSubscribersFunc(func(subscriber database.Subscriber) Message {
+		preferences, err := delivery.database.GetSubscriberPreferences(subscriber.ChatID)
+		if err != nil {
+			log.Error(err)
+			preferences = database.GetDefaultPreferences(subscriber.ChatID)
+		}
+
+		if !tools.Find(preferences.Reports, reportType) {
+			return Message{}
+		}
+
+		return getMessage(subscriber)
+	})
+
+	delivery.broadcastFunc(CATEGORY_REPORT, messages, func(chatID int64) Message {
+		return getMessage(getBroadcastSubscriber(chatID))
+	})
+
+	return err
+}
+
+// GetLocation returns timezone of the chat or the default one.
+func (delivery *Delivery) GetLocation(chatID int64) *time.Location {
+	location, err := tools.GetLocation()
+	if err != nil {
+		log.Error(err)
+		location = time.UTC
+	}
+
+	timezone, err := delivery.database.GetChatTimezone(chatID)
+	if err != nil {
+		log.Error(err)
+		return location
+	}
+
+	if timezone == "" {
+		return location
+	}
+
+	chatLocation, err := tools.LoadLocation(timezone)
+	if err != nil {
+		log.Error(err)
+		return location
+	}
+
+	return chatLocation
+}
+
+// GetLanguage returns language of the chat or the default one.
+func (delivery *Delivery) GetLanguage(chatID int64) string {
+	language, err := delivery.database.GetChatLanguage(chatID)
+	if err != nil {
+		log.Error(err)
+	}
+
+	if !i18n.IsSupported(language) {
+		return i18n.DefaultLanguage
+	}
+
+	return language
+}
+
+func (delivery *Delivery) isSignalAllowedForChat(chatID int64, signal Signal) (bool, string) {
+	preferences, err := delivery.database.GetSubscriberPreferences(chatID)
+	if err != nil {
+		log.Error(err)
+		return true, ""
+	}
+
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		log.Error(err)
+		return true, ""
+	}
+
+	timeNow = timeNow.In(delivery.GetLocation(chatID))
+
+	var deliveredToday int
+	if preferences.MaxSignalsPerDay > 0 {
+		deliveredToday, err = delivery.database.CountSignalDeliveries(
+			chatID,
+			tools.BeginningOfDay(timeNow),
+		)
+		if err != nil {
+			log.Error(err)
+		}
+	}
+
+	return IsSignalAllowed(preferences, signal, timeNow, deliveredToday)
+}
+
+// IsSignalAllowed returns false and reason when signal doesn't match
+// preferences, timeNow must be in timezone of the chat.
+func IsSignalAllowed(
+	preferences database.Preferences,
+	signal Signal,
+	timeNow time.Time,
+	deliveredToday int,
+) (bool, string) {
+	if len(preferences.Leagues) != 0 && !tools.Find(preferences.Leagues, signal.LeagueID) {
+		return false, "league is not allowed"
+	}
+
+	if len(preferences.Countries) != 0 &&
+		!tools.Find(preferences.Countries, strings.ToLower(signal.CountryCode)) {
+		return false, "country is not allowed"
+	}
+
+	if signal.LiveOdd < preferences.MinLiveOdd {
+		return false, "live odd is below minimum"
+	}
+
+	if preferences.MaxSignalsPerDay > 0 && deliveredToday >= preferences.MaxSignalsPerDay {
+		return false, "daily signals limit is reached"
+	}
+
+	if IsQuietHour(preferences.QuietHoursFrom, preferences.QuietHoursTo, timeNow.Hour()) {
+		return false, "quiet hours"
+	}
+
+	return true, ""
+}
+
+// IsQuietHour checks hour against quiet hours range [from, to) which may
+// wrap around midnight, equal bounds mean quiet hours are disabled.
+func IsQuietHour(from int, to int, hour int) bool {
+	if from == to {
+		return false
+	}
+
+	if from < to {
+		return hour >= from && hour < to
+	}
+
+	return hour >= from || hour < to
+}
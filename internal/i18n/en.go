@@ -0,0 +1,188 @@
+package i18n
+
+var en = Catalogue{
+	TEXT_ABOUT_START: "Hi! I am a telegram bot and I can notify you about all events for today" +
+		" on volleyball. Send /stop to unsubscribe",
+	TEXT_ABOUT_STOP:              "You are unsubscribed, send /start to subscribe again",
+	TEXT_ABOUT_TIMEZONE_USAGE:    "Usage: /timezone Europe/Berlin",
+	TEXT_ABOUT_TIMEZONE_CHANGED:  "Timezone for messages changed to %s",
+	TEXT_ABOUT_LANGUAGE_USAGE:    "Usage: /language <%s>",
+	TEXT_ABOUT_LANGUAGE_CHANGED:  "Language for messages changed to English",
+	TEXT_ABOUT_EVENTS_FOR_DAY:    "Selected events for %s:\n",
+	TEXT_ABOUT_NO_EVENTS_FOR_DAY: "No selected events for %s\n",
+	TEXT_ABOUT_EVENT_FOR_DAY:     "  %s %s - %s (%s), favorite: %s, odds: %s / %s\n",
+	TEXT_ABOUT_DIGEST:            "☀️ Candidates for %s:\n",
+	TEXT_ABOUT_DIGEST_UPDATE:     "🆕 New candidates for %s:\n",
+	TEXT_ABOUT_DIGEST_LEAGUE:     "\n🏆 %s\n",
+	TEXT_ABOUT_DIGEST_EVENT:      "  %s %s - %s, favorite: %s, odd: %s\n",
+	TEXT_ABOUT_ODDS_DRIFT: "Pre-match odds changed: %s\n" +
+		"  event_id: %s\n" +
+		"  league: %s\n" +
+		"  home: %s\n" +
+		"  away: %s\n" +
+		"  opening odds: %s / %s\n" +
+		"  current odds: %s / %s\n" +
+		"  start: %s\n" +
+		"  opening favorite: %s\n" +
+		"  current favorite: %s\n",
+	TEXT_ABOUT_SIGNAL_CANCEL: "CANCELED! The bet is no longer relevant\n" +
+		"  event_id: %s\n" +
+		"  reason: %s\n" +
+		"  home odd: %s\n" +
+		"  away odd: %s\n" +
+		"  score: %s\n",
+	TEXT_ABOUT_SIGNAL_UPDATE: "UPDATE! Favorite odd has changed\n" +
+		"  event_id: %s\n" +
+		"  favorite odd: %s -> %s\n" +
+		"  home odd: %s\n" +
+		"  away odd: %s\n" +
+		"  score: %s\n",
+	TEXT_ABOUT_SIGNAL_FALLBACK:         "Signal: bet on %s\n  event_id: %s\n",
+	TEXT_ABOUT_SIGNAL_SKIPPED:          "Signal skipped",
+	TEXT_ABOUT_EVENT_NOT_FOUND:         "Event not found",
+	TEXT_ABOUT_BUTTONS_IN_PRIVATE_CHAT: "Buttons work in private chat with the bot",
+
+	TEXT_FAVORITE_HOME: "home",
+	TEXT_FAVORITE_AWAY: "away",
+
+	TEXT_ODDS_DRIFT_KIND_DRIFT: "drift",
+	TEXT_ODDS_DRIFT_KIND_FLIP:  "favorite flip",
+
+	TEXT_REASON_MARKET_SUSPENDED: "market suspended",
+	TEXT_REASON_ODD_BELOW_CUTOFF: "favorite odd is not above cutoff",
+	TEXT_REASON_MATCH_ABANDONED:  "match abandoned",
+
+	TEXT_BUTTON_PLACED:  "✅ Placed",
+	TEXT_BUTTON_SKIP:    "🚫 Skip",
+	TEXT_BUTTON_DETAILS: "ℹ️ Details",
+
+	TEXT_ABOUT_BET_PLACED:         "Bet saved",
+	TEXT_ABOUT_BET_ALREADY_PLACED: "Bet is already saved",
+	TEXT_ABOUT_BET_DIALOG: "Bet on %s saved: stake %s, odd %s\n" +
+		"To change it, send stake and odd, e.g.: 100 1.85",
+	TEXT_ABOUT_BET_DIALOG_USAGE: "Send stake and odd, e.g.: 100 1.85",
+	TEXT_ABOUT_BET_UPDATED:      "Bet updated: stake %s, odd %s",
+	TEXT_ABOUT_BET_SETTLED: "Bet settled: %s - %s\n" +
+		"  event_id: %s\n" +
+		"  profit: %s\n",
+	TEXT_ABOUT_NO_BETS: "You have no saved bets, press «%s» under a signal",
+	TEXT_ABOUT_MY_BETS: "Your bets:\n" +
+		"  total: %d\n" +
+		"  won: %d\n" +
+		"  lost: %d\n" +
+		"  open: %d\n" +
+		"  staked: %s\n" +
+		"  profit: %s\n" +
+		"  roi: %s%%\n\n" +
+		"Recent bets:\n",
+	TEXT_ABOUT_MY_BET:    "  %s %s: %s x %s, %s %s\n",
+	TEXT_BET_STATUS_OPEN: "open",
+	TEXT_BET_STATUS_WON:  "won",
+	TEXT_BET_STATUS_LOST: "lost",
+
+	TEXT_ABOUT_SETTINGS: "Your settings:\n" +
+		"  leagues: %s\n" +
+		"  countries: %s\n" +
+		"  min_odd: %s\n" +
+		"  max_signals: %s\n" +
+		"  quiet: %s\n" +
+		"  reports: %s\n",
+	TEXT_ABOUT_SETTINGS_USAGE: "Change setting with button below or: /settings <name> <value>\n" +
+		"  /settings leagues 22614,22615 | all\n" +
+		"  /settings countries it,pl | all\n" +
+		"  /settings min_odd 1.5 | off\n" +
+		"  /settings max_signals 5 | off\n" +
+		"  /settings quiet 23-8 | off\n" +
+		"  /settings reports %s | off\n",
+	TEXT_ABOUT_SETTING_INVALID:       "Setting is not changed: %s\n\n",
+	TEXT_ABOUT_SETTING_DIALOG:        "Send new value of %s",
+	TEXT_SETTING_VALUE_REQUIRED:      "value is required",
+	TEXT_SETTING_UNKNOWN:             "unknown setting: %s",
+	TEXT_SETTING_INVALID_MIN_ODD:     "min_odd must be a positive number",
+	TEXT_SETTING_INVALID_MAX_SIGNALS: "max_signals must be a positive integer",
+	TEXT_SETTING_INVALID_QUIET_HOURS: "quiet hours must be <from>-<to> between 0 and 23, e.g. 23-8",
+	TEXT_SETTING_UNKNOWN_REPORT:      "unknown report type: %s",
+
+	TEXT_ABOUT_ACCESS_CODE_REQUIRED:  "Access code is required, send /start <code> or open invite link",
+	TEXT_ABOUT_ACCESS_CODE_NOT_FOUND: "Access code is not accepted: access code not found",
+	TEXT_ABOUT_ACCESS_CODE_EXPIRED:   "Access code is not accepted: access code has expired",
+	TEXT_ABOUT_ACCESS_CODE_USED_UP:   "Access code is not accepted: access code has been used up",
+	TEXT_ABOUT_ACCESS_GRANTED:        "Access granted until %s. Send /stop to unsubscribe",
+	TEXT_ABOUT_CREATE_CODE_USAGE:     "Usage: /create_code <days> [uses], access lasts days since redemption, uses 0 means unlimited, default is 1",
+	TEXT_ABOUT_ACCESS_CODE_CREATED: "Access code: %s\n" +
+		"  access days: %d\n" +
+		"  uses: %s\n" +
+		"  redeem until: %s\n" +
+		"  link: %s\n",
+	TEXT_ACCESS_CODE_UNLIMITED: "unlimited",
+	TEXT_ABOUT_ACCESS_EXPIRES:  "Your access expires at %s, ask admin for a new access code",
+	TEXT_ABOUT_ACCESS_EXPIRED:  "Your access has expired, send /start <code> with a new access code",
+
+	TEXT_ABOUT_ACCESS_DENIED:        "Not enough rights, required role: %s",
+	TEXT_ABOUT_ADMIN_COMMAND_USAGE:  "Usage: %s <%s>",
+	TEXT_ABOUT_ADMIN_COMMAND_ERROR:  "error: %s",
+	TEXT_ABOUT_ERROR:                "⚠️ Error: %s",
+	TEXT_ABOUT_EVENT_MONITORED:      "Event %s added to live monitoring: %s - %s (%s), favorite: %s",
+	TEXT_ABOUT_EVENT_MONITOR_LATER:  "Event %s is not found in upcoming events, it will be monitored when it appears",
+	TEXT_ABOUT_EVENT_SKIPPED:        "Event %s skipped, live monitoring routine canceled",
+	TEXT_ABOUT_EVENT_SKIPPED_FUTURE: "Event %s skipped, it has no live monitoring routine yet",
+	TEXT_ABOUT_TEAM_BANNED:          "Team %q banned, its events are excluded from now on",
+	TEXT_ABOUT_LEAGUE_BANNED:        "League %s banned, its events are excluded from now on",
+	TEXT_ABOUT_ROLE_USAGE:           "Usage: /role <user_id> <role>, roles: %s",
+	TEXT_ABOUT_ROLE_SET:             "Role of user %d is %s now",
+	TEXT_ABOUT_ROLE_NOT_ALLOWED:     "Only owner can grant or revoke admin role, owner is set in config",
+
+	TEXT_STATISTICS_ON_PREVIOUS_DAY: "Results for yesterday:\n" +
+		"  win: %d\n" +
+		"  lose: %d\n" +
+		"  average odd: %s\n",
+	TEXT_STATISTICS_ON_PREVIOUS_WEEK: "Results for previous week:\n" +
+		"  win: %d\n" +
+		"  lose: %d\n" +
+		"  average odd: %s\n",
+
+	TEXT_ABOUT_HELP:         "Commands:\n",
+	TEXT_ABOUT_HELP_ADMIN:   "\nAdmin commands:\n",
+	TEXT_ABOUT_HELP_COMMAND: "  %s - %s\n",
+	TEXT_ABOUT_LIVE_EVENTS:  "Events in live monitoring:\n",
+	TEXT_ABOUT_LIVE_EVENT:   "  %s - %s (%s), favorite: %s, score: %s, set %d, odds: %s / %s\n",
+	TEXT_ABOUT_NO_LIVE:      "No events in live monitoring now",
+	TEXT_ABOUT_STATS: "Statistics from %s to %s:\n" +
+		"  win: %d\n" +
+		"  lose: %d\n" +
+		"  average odd: %s\n",
+	TEXT_ABOUT_STATS_USAGE:   "Usage: /stats [day|week|month] or /stats <from> <to>, dates in format 2021-09-05",
+	TEXT_ABOUT_HISTORY:       "Last signals:\n",
+	TEXT_ABOUT_HISTORY_USAGE: "Usage: /history [number from 1 to %d]",
+	TEXT_ABOUT_NO_HISTORY:    "No signals yet",
+	TEXT_ABOUT_SIGNAL_RESULT: "  %s %s - %s, bet on %s at %s: %s\n",
+	TEXT_SIGNAL_RESULT_WON:   "✅ won (%s)",
+	TEXT_SIGNAL_RESULT_LOST:  "❌ lost (%s)",
+	TEXT_SIGNAL_RESULT_OPEN:  "⏳ in play",
+	TEXT_ABOUT_CHARTS_USAGE:  "Usage: /charts [day|week|month] or /charts <from> <to>, dates in format 2021-09-05, at most %d days",
+	TEXT_ABOUT_NO_RESULTS:    "No settled signals from %s to %s",
+	TEXT_CHART_PROFIT:        "📈 Profit with flat stake of 1 unit from %s to %s",
+	TEXT_CHART_DAILY:         "📊 Wins 🟩 and losses 🟥 by day from %s to %s",
+	TEXT_CHART_LEAGUES:       "🏐 Hit rate by league from %s to %s, number of signals in brackets",
+	TEXT_CHART_ODDS:          "🎯 Wins 🟩 and losses 🟥 by favorite odds from %s to %s",
+
+	TEXT_COMMAND_START:       "subscribe to signals",
+	TEXT_COMMAND_STOP:        "unsubscribe",
+	TEXT_COMMAND_TODAY:       "selected events for today",
+	TEXT_COMMAND_TOMORROW:    "selected events for tomorrow",
+	TEXT_COMMAND_LIVE:        "events in live monitoring with their scores",
+	TEXT_COMMAND_STATS:       "statistics for day, week, month or from <date> to <date>",
+	TEXT_COMMAND_HISTORY:     "last N signals with their results",
+	TEXT_COMMAND_CHARTS:      "charts of results for day, week, month or from <date> to <date>",
+	TEXT_COMMAND_MY_BETS:     "your bets",
+	TEXT_COMMAND_SETTINGS:    "signal and report filters",
+	TEXT_COMMAND_TIMEZONE:    "timezone for messages",
+	TEXT_COMMAND_LANGUAGE:    "language of messages",
+	TEXT_COMMAND_HELP:        "list of commands",
+	TEXT_COMMAND_MONITOR:     "add event to live monitoring",
+	TEXT_COMMAND_SKIP:        "skip event",
+	TEXT_COMMAND_BAN_TEAM:    "ban team",
+	TEXT_COMMAND_BAN_LEAGUE:  "ban league",
+	TEXT_COMMAND_CREATE_CODE: "create access code",
+	TEXT_COMMAND_ROLE:        "set role of user",
+}
@@ -0,0 +1,122 @@
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	LANGUAGE_RU = "ru"
+	LANGUAGE_EN = "en"
+)
+
+type Catalogue map[string]string
+
+// Locale describes how numbers and dates are formatted in the language.
+type Locale struct {
+	DecimalSeparator string
+	DateFormat       string
+	TimeFormat       string
+}
+
+var (
+	// DefaultLanguage is used for chats which have not chosen language.
+	DefaultLanguage = LANGUAGE_RU
+
+	catalogues = map[string]Catalogue{
+		LANGUAGE_RU: ru,
+		LANGUAGE_EN: en,
+	}
+
+	locales = map[string]Locale{
+		LANGUAGE_RU: {
+			DecimalSeparator: ",",
+			DateFormat:       "02.01.2006",
+			TimeFormat:       "02.01.2006 15:04 MST",
+		},
+		LANGUAGE_EN: {
+			DecimalSeparator: ".",
+			DateFormat:       "Jan 2, 2006",
+			TimeFormat:       "Jan 2, 2006 15:04 MST",
+		},
+	}
+)
+
+func GetLanguages() []string {
+	var languages []string
+	for language := range catalogues {
+		languages = append(languages, language)
+	}
+
+	sort.Strings(languages)
+	return languages
+}
+
+func IsSupported(language string) bool {
+	_, ok := catalogues[language]
+	return ok
+}
+
+// GetLanguage returns supported language for telegram language_code like
+// "en-US" or empty string when language is not supported.
+func GetLanguage(languageCode string) string {
+	language := strings.ToLower(strings.SplitN(languageCode, "-", 2)[0])
+	if !IsSupported(language) {
+		return ""
+	}
+
+	return language
+}
+
+// Translate formats message of the language, messages missing in the
+// language are taken from default language.
+func Translate(language string, key string, args ...interface{}) string {
+	format, ok := catalogues[language][key]
+	if !ok {
+		format, ok = catalogues[DefaultLanguage][key]
+	}
+
+	if !ok {
+		format = key
+	}
+
+	if len(args) == 0 {
+		return format
+	}
+
+	return fmt.Sprintf(format, args...)
+}
+
+func FormatNumber(language string, value float64, precision int) string {
+	text := strconv.FormatFloat(value, 'f', precision, 64)
+	return strings.Replace(text, ".", getLocale(language).DecimalSeparator, 1)
+}
+
+func FormatSignedNumber(language string, value float64, precision int) string {
+	text := FormatNumber(language, value, precision)
+	if value >= 0 {
+		return "+" + text
+	}
+
+	return text
+}
+
+func FormatDate(language string, t time.Time) string {
+	return t.Format(getLocale(language).DateFormat)
+}
+
+func FormatTime(language string, t time.Time) string {
+	return t.Format(getLocale(language).TimeFormat)
+}
+
+func getLocale(language string) Locale {
+	locale, ok := locales[language]
+	if !ok {
+		return locales[DefaultLanguage]
+	}
+
+	return locale
+}
@@ -0,0 +1,60 @@
+package i18n
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestI18n_Catalogues_HaveSameKeys(
+	t *testing.T,
+) {
+	for language, catalogue := range catalogues {
+		for key := range catalogues[DefaultLanguage] {
+			_, ok := catalogue[key]
+			assert.True(t, ok, "%s: missing key %s", language, key)
+		}
+
+		for key := range catalogue {
+			_, ok := catalogues[DefaultLanguage][key]
+			assert.True(t, ok, "%s: unknown key %s", language, key)
+		}
+	}
+}
+
+func TestI18n_Translate_FallbackToDefaultLanguageAndKey(
+	t *testing.T,
+) {
+	assert.Equal(t, "Ставка отмечена", Translate(LANGUAGE_RU, TEXT_ABOUT_BET_PLACED))
+	assert.Equal(t, "Bet saved", Translate(LANGUAGE_EN, TEXT_ABOUT_BET_PLACED))
+	assert.Equal(t, "Ставка отмечена", Translate("de", TEXT_ABOUT_BET_PLACED))
+	assert.Equal(t, "unknown_key", Translate(LANGUAGE_EN, "unknown_key"))
+}
+
+func TestI18n_GetLanguage_NormalizeLanguageCode(
+	t *testing.T,
+) {
+	assert.Equal(t, LANGUAGE_EN, GetLanguage("en-US"))
+	assert.Equal(t, LANGUAGE_RU, GetLanguage("RU"))
+	assert.Equal(t, "", GetLanguage("de"))
+	assert.Equal(t, "", GetLanguage(""))
+}
+
+func TestI18n_FormatNumber_UseDecimalSeparatorOfLanguage(
+	t *testing.T,
+) {
+	assert.Equal(t, "1,85", FormatNumber(LANGUAGE_RU, 1.85, 2))
+	assert.Equal(t, "1.85", FormatNumber(LANGUAGE_EN, 1.85, 2))
+	assert.Equal(t, "+85,00", FormatSignedNumber(LANGUAGE_RU, 85, 2))
+	assert.Equal(t, "-1.00", FormatSignedNumber(LANGUAGE_EN, -1, 2))
+}
+
+func TestI18n_FormatTime_UseLayoutOfLanguage(
+	t *testing.T,
+) {
+	moment := time.Date(2021, 9, 5, 18, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	assert.Equal(t, "05.09.2021 18:00 MSK", FormatTime(LANGUAGE_RU, moment))
+	assert.Equal(t, "Sep 5, 2021 18:00 MSK", FormatTime(LANGUAGE_EN, moment))
+	assert.Equal(t, "Sep 5, 2021", FormatDate(LANGUAGE_EN, moment))
+}
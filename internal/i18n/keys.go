@@ -0,0 +1,132 @@
+package i18n
+
+const (
+	TEXT_ABOUT_START                   = "about_start"
+	TEXT_ABOUT_STOP                    = "about_stop"
+	TEXT_ABOUT_TIMEZONE_USAGE          = "about_timezone_usage"
+	TEXT_ABOUT_TIMEZONE_CHANGED        = "about_timezone_changed"
+	TEXT_ABOUT_LANGUAGE_USAGE          = "about_language_usage"
+	TEXT_ABOUT_LANGUAGE_CHANGED        = "about_language_changed"
+	TEXT_ABOUT_EVENTS_FOR_DAY          = "about_events_for_day"
+	TEXT_ABOUT_NO_EVENTS_FOR_DAY       = "about_no_events_for_day"
+	TEXT_ABOUT_EVENT_FOR_DAY           = "about_event_for_day"
+	TEXT_ABOUT_DIGEST                  = "about_digest"
+	TEXT_ABOUT_DIGEST_UPDATE           = "about_digest_update"
+	TEXT_ABOUT_DIGEST_LEAGUE           = "about_digest_league"
+	TEXT_ABOUT_DIGEST_EVENT            = "about_digest_event"
+	TEXT_ABOUT_ODDS_DRIFT              = "about_odds_drift"
+	TEXT_ABOUT_SIGNAL_CANCEL           = "about_signal_cancel"
+	TEXT_ABOUT_SIGNAL_UPDATE           = "about_signal_update"
+	TEXT_ABOUT_SIGNAL_FALLBACK         = "about_signal_fallback"
+	TEXT_ABOUT_SIGNAL_SKIPPED          = "about_signal_skipped"
+	TEXT_ABOUT_EVENT_NOT_FOUND         = "about_event_not_found"
+	TEXT_ABOUT_BUTTONS_IN_PRIVATE_CHAT = "about_buttons_in_private_chat"
+
+	TEXT_FAVORITE_HOME = "favorite_home"
+	TEXT_FAVORITE_AWAY = "favorite_away"
+
+	TEXT_ODDS_DRIFT_KIND_DRIFT = "odds_drift_kind_drift"
+	TEXT_ODDS_DRIFT_KIND_FLIP  = "odds_drift_kind_flip"
+
+	TEXT_REASON_MARKET_SUSPENDED = "reason_market_suspended"
+	TEXT_REASON_ODD_BELOW_CUTOFF = "reason_odd_below_cutoff"
+	TEXT_REASON_MATCH_ABANDONED  = "reason_match_abandoned"
+
+	TEXT_BUTTON_PLACED  = "button_placed"
+	TEXT_BUTTON_SKIP    = "button_skip"
+	TEXT_BUTTON_DETAILS = "button_details"
+
+	TEXT_ABOUT_BET_PLACED         = "about_bet_placed"
+	TEXT_ABOUT_BET_ALREADY_PLACED = "about_bet_already_placed"
+	TEXT_ABOUT_BET_DIALOG         = "about_bet_dialog"
+	TEXT_ABOUT_BET_DIALOG_USAGE   = "about_bet_dialog_usage"
+	TEXT_ABOUT_BET_UPDATED        = "about_bet_updated"
+	TEXT_ABOUT_BET_SETTLED        = "about_bet_settled"
+	TEXT_ABOUT_NO_BETS            = "about_no_bets"
+	TEXT_ABOUT_MY_BETS            = "about_my_bets"
+	TEXT_ABOUT_MY_BET             = "about_my_bet"
+	TEXT_BET_STATUS_OPEN          = "bet_status_open"
+	TEXT_BET_STATUS_WON           = "bet_status_won"
+	TEXT_BET_STATUS_LOST          = "bet_status_lost"
+
+	TEXT_ABOUT_SETTINGS              = "about_settings"
+	TEXT_ABOUT_SETTINGS_USAGE        = "about_settings_usage"
+	TEXT_ABOUT_SETTING_INVALID       = "about_setting_invalid"
+	TEXT_ABOUT_SETTING_DIALOG        = "about_setting_dialog"
+	TEXT_SETTING_VALUE_REQUIRED      = "setting_value_required"
+	TEXT_SETTING_UNKNOWN             = "setting_unknown"
+	TEXT_SETTING_INVALID_MIN_ODD     = "setting_invalid_min_odd"
+	TEXT_SETTING_INVALID_MAX_SIGNALS = "setting_invalid_max_signals"
+	TEXT_SETTING_INVALID_QUIET_HOURS = "setting_invalid_quiet_hours"
+	TEXT_SETTING_UNKNOWN_REPORT      = "setting_unknown_report"
+
+	TEXT_ABOUT_ACCESS_CODE_REQUIRED  = "about_access_code_required"
+	TEXT_ABOUT_ACCESS_CODE_NOT_FOUND = "about_access_code_not_found"
+	TEXT_ABOUT_ACCESS_CODE_EXPIRED   = "about_access_code_expired"
+	TEXT_ABOUT_ACCESS_CODE_USED_UP   = "about_access_code_used_up"
+	TEXT_ABOUT_ACCESS_GRANTED        = "about_access_granted"
+	TEXT_ABOUT_CREATE_CODE_USAGE     = "about_create_code_usage"
+	TEXT_ABOUT_ACCESS_CODE_CREATED   = "about_access_code_created"
+	TEXT_ACCESS_CODE_UNLIMITED       = "access_code_unlimited"
+	TEXT_ABOUT_ACCESS_EXPIRES        = "about_access_expires"
+	TEXT_ABOUT_ACCESS_EXPIRED        = "about_access_expired"
+
+	TEXT_ABOUT_ACCESS_DENIED        = "about_access_denied"
+	TEXT_ABOUT_ADMIN_COMMAND_USAGE  = "about_admin_command_usage"
+	TEXT_ABOUT_ADMIN_COMMAND_ERROR  = "about_admin_command_error"
+	TEXT_ABOUT_ERROR                = "about_error"
+	TEXT_ABOUT_EVENT_MONITORED      = "about_event_monitored"
+	TEXT_ABOUT_EVENT_MONITOR_LATER  = "about_event_monitor_later"
+	TEXT_ABOUT_EVENT_SKIPPED        = "about_event_skipped"
+	TEXT_ABOUT_EVENT_SKIPPED_FUTURE = "about_event_skipped_future"
+	TEXT_ABOUT_TEAM_BANNED          = "about_team_banned"
+	TEXT_ABOUT_LEAGUE_BANNED        = "about_league_banned"
+	TEXT_ABOUT_ROLE_USAGE           = "about_role_usage"
+	TEXT_ABOUT_ROLE_SET             = "about_role_set"
+	TEXT_ABOUT_ROLE_NOT_ALLOWED     = "about_role_not_allowed"
+
+	TEXT_STATISTICS_ON_PREVIOUS_DAY  = "statistics_on_previous_day"
+	TEXT_STATISTICS_ON_PREVIOUS_WEEK = "statistics_on_previous_week"
+
+	TEXT_ABOUT_HELP          = "about_help"
+	TEXT_ABOUT_HELP_ADMIN    = "about_help_admin"
+	TEXT_ABOUT_HELP_COMMAND  = "about_help_command"
+	TEXT_ABOUT_LIVE_EVENTS   = "about_live_events"
+	TEXT_ABOUT_LIVE_EVENT    = "about_live_event"
+	TEXT_ABOUT_NO_LIVE       = "about_no_live"
+	TEXT_ABOUT_STATS         = "about_stats"
+	TEXT_ABOUT_STATS_USAGE   = "about_stats_usage"
+	TEXT_ABOUT_HISTORY       = "about_history"
+	TEXT_ABOUT_HISTORY_USAGE = "about_history_usage"
+	TEXT_ABOUT_NO_HISTORY    = "about_no_history"
+	TEXT_ABOUT_SIGNAL_RESULT = "about_signal_result"
+	TEXT_ABOUT_CHARTS_USAGE  = "about_charts_usage"
+	TEXT_ABOUT_NO_RESULTS    = "about_no_results"
+	TEXT_CHART_PROFIT        = "chart_profit"
+	TEXT_CHART_DAILY         = "chart_daily"
+	TEXT_CHART_LEAGUES       = "chart_leagues"
+	TEXT_CHART_ODDS          = "chart_odds"
+	TEXT_SIGNAL_RESULT_WON   = "signal_result_won"
+	TEXT_SIGNAL_RESULT_LOST  = "signal_result_lost"
+	TEXT_SIGNAL_RESULT_OPEN  = "signal_result_open"
+
+	TEXT_COMMAND_START       = "command_start"
+	TEXT_COMMAND_STOP        = "command_stop"
+	TEXT_COMMAND_TODAY       = "command_today"
+	TEXT_COMMAND_TOMORROW    = "command_tomorrow"
+	TEXT_COMMAND_LIVE        = "command_live"
+	TEXT_COMMAND_STATS       = "command_stats"
+	TEXT_COMMAND_HISTORY     = "command_history"
+	TEXT_COMMAND_CHARTS      = "command_charts"
+	TEXT_COMMAND_MY_BETS     = "command_my_bets"
+	TEXT_COMMAND_SETTINGS    = "command_settings"
+	TEXT_COMMAND_TIMEZONE    = "command_timezone"
+	TEXT_COMMAND_LANGUAGE    = "command_language"
+	TEXT_COMMAND_HELP        = "command_help"
+	TEXT_COMMAND_MONITOR     = "command_monitor"
+	TEXT_COMMAND_SKIP        = "command_skip"
+	TEXT_COMMAND_BAN_TEAM    = "command_ban_team"
+	TEXT_COMMAND_BAN_LEAGUE  = "command_ban_league"
+	TEXT_COMMAND_CREATE_CODE = "command_create_code"
+	TEXT_COMMAND_ROLE        = "command_role"
+)
@@ -0,0 +1,188 @@
+package i18n
+
+var ru = Catalogue{
+	TEXT_ABOUT_START: "Привет! Я телеграм-бот и присылаю сигналы по волейбольным матчам на сегодня." +
+		" Отправьте /stop, чтобы отписаться",
+	TEXT_ABOUT_STOP:              "Вы отписались, отправьте /start, чтобы подписаться снова",
+	TEXT_ABOUT_TIMEZONE_USAGE:    "Использование: /timezone Europe/Moscow",
+	TEXT_ABOUT_TIMEZONE_CHANGED:  "Часовой пояс для сообщений изменён на %s",
+	TEXT_ABOUT_LANGUAGE_USAGE:    "Использование: /language <%s>",
+	TEXT_ABOUT_LANGUAGE_CHANGED:  "Язык сообщений изменён на русский",
+	TEXT_ABOUT_EVENTS_FOR_DAY:    "Отобранные матчи на %s:\n",
+	TEXT_ABOUT_NO_EVENTS_FOR_DAY: "Нет отобранных матчей на %s\n",
+	TEXT_ABOUT_EVENT_FOR_DAY:     "  %s %s - %s (%s), фаворит: %s, коэффициенты: %s / %s\n",
+	TEXT_ABOUT_DIGEST:            "☀️ Кандидаты на %s:\n",
+	TEXT_ABOUT_DIGEST_UPDATE:     "🆕 Новые кандидаты на %s:\n",
+	TEXT_ABOUT_DIGEST_LEAGUE:     "\n🏆 %s\n",
+	TEXT_ABOUT_DIGEST_EVENT:      "  %s %s - %s, фаворит: %s, коэффициент: %s\n",
+	TEXT_ABOUT_ODDS_DRIFT: "Изменение коэффициентов до матча: %s\n" +
+		"  event_id: %s\n" +
+		"  лига: %s\n" +
+		"  хозяева: %s\n" +
+		"  гости: %s\n" +
+		"  открытие: %s / %s\n" +
+		"  сейчас: %s / %s\n" +
+		"  начало: %s\n" +
+		"  фаворит на открытии: %s\n" +
+		"  фаворит сейчас: %s\n",
+	TEXT_ABOUT_SIGNAL_CANCEL: "ОТМЕНА! Ставка больше не актуальна\n" +
+		"  event_id: %s\n" +
+		"  причина: %s\n" +
+		"  коэффициент хозяев: %s\n" +
+		"  коэффициент гостей: %s\n" +
+		"  счёт: %s\n",
+	TEXT_ABOUT_SIGNAL_UPDATE: "ОБНОВЛЕНИЕ! Коэффициент фаворита изменился\n" +
+		"  event_id: %s\n" +
+		"  коэффициент фаворита: %s -> %s\n" +
+		"  коэффициент хозяев: %s\n" +
+		"  коэффициент гостей: %s\n" +
+		"  счёт: %s\n",
+	TEXT_ABOUT_SIGNAL_FALLBACK:         "Сигнал: ставка на %s\n  event_id: %s\n",
+	TEXT_ABOUT_SIGNAL_SKIPPED:          "Сигнал пропущен",
+	TEXT_ABOUT_EVENT_NOT_FOUND:         "Матч не найден",
+	TEXT_ABOUT_BUTTONS_IN_PRIVATE_CHAT: "Кнопки работают в личном чате с ботом",
+
+	TEXT_FAVORITE_HOME: "хозяева",
+	TEXT_FAVORITE_AWAY: "гости",
+
+	TEXT_ODDS_DRIFT_KIND_DRIFT: "сдвиг",
+	TEXT_ODDS_DRIFT_KIND_FLIP:  "смена фаворита",
+
+	TEXT_REASON_MARKET_SUSPENDED: "рынок закрыт",
+	TEXT_REASON_ODD_BELOW_CUTOFF: "коэффициент фаворита упал ниже порога",
+	TEXT_REASON_MATCH_ABANDONED:  "матч прерван",
+
+	TEXT_BUTTON_PLACED:  "✅ Поставил",
+	TEXT_BUTTON_SKIP:    "🚫 Пропускаю",
+	TEXT_BUTTON_DETAILS: "ℹ️ Подробнее",
+
+	TEXT_ABOUT_BET_PLACED:         "Ставка отмечена",
+	TEXT_ABOUT_BET_ALREADY_PLACED: "Ставка уже отмечена",
+	TEXT_ABOUT_BET_DIALOG: "Ставка на %s записана: сумма %s, коэффициент %s\n" +
+		"Чтобы изменить, отправьте сумму и коэффициент, например: 100 1,85",
+	TEXT_ABOUT_BET_DIALOG_USAGE: "Отправьте сумму и коэффициент, например: 100 1,85",
+	TEXT_ABOUT_BET_UPDATED:      "Ставка обновлена: сумма %s, коэффициент %s",
+	TEXT_ABOUT_BET_SETTLED: "Ставка рассчитана: %s - %s\n" +
+		"  event_id: %s\n" +
+		"  прибыль: %s\n",
+	TEXT_ABOUT_NO_BETS: "У вас нет отмеченных ставок, нажмите «%s» под сигналом",
+	TEXT_ABOUT_MY_BETS: "Ваши ставки:\n" +
+		"  всего: %d\n" +
+		"  выиграно: %d\n" +
+		"  проиграно: %d\n" +
+		"  открыто: %d\n" +
+		"  поставлено: %s\n" +
+		"  прибыль: %s\n" +
+		"  roi: %s%%\n\n" +
+		"Последние ставки:\n",
+	TEXT_ABOUT_MY_BET:    "  %s %s: %s x %s, %s %s\n",
+	TEXT_BET_STATUS_OPEN: "открыта",
+	TEXT_BET_STATUS_WON:  "выиграна",
+	TEXT_BET_STATUS_LOST: "проиграна",
+
+	TEXT_ABOUT_SETTINGS: "Ваши настройки:\n" +
+		"  leagues: %s\n" +
+		"  countries: %s\n" +
+		"  min_odd: %s\n" +
+		"  max_signals: %s\n" +
+		"  quiet: %s\n" +
+		"  reports: %s\n",
+	TEXT_ABOUT_SETTINGS_USAGE: "Изменить настройку кнопкой ниже или: /settings <название> <значение>\n" +
+		"  /settings leagues 22614,22615 | all\n" +
+		"  /settings countries it,pl | all\n" +
+		"  /settings min_odd 1,5 | off\n" +
+		"  /settings max_signals 5 | off\n" +
+		"  /settings quiet 23-8 | off\n" +
+		"  /settings reports %s | off\n",
+	TEXT_ABOUT_SETTING_INVALID:       "Настройка не изменена: %s\n\n",
+	TEXT_ABOUT_SETTING_DIALOG:        "Отправьте новое значение %s",
+	TEXT_SETTING_VALUE_REQUIRED:      "не указано значение",
+	TEXT_SETTING_UNKNOWN:             "неизвестная настройка: %s",
+	TEXT_SETTING_INVALID_MIN_ODD:     "min_odd должен быть положительным числом",
+	TEXT_SETTING_INVALID_MAX_SIGNALS: "max_signals должен быть положительным целым числом",
+	TEXT_SETTING_INVALID_QUIET_HOURS: "тихие часы задаются как <с>-<до> от 0 до 23, например 23-8",
+	TEXT_SETTING_UNKNOWN_REPORT:      "неизвестный тип отчёта: %s",
+
+	TEXT_ABOUT_ACCESS_CODE_REQUIRED:  "Нужен код доступа, отправьте /start <код> или откройте ссылку-приглашение",
+	TEXT_ABOUT_ACCESS_CODE_NOT_FOUND: "Код доступа не принят: код не найден",
+	TEXT_ABOUT_ACCESS_CODE_EXPIRED:   "Код доступа не принят: срок действия кода истёк",
+	TEXT_ABOUT_ACCESS_CODE_USED_UP:   "Код доступа не принят: код уже использован",
+	TEXT_ABOUT_ACCESS_GRANTED:        "Доступ открыт до %s. Отправьте /stop, чтобы отписаться",
+	TEXT_ABOUT_CREATE_CODE_USAGE:     "Использование: /create_code <дней> [использований], доступ действует дни с активации, 0 использований - без ограничений, по умолчанию 1",
+	TEXT_ABOUT_ACCESS_CODE_CREATED: "Код доступа: %s\n" +
+		"  дней доступа: %d\n" +
+		"  использований: %s\n" +
+		"  активировать до: %s\n" +
+		"  ссылка: %s\n",
+	TEXT_ACCESS_CODE_UNLIMITED: "без ограничений",
+	TEXT_ABOUT_ACCESS_EXPIRES:  "Ваш доступ истекает %s, попросите у администратора новый код",
+	TEXT_ABOUT_ACCESS_EXPIRED:  "Ваш доступ истёк, отправьте /start <код> с новым кодом доступа",
+
+	TEXT_ABOUT_ACCESS_DENIED:        "Недостаточно прав, нужна роль: %s",
+	TEXT_ABOUT_ADMIN_COMMAND_USAGE:  "Использование: %s <%s>",
+	TEXT_ABOUT_ADMIN_COMMAND_ERROR:  "ошибка: %s",
+	TEXT_ABOUT_ERROR:                "⚠️ Ошибка: %s",
+	TEXT_ABOUT_EVENT_MONITORED:      "Матч %s добавлен в live-мониторинг: %s - %s (%s), фаворит: %s",
+	TEXT_ABOUT_EVENT_MONITOR_LATER:  "Матч %s не найден среди ближайших, он будет отслеживаться, когда появится",
+	TEXT_ABOUT_EVENT_SKIPPED:        "Матч %s пропущен, live-мониторинг остановлен",
+	TEXT_ABOUT_EVENT_SKIPPED_FUTURE: "Матч %s пропущен, live-мониторинг для него ещё не запущен",
+	TEXT_ABOUT_TEAM_BANNED:          "Команда %q заблокирована, её матчи больше не отбираются",
+	TEXT_ABOUT_LEAGUE_BANNED:        "Лига %s заблокирована, её матчи больше не отбираются",
+	TEXT_ABOUT_ROLE_USAGE:           "Использование: /role <user_id> <роль>, роли: %s",
+	TEXT_ABOUT_ROLE_SET:             "Роль пользователя %d теперь %s",
+	TEXT_ABOUT_ROLE_NOT_ALLOWED:     "Только владелец может выдавать и отзывать роль admin, владелец задаётся в конфиге",
+
+	TEXT_STATISTICS_ON_PREVIOUS_DAY: "Результаты за вчера:\n" +
+		"  win: %d\n" +
+		"  lose: %d\n" +
+		"  average odd: %s\n",
+	TEXT_STATISTICS_ON_PREVIOUS_WEEK: "Результаты за прошлую неделю:\n" +
+		"  win: %d\n" +
+		"  lose: %d\n" +
+		"  average odd: %s\n",
+
+	TEXT_ABOUT_HELP:         "Команды:\n",
+	TEXT_ABOUT_HELP_ADMIN:   "\nКоманды администратора:\n",
+	TEXT_ABOUT_HELP_COMMAND: "  %s - %s\n",
+	TEXT_ABOUT_LIVE_EVENTS:  "Матчи в live-мониторинге:\n",
+	TEXT_ABOUT_LIVE_EVENT:   "  %s - %s (%s), фаворит: %s, счёт: %s, сет %d, коэффициенты: %s / %s\n",
+	TEXT_ABOUT_NO_LIVE:      "Сейчас нет матчей в live-мониторинге",
+	TEXT_ABOUT_STATS: "Статистика с %s по %s:\n" +
+		"  win: %d\n" +
+		"  lose: %d\n" +
+		"  average odd: %s\n",
+	TEXT_ABOUT_STATS_USAGE:   "Использование: /stats [day|week|month] или /stats <с> <по>, даты в формате 2021-09-05",
+	TEXT_ABOUT_HISTORY:       "Последние сигналы:\n",
+	TEXT_ABOUT_HISTORY_USAGE: "Использование: /history [количество от 1 до %d]",
+	TEXT_ABOUT_NO_HISTORY:    "Сигналов ещё не было",
+	TEXT_ABOUT_SIGNAL_RESULT: "  %s %s - %s, ставка на %s по %s: %s\n",
+	TEXT_SIGNAL_RESULT_WON:   "✅ зашла (%s)",
+	TEXT_SIGNAL_RESULT_LOST:  "❌ не зашла (%s)",
+	TEXT_SIGNAL_RESULT_OPEN:  "⏳ в игре",
+	TEXT_ABOUT_CHARTS_USAGE:  "Использование: /charts [day|week|month] или /charts <с> <по>, даты в формате 2021-09-05, не больше %d дней",
+	TEXT_ABOUT_NO_RESULTS:    "Нет рассчитанных сигналов с %s по %s",
+	TEXT_CHART_PROFIT:        "📈 Прибыль при ставке в 1 единицу с %s по %s",
+	TEXT_CHART_DAILY:         "📊 Выигрыши 🟩 и проигрыши 🟥 по дням с %s по %s",
+	TEXT_CHART_LEAGUES:       "🏐 Процент выигрышей по лигам с %s по %s, в скобках количество сигналов",
+	TEXT_CHART_ODDS:          "🎯 Выигрыши 🟩 и проигрыши 🟥 по коэффициенту фаворита с %s по %s",
+
+	TEXT_COMMAND_START:       "подписаться на сигналы",
+	TEXT_COMMAND_STOP:        "отписаться",
+	TEXT_COMMAND_TODAY:       "отобранные матчи на сегодня",
+	TEXT_COMMAND_TOMORROW:    "отобранные матчи на завтра",
+	TEXT_COMMAND_LIVE:        "матчи в live-мониторинге и их счёт",
+	TEXT_COMMAND_STATS:       "статистика за day, week, month или с <дата> по <дата>",
+	TEXT_COMMAND_HISTORY:     "последние N сигналов и их результаты",
+	TEXT_COMMAND_CHARTS:      "графики результатов за day, week, month или с <дата> по <дата>",
+	TEXT_COMMAND_MY_BETS:     "ваши ставки",
+	TEXT_COMMAND_SETTINGS:    "фильтры сигналов и отчётов",
+	TEXT_COMMAND_TIMEZONE:    "часовой пояс для сообщений",
+	TEXT_COMMAND_LANGUAGE:    "язык сообщений",
+	TEXT_COMMAND_HELP:        "список команд",
+	TEXT_COMMAND_MONITOR:     "добавить матч в live-мониторинг",
+	TEXT_COMMAND_SKIP:        "пропустить матч",
+	TEXT_COMMAND_BAN_TEAM:    "заблокировать команду",
+	TEXT_COMMAND_BAN_LEAGUE:  "заблокировать лигу",
+	TEXT_COMMAND_CREATE_CODE: "создать код доступа",
+	TEXT_COMMAND_ROLE:        "назначить роль пользователю",
+}
@@ -0,0 +1,87 @@
+package leader
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+	"github.com/reconquest/karma-go"
+	"github.com/reconquest/pkg/log"
+)
+
+type Elector struct {
+	database      *database.Database
+	lockID        int64
+	renewInterval time.Duration
+	lock          *database.LeaderLock
+	isLeader      int32
+}
+
+func NewElector(
+	database *database.Database,
+	lockID int64,
+	renewInterval time.Duration,
+) *Elector {
+	return &Elector{
+		database:      database,
+		lockID:        lockID,
+		renewInterval: renewInterval,
+	}
+}
+
+func (elector *Elector) IsLeader() bool {
+	return atomic.LoadInt32(&elector.isLeader) == 1
+}
+
+// Elect tries to take leadership if instance is follower or renews lease if
+// instance is leader already.
+func (elector *Elector) Elect() {
+	if elector.lock != nil {
+		err := elector.lock.Renew()
+		if err == nil {
+			return
+		}
+
+		log.Errorf(err, "leadership lost")
+		elector.release()
+		return
+	}
+
+	lock, err := elector.database.TryLeaderLock(elector.lockID)
+	if err != nil {
+		log.Errorf(err, "unable to try leader lock")
+		return
+	}
+
+	if lock == nil {
+		log.Debugf(nil, "leader lock is held by another instance")
+		return
+	}
+
+	elector.lock = lock
+	atomic.StoreInt32(&elector.isLeader, 1)
+	log.Infof(karma.Describe("lock_id", elector.lockID), "instance became leader")
+}
+
+func (elector *Elector) Run(ctx context.Context) {
+	for tools.Sleep(ctx, elector.renewInterval) {
+		elector.Elect()
+	}
+
+	if elector.lock != nil {
+		elector.release()
+		log.Info("leadership released")
+	}
+}
+
+func (elector *Elector) release() {
+	atomic.StoreInt32(&elector.isLeader, 0)
+	err := elector.lock.Release()
+	if err != nil {
+		log.Error(err)
+	}
+
+	elector.lock = nil
+}
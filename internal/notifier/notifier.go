@@ -0,0 +1,223 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/config"
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+	"github.com/reconquest/karma-go"
+	"github.com/reconquest/pkg/log"
+)
+
+const (
+	KIND_SIGNAL = "signal"
+	KIND_REPORT = "report"
+	KIND_ERROR  = "error"
+
+	CHANNEL_DISCORD = "discord"
+	CHANNEL_SLACK   = "slack"
+	CHANNEL_WEBHOOK = "webhook"
+
+	HTTP_TIMEOUT = 10 * time.Second
+	QUEUE_SIZE   = 100
+)
+
+var (
+	KINDS = []string{KIND_SIGNAL, KIND_REPORT, KIND_ERROR}
+
+	htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+)
+
+// Notification is a channel-agnostic message, HTML text is converted to plain
+// text by channels which don't support telegram HTML.
+type Notification struct {
+	Kind      string    `json:"kind"`
+	Text      string    `json:"text"`
+	HTML      bool      `json:"-"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type Notifier interface {
+	Notify(Notification) error
+}
+
+// Router sends notifications to channels configured for their kind in
+// background, so slow services don't delay signals, nil router doesn't send
+// anything.
+type Router struct {
+	routes map[string][]namedNotifier
+	queue  chan Notification
+}
+
+type namedNotifier struct {
+	name     string
+	notifier Notifier
+}
+
+// NewRouter creates notifiers for external services, telegram chats receive
+// signals, reports and errors by broadcast routes instead.
+func NewRouter(config config.Notifications) (*Router, error) {
+	notifiers := map[string]Notifier{}
+	for _, channel := range config.Channels {
+		if channel.Name == "" {
+			return nil, errors.New("name of notification channel is required")
+		}
+
+		if _, ok := notifiers[channel.Name]; ok {
+			return nil, fmt.Errorf("duplicate notification channel: %s", channel.Name)
+		}
+
+		notifier, err := newNotifier(channel)
+		if err != nil {
+			return nil, karma.Format(
+				err,
+				"unable to create notification channel: %s",
+				channel.Name,
+			)
+		}
+
+		notifiers[channel.Name] = notifier
+	}
+
+	router := &Router{
+		routes: map[string][]namedNotifier{},
+		queue:  make(chan Notification, QUEUE_SIZE),
+	}
+	for kind, names := range config.Routes {
+		if !isKnownKind(kind) {
+			return nil, fmt.Errorf(
+				"unknown notification kind: %s, known: %s",
+				kind,
+				strings.Join(KINDS, ", "),
+			)
+		}
+
+		for _, name := range names {
+			notifier, ok := notifiers[name]
+			if !ok {
+				return nil, fmt.Errorf(
+					"unknown notification channel %s in route %s",
+					name,
+					kind,
+				)
+			}
+
+			router.routes[kind] = append(
+				router.routes[kind],
+				namedNotifier{name: name, notifier: notifier},
+			)
+		}
+	}
+
+	return router, nil
+}
+
+// Notify queues notification for sending by Run, notification is dropped when
+// the queue is full.
+func (router *Router) Notify(notification Notification) {
+	if router == nil || len(router.routes[notification.Kind]) == 0 {
+		return
+	}
+
+	if notification.CreatedAt.IsZero() {
+		notification.CreatedAt = tools.TimeNow()
+	}
+
+	select {
+	case router.queue <- notification:
+	default:
+		log.Warningf(
+			fmt.Errorf("queue of %d notifications is full", QUEUE_SIZE),
+			"unable to queue %s notification",
+			notification.Kind,
+		)
+	}
+}
+
+// Run sends queued notifications until context is canceled, notifications
+// queued before that are still sent.
+func (router *Router) Run(ctx context.Context) {
+	if router == nil {
+		return
+	}
+
+	for {
+		select {
+		case notification := <-router.queue:
+			router.send(notification)
+
+		case <-ctx.Done():
+			for {
+				select {
+				case notification := <-router.queue:
+					router.send(notification)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// send sends notification to every channel routed for its kind, failed
+// channels are logged and don't prevent sending to others.
+func (router *Router) send(notification Notification) {
+	for _, route := range router.routes[notification.Kind] {
+		err := route.notifier.Notify(notification)
+		if err != nil {
+			log.Errorf(
+				karma.Describe("channel", route.name).Reason(err),
+				"unable to send %s notification",
+				notification.Kind,
+			)
+		}
+	}
+}
+
+func (router *Router) NotifyError(err error) {
+	router.Notify(Notification{Kind: KIND_ERROR, Text: err.Error()})
+}
+
+func newNotifier(channel config.NotificationChannel) (Notifier, error) {
+	if channel.URL == "" {
+		return nil, fmt.Errorf("url is required for %s channel", channel.Type)
+	}
+
+	switch channel.Type {
+	case CHANNEL_DISCORD:
+		return NewDiscord(channel.URL), nil
+
+	case CHANNEL_SLACK:
+		return NewSlack(channel.URL), nil
+
+	case CHANNEL_WEBHOOK:
+		return NewWebhook(channel.URL, channel.Headers), nil
+	}
+
+	return nil, fmt.Errorf("unknown type of notification channel: %s", channel.Type)
+}
+
+func isKnownKind(kind string) bool {
+	for _, known := range KINDS {
+		if kind == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getPlainText returns text without telegram HTML markup.
+func getPlainText(notification Notification) string {
+	if !notification.HTML {
+		return notification.Text
+	}
+
+	return html.UnescapeString(htmlTagPattern.ReplaceAllString(notification.Text, ""))
+}
@@ -0,0 +1,183 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert"
+	"github.com/daniilsolovey/BetBotGo/internal/config"
+)
+
+type request struct {
+	Path    string
+	Header  http.Header
+	Payload map[string]interface{}
+}
+
+// standIn records JSON requests like discord or slack would receive them.
+type standIn struct {
+	mutex    sync.Mutex
+	server   *httptest.Server
+	requests []request
+	status   int
+}
+
+func newStandIn(status int, response string) *standIn {
+	standIn := &standIn{status: status}
+	standIn.server = httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, httpRequest *http.Request) {
+			body, _ := ioutil.ReadAll(httpRequest.Body)
+			payload := map[string]interface{}{}
+			_ = json.Unmarshal(body, &payload)
+
+			standIn.mutex.Lock()
+			standIn.requests = append(standIn.requests, request{
+				Path:    httpRequest.URL.Path,
+				Header:  httpRequest.Header,
+				Payload: payload,
+			})
+			standIn.mutex.Unlock()
+
+			writer.WriteHeader(standIn.status)
+			_, _ = writer.Write([]byte(response))
+		},
+	))
+
+	return standIn
+}
+
+func (standIn *standIn) getRequests() []request {
+	standIn.mutex.Lock()
+	defer standIn.mutex.Unlock()
+
+	return append([]request{}, standIn.requests...)
+}
+
+func TestNotifier_Discord_PostPlainContent(
+	t *testing.T,
+) {
+	server := newStandIn(http.StatusNoContent, "")
+	defer server.server.Close()
+
+	err := NewDiscord(server.server.URL + "/webhook").Notify(Notification{
+		Kind: KIND_SIGNAL,
+		Text: "<b>Signal</b> A &amp; B",
+		HTML: true,
+	})
+	assert.NoError(t, err)
+
+	requests := server.getRequests()
+	assert.Equal(t, 1, len(requests))
+	assert.Equal(t, "/webhook", requests[0].Path)
+	assert.Equal(t, map[string]interface{}{"content": "Signal A & B"}, requests[0].Payload)
+}
+
+func TestNotifier_Slack_PostTextAndFailOnBadStatus(
+	t *testing.T,
+) {
+	server := newStandIn(http.StatusOK, "ok")
+	defer server.server.Close()
+
+	err := NewSlack(server.server.URL).Notify(Notification{Kind: KIND_ERROR, Text: "failure"})
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		map[string]interface{}{"text": "failure"},
+		server.getRequests()[0].Payload,
+	)
+
+	server.status = http.StatusNotFound
+	err = NewSlack(server.server.URL).Notify(Notification{Kind: KIND_ERROR, Text: "failure"})
+	assert.Error(t, err)
+}
+
+func TestNotifier_Webhook_PostNotificationWithHeaders(
+	t *testing.T,
+) {
+	server := newStandIn(http.StatusOK, "")
+	defer server.server.Close()
+
+	createdAt := time.Date(2021, 9, 6, 10, 0, 0, 0, time.UTC)
+	err := NewWebhook(server.server.URL, map[string]string{"Authorization": "Bearer secret"}).
+		Notify(Notification{Kind: KIND_REPORT, Text: "report", CreatedAt: createdAt})
+	assert.NoError(t, err)
+
+	requests := server.getRequests()
+	assert.Equal(t, "Bearer secret", requests[0].Header.Get("Authorization"))
+	assert.Equal(t, "application/json", requests[0].Header.Get("Content-Type"))
+	assert.Equal(t, map[string]interface{}{
+		"kind":      "report",
+		"text":      "report",
+		"createdAt": "2021-09-06T10:00:00Z",
+	}, requests[0].Payload)
+}
+
+func TestNotifier_Router_SendByKind(
+	t *testing.T,
+) {
+	discord := newStandIn(http.StatusNoContent, "")
+	defer discord.server.Close()
+
+	slack := newStandIn(http.StatusOK, "ok")
+	defer slack.server.Close()
+
+	router, err := NewRouter(config.Notifications{
+		Channels: []config.NotificationChannel{
+			{Name: "discord", Type: CHANNEL_DISCORD, URL: discord.server.URL},
+			{Name: "slack", Type: CHANNEL_SLACK, URL: slack.server.URL},
+		},
+		Routes: map[string][]string{
+			KIND_SIGNAL: {"discord", "slack"},
+			KIND_ERROR:  {"slack"},
+		},
+	})
+	assert.NoError(t, err)
+
+	router.Notify(Notification{Kind: KIND_SIGNAL, Text: "signal"})
+	router.Notify(Notification{Kind: KIND_REPORT, Text: "report"})
+	router.Notify(Notification{Kind: KIND_ERROR, Text: "error"})
+
+	assert.Equal(t, 0, len(discord.getRequests()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	router.Run(ctx)
+
+	assert.Equal(t, 1, len(discord.getRequests()))
+	assert.Equal(t, "signal", discord.getRequests()[0].Payload["content"])
+	assert.Equal(t, 2, len(slack.getRequests()))
+	assert.Equal(t, "error", slack.getRequests()[1].Payload["text"])
+
+	var nilRouter *Router
+	nilRouter.Notify(Notification{Kind: KIND_SIGNAL, Text: "signal"})
+}
+
+func TestNotifier_NewRouter_ValidateConfig(
+	t *testing.T,
+) {
+	_, err := NewRouter(config.Notifications{
+		Channels: []config.NotificationChannel{{Name: "sms", Type: "sms", URL: "http://localhost"}},
+	})
+	assert.Error(t, err)
+
+	_, err = NewRouter(config.Notifications{
+		Routes: map[string][]string{KIND_SIGNAL: {"discord"}},
+	})
+	assert.Error(t, err)
+
+	_, err = NewRouter(config.Notifications{
+		Channels: []config.NotificationChannel{{Name: "discord", Type: CHANNEL_DISCORD}},
+	})
+	assert.Error(t, err)
+
+	_, err = NewRouter(config.Notifications{
+		Routes: map[string][]string{"news": {}},
+	})
+	assert.Error(t, err)
+}
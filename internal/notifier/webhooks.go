@@ -0,0 +1,108 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/reconquest/karma-go"
+)
+
+// Discord sends notifications to discord webhook.
+type Discord struct {
+	url string
+}
+
+// Slack sends notifications to slack incoming webhook.
+type Slack struct {
+	url string
+}
+
+// Webhook posts notification as JSON with optional headers, e.g. for
+// authorization.
+type Webhook struct {
+	url     string
+	headers map[string]string
+}
+
+type DiscordPayload struct {
+	Content string `json:"content"`
+}
+
+type SlackPayload struct {
+	Text string `json:"text"`
+}
+
+func NewDiscord(url string) *Discord {
+	return &Discord{url: url}
+}
+
+func NewSlack(url string) *Slack {
+	return &Slack{url: url}
+}
+
+func NewWebhook(url string, headers map[string]string) *Webhook {
+	return &Webhook{url: url, headers: headers}
+}
+
+func (discord *Discord) Notify(notification Notification) error {
+	return postJSON(
+		discord.url,
+		DiscordPayload{Content: getPlainText(notification)},
+		nil,
+	)
+}
+
+func (slack *Slack) Notify(notification Notification) error {
+	return postJSON(
+		slack.url,
+		SlackPayload{Text: getPlainText(notification)},
+		nil,
+	)
+}
+
+func (webhook *Webhook) Notify(notification Notification) error {
+	notification.Text = getPlainText(notification)
+	return postJSON(webhook.url, notification, webhook.headers)
+}
+
+func postJSON(url string, payload interface{}, headers map[string]string) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to encode notification",
+		)
+	}
+
+	request, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to create request for notification",
+		)
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+
+	client := &http.Client{Timeout: HTTP_TIMEOUT}
+	response, err := client.Do(request)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to send notification",
+		)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("unable to send notification, unexpected status: %s", response.Status)
+	}
+
+	return nil
+}
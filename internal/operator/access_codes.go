@@ -0,0 +1,222 @@
+package operator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/daniilsolovey/BetBotGo/internal/delivery"
+	"github.com/daniilsolovey/BetBotGo/internal/i18n"
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+	"github.com/reconquest/karma-go"
+	"github.com/reconquest/pkg/log"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+const (
+	COMMAND_CREATE_CODE = "/create_code"
+	DEEP_LINK_FORMAT    = "https://t.me/%s?start=%s"
+)
+
+func (operator *Operator) CreateCode(message *tb.Message) error {
+	return operator.handleAdminCommand(message, COMMAND_CREATE_CODE, "days", operator.createAccessCode)
+}
+
+// SetBotUsername is used for deep links with access codes.
+func (operator *Operator) SetBotUsername(username string) {
+	operator.botUsername = username
+}
+
+// HandleSubscriptionsExpiry reminds subscribers about upcoming access expiry
+// and deactivates subscribers which access has expired.
+func (operator *Operator) HandleSubscriptionsExpiry() error {
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to get current time for subscriptions expiry",
+		)
+	}
+
+	subscribers, err := operator.database.GetSubscribersToRemind(
+		timeNow.Add(operator.config.Access.ReminderBefore),
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, subscriber := range subscribers {
+		language := operator.delivery.GetLanguage(subscriber.ChatID)
+		text := i18n.Translate(
+			language,
+			i18n.TEXT_ABOUT_ACCESS_EXPIRES,
+			operator.formatTimeForChat(*subscriber.SecretKeyExpiredAt, subscriber.ChatID, language),
+		)
+
+		err := operator.delivery.SendToSubscriber(subscriber.ChatID, text)
+		if err != nil {
+			continue
+		}
+
+		err = operator.database.MarkSubscriberReminded(subscriber.ChatID)
+		if err != nil {
+			log.Error(err)
+		}
+	}
+
+	expiredSubscribers, err := operator.database.DeactivateExpiredSubscribers()
+	if err != nil {
+		return err
+	}
+
+	for _, subscriber := range expiredSubscribers {
+		log.Infof(nil, "access of subscriber expired, chat_id: %d", subscriber.ChatID)
+		err := operator.delivery.SendToSubscriber(
+			subscriber.ChatID,
+			i18n.Translate(operator.delivery.GetLanguage(subscriber.ChatID), i18n.TEXT_ABOUT_ACCESS_EXPIRED),
+		)
+		if err != nil {
+			log.Errorf(err, "unable to notify subscriber about access expiry, chat_id: %d", subscriber.ChatID)
+		}
+	}
+
+	return nil
+}
+
+func (operator *Operator) redeemAccessCode(
+	subscriber database.Subscriber,
+	code string,
+	language string,
+) error {
+	expiresAt, err := operator.database.RedeemAccessCode(code, subscriber)
+	if err != nil {
+		var key string
+		switch err {
+		case database.ErrAccessCodeNotFound:
+			key = i18n.TEXT_ABOUT_ACCESS_CODE_NOT_FOUND
+		case database.ErrAccessCodeExpired:
+			key = i18n.TEXT_ABOUT_ACCESS_CODE_EXPIRED
+		case database.ErrAccessCodeUsedUp:
+			key = i18n.TEXT_ABOUT_ACCESS_CODE_USED_UP
+		default:
+			return err
+		}
+
+		log.Infof(nil, "access code is not accepted, chat_id: %d, reason: %s", subscriber.ChatID, err)
+		return operator.transport.SendMessage(
+			delivery.GetRecipient(subscriber.ChatID),
+			i18n.Translate(language, key),
+		)
+	}
+
+	log.Infof(nil, "access code redeemed, chat_id: %d, expires_at: %s", subscriber.ChatID, expiresAt)
+	return operator.transport.SendMessage(
+		delivery.GetRecipient(subscriber.ChatID),
+		i18n.Translate(
+			language,
+			i18n.TEXT_ABOUT_ACCESS_GRANTED,
+			operator.formatTimeForChat(expiresAt, subscriber.ChatID, language),
+		),
+	)
+}
+
+// hasAccess is used when access codes are required, subscribers which have
+// been added before codes were required keep access without expiry.
+func (operator *Operator) hasAccess(chatID int64) (bool, error) {
+	subscriber, err := operator.database.GetSubscriber(chatID)
+	if err != nil {
+		return false, err
+	}
+
+	if subscriber == nil || !subscriber.IsActive {
+		return false, nil
+	}
+
+	if subscriber.SecretKeyExpiredAt == nil {
+		return true, nil
+	}
+
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return false, err
+	}
+
+	return subscriber.SecretKeyExpiredAt.After(timeNow), nil
+}
+
+func (operator *Operator) createAccessCode(
+	argument string,
+	userID int64,
+	language string,
+) (string, error) {
+	days, maxUses, ok := parseCreateCodeArguments(argument)
+	if !ok {
+		return i18n.Translate(language, i18n.TEXT_ABOUT_CREATE_CODE_USAGE), nil
+	}
+
+	accessCode, err := database.NewAccessCode(
+		days,
+		maxUses,
+		operator.config.Access.CodeValidity,
+		userID,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	err = operator.database.InsertAccessCode(accessCode)
+	if err != nil {
+		return "", err
+	}
+
+	uses := strconv.Itoa(maxUses)
+	if maxUses == 0 {
+		uses = i18n.Translate(language, i18n.TEXT_ACCESS_CODE_UNLIMITED)
+	}
+
+	link := "-"
+	if operator.botUsername != "" {
+		link = fmt.Sprintf(DEEP_LINK_FORMAT, operator.botUsername, accessCode.Code)
+	}
+
+	return i18n.Translate(
+		language,
+		i18n.TEXT_ABOUT_ACCESS_CODE_CREATED,
+		accessCode.Code,
+		days,
+		uses,
+		i18n.FormatTime(language, accessCode.ExpiresAt),
+		link,
+	), nil
+}
+
+func (operator *Operator) formatTimeForChat(t time.Time, chatID int64, language string) string {
+	return i18n.FormatTime(
+		language,
+		t.In(operator.delivery.GetLocation(chatID)),
+	)
+}
+
+func parseCreateCodeArguments(argument string) (int, int, bool) {
+	fields := strings.Fields(argument)
+	if len(fields) == 0 || len(fields) > 2 {
+		return 0, 0, false
+	}
+
+	days, err := strconv.Atoi(fields[0])
+	if err != nil || days <= 0 {
+		return 0, 0, false
+	}
+
+	maxUses := 1
+	if len(fields) == 2 {
+		maxUses, err = strconv.Atoi(fields[1])
+		if err != nil || maxUses < 0 {
+			return 0, 0, false
+		}
+	}
+
+	return days, maxUses, true
+}
@@ -0,0 +1,310 @@
+package operator
+
+import (
+	"strings"
+
+	"github.com/daniilsolovey/BetBotGo/internal/constants"
+	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/daniilsolovey/BetBotGo/internal/i18n"
+	"github.com/daniilsolovey/BetBotGo/internal/requester"
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+	"github.com/reconquest/karma-go"
+	"github.com/reconquest/pkg/log"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+const (
+	COMMAND_MONITOR    = "/monitor"
+	COMMAND_SKIP       = "/skip"
+	COMMAND_BAN_TEAM   = "/ban_team"
+	COMMAND_BAN_LEAGUE = "/ban_league"
+)
+
+func (operator *Operator) Monitor(message *tb.Message) error {
+	return operator.handleAdminCommand(message, COMMAND_MONITOR, "event_id", operator.monitorEvent)
+}
+
+func (operator *Operator) Skip(message *tb.Message) error {
+	return operator.handleAdminCommand(message, COMMAND_SKIP, "event_id", operator.skipEvent)
+}
+
+func (operator *Operator) BanTeam(message *tb.Message) error {
+	return operator.handleAdminCommand(message, COMMAND_BAN_TEAM, "team name", operator.banTeam)
+}
+
+func (operator *Operator) BanLeague(message *tb.Message) error {
+	return operator.handleAdminCommand(message, COMMAND_BAN_LEAGUE, "league_id", operator.banLeague)
+}
+
+func (operator *Operator) handleAdminCommand(
+	message *tb.Message,
+	command string,
+	argumentName string,
+	action func(argument string, userID int64, language string) (string, error),
+) error {
+	language := operator.getLanguage(message)
+	argument := strings.TrimSpace(message.Payload)
+	if argument == "" {
+		return operator.transport.SendMessage(
+			message.Chat,
+			i18n.Translate(language, i18n.TEXT_ABOUT_ADMIN_COMMAND_USAGE, command, argumentName),
+		)
+	}
+
+	userID := int64(message.Sender.ID)
+	result, err := action(argument, userID, language)
+	if err != nil {
+		result = i18n.Translate(language, i18n.TEXT_ABOUT_ADMIN_COMMAND_ERROR, err.Error())
+	}
+
+	log.Infof(
+		karma.
+			Describe("user_id", userID).
+			Describe("username", message.Sender.Username).
+			Describe("command", command).
+			Describe("argument", argument),
+		"admin command handled: %s",
+		result,
+	)
+
+	actionErr := operator.database.InsertAdminAction(database.AdminAction{
+		UserID:   userID,
+		Username: message.Sender.Username,
+		Command:  command,
+		Argument: argument,
+		Result:   result,
+	})
+	if actionErr != nil {
+		log.Error(actionErr)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return operator.transport.SendMessage(message.Chat, result)
+}
+
+func (operator *Operator) monitorEvent(
+	eventID string,
+	userID int64,
+	language string,
+) (string, error) {
+	err := operator.database.DeleteEventRule(constants.RULE_SKIP_EVENT, eventID)
+	if err != nil {
+		return "", err
+	}
+
+	err = operator.saveEventRule(constants.RULE_MONITOR_EVENT, eventID, userID)
+	if err != nil {
+		return "", err
+	}
+
+	event, err := operator.getUpcomingEventByID(eventID)
+	if err != nil {
+		return "", err
+	}
+
+	var monitoredEvent requester.EventWithOdds
+	if event != nil {
+		var ok bool
+		monitoredEvent, ok = getEventWithFavorite(*event)
+		if !ok {
+			return i18n.Translate(language, i18n.TEXT_ABOUT_EVENT_MONITOR_LATER, eventID), nil
+		}
+
+		err = operator.database.InsertEventsForToday([]requester.EventWithOdds{monitoredEvent})
+		if err != nil {
+			return "", err
+		}
+	} else {
+		event, err = operator.getStartedEventByID(eventID)
+		if err != nil {
+			return "", err
+		}
+
+		if event == nil {
+			return i18n.Translate(language, i18n.TEXT_ABOUT_EVENT_MONITOR_LATER, eventID), nil
+		}
+
+		monitoredEvent = *event
+	}
+
+	err = operator.CreateRoutinesForHandleLiveEvents([]requester.EventWithOdds{monitoredEvent})
+	if err != nil {
+		return "", err
+	}
+
+	return i18n.Translate(
+		language,
+		i18n.TEXT_ABOUT_EVENT_MONITORED,
+		eventID,
+		monitoredEvent.HomeCommandName,
+		monitoredEvent.AwayCommandName,
+		monitoredEvent.League.Name,
+		translateFavorite(language, monitoredEvent.Favorite),
+	), nil
+}
+
+func (operator *Operator) skipEvent(
+	eventID string,
+	userID int64,
+	language string,
+) (string, error) {
+	err := operator.database.DeleteEventRule(constants.RULE_MONITOR_EVENT, eventID)
+	if err != nil {
+		return "", err
+	}
+
+	err = operator.saveEventRule(constants.RULE_SKIP_EVENT, eventID, userID)
+	if err != nil {
+		return "", err
+	}
+
+	if operator.CancelEventRoutines(eventID) {
+		return i18n.Translate(language, i18n.TEXT_ABOUT_EVENT_SKIPPED, eventID), nil
+	}
+
+	return i18n.Translate(language, i18n.TEXT_ABOUT_EVENT_SKIPPED_FUTURE, eventID), nil
+}
+
+func (operator *Operator) banTeam(
+	team string,
+	userID int64,
+	language string,
+) (string, error) {
+	err := operator.saveEventRule(constants.RULE_BAN_TEAM, team, userID)
+	if err != nil {
+		return "", err
+	}
+
+	return i18n.Translate(language, i18n.TEXT_ABOUT_TEAM_BANNED, team), nil
+}
+
+func (operator *Operator) banLeague(
+	leagueID string,
+	userID int64,
+	language string,
+) (string, error) {
+	err := operator.saveEventRule(constants.RULE_BAN_LEAGUE, leagueID, userID)
+	if err != nil {
+		return "", err
+	}
+
+	return i18n.Translate(language, i18n.TEXT_ABOUT_LEAGUE_BANNED, leagueID), nil
+}
+
+func (operator *Operator) saveEventRule(kind, value string, userID int64) error {
+	err := operator.database.InsertEventRule(database.EventRule{
+		Kind:      kind,
+		Value:     value,
+		CreatedBy: userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return operator.RefreshEventRules()
+}
+
+// getStartedEventByID returns event which is not upcoming anymore: stored one
+// or the event in play, which is stored first. The event is monitored from
+// now, so its start time is moved to the current time.
+func (operator *Operator) getStartedEventByID(eventID string) (*requester.EventWithOdds, error) {
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := operator.database.GetEventByID(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	if event == nil {
+		liveEvent, err := operator.requester.GetLiveEventByID(eventID)
+		if err != nil {
+			return nil, karma.Format(
+				err,
+				"unable to get live event, event_id: %s",
+				eventID,
+			)
+		}
+
+		inplayEvent, ok := getEventWithFavorite(*liveEvent)
+		if !ok {
+			return nil, nil
+		}
+
+		inplayEvent.EventStartTime = timeNow
+		err = operator.database.InsertEventsForToday([]requester.EventWithOdds{inplayEvent})
+		if err != nil {
+			return nil, err
+		}
+
+		event = &inplayEvent
+	}
+
+	if event.EventStartTime.Before(timeNow) {
+		event.EventStartTime = timeNow
+	}
+
+	return event, nil
+}
+
+func (operator *Operator) getUpcomingEventByID(eventID string) (*requester.EventWithOdds, error) {
+	upcomingEvents, err := operator.requester.GetUpcomingEvents()
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to get upcoming events",
+		)
+	}
+
+	if upcomingEvents == nil {
+		return nil, nil
+	}
+
+	var result requester.UpcomingEvents
+	for _, event := range upcomingEvents.Results {
+		if event.ID == eventID {
+			result.Results = append(result.Results, event)
+		}
+	}
+
+	if len(result.Results) == 0 {
+		return nil, nil
+	}
+
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return nil, err
+	}
+
+	eventsInWindow, err := getUpcomingEventsInWindow(
+		&result,
+		getDiscoveryWindowEnd(timeNow, operator.config.Discovery),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(eventsInWindow.Results) == 0 {
+		return nil, nil
+	}
+
+	eventsWithOdds, err := operator.requester.GetEventOddsByEventIDs(eventsInWindow)
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to get odds of the event, event_id: %s",
+			eventID,
+		)
+	}
+
+	if len(eventsWithOdds) == 0 {
+		return nil, nil
+	}
+
+	return &eventsWithOdds[0], nil
+}
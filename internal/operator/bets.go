@@ -0,0 +1,337 @@
+package operator
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/constants"
+	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/daniilsolovey/BetBotGo/internal/i18n"
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+	"github.com/reconquest/pkg/log"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+const (
+	COMMAND_MY_BETS    = "/mybets"
+	BET_DIALOG_TIMEOUT = 10 * time.Minute
+	MY_BETS_LIMIT      = 10
+)
+
+type betDialog struct {
+	eventID   string
+	expiresAt time.Time
+}
+
+type BetsSummary struct {
+	Total  int
+	Won    int
+	Lost   int
+	Open   int
+	Staked float64
+	Profit float64
+}
+
+func (operator *Operator) Placed(callback *tb.Callback) (string, error) {
+	if operator.isBroadcastCallback(callback) {
+		return operator.getTextAboutBroadcastCallback(callback), nil
+	}
+
+	language := operator.getChatLanguage(
+		callback.Message.Chat.ID,
+		callback.Sender.LanguageCode,
+	)
+
+	result, err := operator.database.GetLiveEventResult(callback.Data)
+	if err != nil {
+		return "", err
+	}
+
+	if result == nil {
+		return i18n.Translate(language, i18n.TEXT_ABOUT_EVENT_NOT_FOUND), nil
+	}
+
+	odd := result.LastHomeOdd
+	if result.Favorite == constants.FAVORITE_IS_AWAY {
+		odd = result.LastAwayOdd
+	}
+
+	userID := int64(callback.Sender.ID)
+	inserted, err := operator.database.InsertBet(database.Bet{
+		ChatID:   callback.Message.Chat.ID,
+		UserID:   userID,
+		EventID:  result.EventID,
+		Favorite: result.Favorite,
+		Stake:    database.BET_DEFAULT_STAKE,
+		Odd:      odd,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if !inserted {
+		return i18n.Translate(language, i18n.TEXT_ABOUT_BET_ALREADY_PLACED), nil
+	}
+
+	log.Infof(nil, "bet placed by user_id: %d, event_id: %s", userID, result.EventID)
+
+	if result.WinnerInSecondSet != "" {
+		// second set is over already, so there is nothing to ask about
+		operator.SettleBets()
+		return i18n.Translate(language, i18n.TEXT_ABOUT_BET_PLACED), nil
+	}
+
+	operator.betDialogsMutex.Lock()
+	operator.betDialogs[userID] = betDialog{
+		eventID:   result.EventID,
+		expiresAt: tools.TimeNow().Add(BET_DIALOG_TIMEOUT),
+	}
+	operator.betDialogsMutex.Unlock()
+
+	err = operator.transport.SendMessage(
+		callback.Message.Chat,
+		i18n.Translate(
+			language,
+			i18n.TEXT_ABOUT_BET_DIALOG,
+			translateFavorite(language, result.Favorite),
+			i18n.FormatNumber(language, database.BET_DEFAULT_STAKE, 2),
+			i18n.FormatNumber(language, odd, 2),
+		),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return i18n.Translate(language, i18n.TEXT_ABOUT_BET_PLACED), nil
+}
+
+// HandleText receives new value of the setting pressed under /settings or
+// stake and odd for the bet placed last, other texts are ignored.
+func (operator *Operator) HandleText(message *tb.Message) error {
+	if message.Sender == nil {
+		return nil
+	}
+
+	handled, err := operator.handleSettingDialog(message)
+	if handled || err != nil {
+		return err
+	}
+
+	userID := int64(message.Sender.ID)
+	dialog, ok := operator.getBetDialog(userID)
+	if !ok {
+		return nil
+	}
+
+	language := operator.getLanguage(message)
+
+	stake, odd, ok := parseBetArguments(message.Text)
+	if !ok {
+		return operator.transport.SendMessage(
+			message.Chat,
+			i18n.Translate(language, i18n.TEXT_ABOUT_BET_DIALOG_USAGE),
+		)
+	}
+
+	if odd == 0 {
+		bets, err := operator.database.GetBets(userID)
+		if err != nil {
+			return err
+		}
+
+		for _, bet := range bets {
+			if bet.EventID == dialog.eventID {
+				odd = bet.Odd
+			}
+		}
+	}
+
+	err = operator.database.UpdateBetStakeAndOdd(userID, dialog.eventID, stake, odd)
+	if err != nil {
+		return err
+	}
+
+	operator.betDialogsMutex.Lock()
+	delete(operator.betDialogs, userID)
+	operator.betDialogsMutex.Unlock()
+
+	return operator.transport.SendMessage(
+		message.Chat,
+		i18n.Translate(
+			language,
+			i18n.TEXT_ABOUT_BET_UPDATED,
+			i18n.FormatNumber(language, stake, 2),
+			i18n.FormatNumber(language, odd, 2),
+		),
+	)
+}
+
+func (operator *Operator) MyBets(message *tb.Message) error {
+	if message.Sender == nil {
+		return nil
+	}
+
+	bets, err := operator.database.GetBets(int64(message.Sender.ID))
+	if err != nil {
+		return err
+	}
+
+	return operator.transport.SendMessage(
+		message.Chat,
+		getTextAboutBets(bets, operator.getLanguage(message)),
+	)
+}
+
+// SettleBets settles open bets of events which have result of the second set
+// and notifies users, it is called whenever results are written and
+// periodically for bets of results written by another instance.
+func (operator *Operator) SettleBets() {
+	bets, err := operator.database.SettleOpenBets()
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	for _, bet := range bets {
+		log.Infof(nil, "bet settled, user_id: %d, event_id: %s, status: %s", bet.UserID, bet.EventID, bet.Status)
+
+		language := operator.delivery.GetLanguage(bet.ChatID)
+		err := operator.delivery.SendToSubscriber(
+			bet.ChatID,
+			i18n.Translate(
+				language,
+				i18n.TEXT_ABOUT_BET_SETTLED,
+				translateFavorite(language, bet.Favorite),
+				translateBetStatus(language, bet.Status),
+				bet.EventID,
+				i18n.FormatSignedNumber(language, bet.Profit, 2),
+			),
+		)
+		if err != nil {
+			log.Errorf(err, "unable to notify about settled bet, user_id: %d", bet.UserID)
+		}
+	}
+}
+
+func (operator *Operator) getBetDialog(userID int64) (betDialog, bool) {
+	operator.betDialogsMutex.Lock()
+	defer operator.betDialogsMutex.Unlock()
+
+	dialog, ok := operator.betDialogs[userID]
+	if !ok {
+		return dialog, false
+	}
+
+	if tools.TimeNow().After(dialog.expiresAt) {
+		delete(operator.betDialogs, userID)
+		return dialog, false
+	}
+
+	return dialog, true
+}
+
+// parseBetArguments parses "<stake> [odd]", odd is zero when it is omitted.
+func parseBetArguments(text string) (float64, float64, bool) {
+	fields := strings.Fields(strings.ReplaceAll(text, ",", "."))
+	if len(fields) == 0 || len(fields) > 2 {
+		return 0, 0, false
+	}
+
+	stake, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil || stake <= 0 {
+		return 0, 0, false
+	}
+
+	if len(fields) == 1 {
+		return stake, 0, true
+	}
+
+	odd, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil || odd <= 1 {
+		return 0, 0, false
+	}
+
+	return stake, odd, true
+}
+
+func getBetsSummary(bets []database.Bet) BetsSummary {
+	var summary BetsSummary
+	for _, bet := range bets {
+		summary.Total++
+		switch bet.Status {
+		case database.BET_STATUS_WON:
+			summary.Won++
+		case database.BET_STATUS_LOST:
+			summary.Lost++
+		default:
+			summary.Open++
+			continue
+		}
+
+		summary.Staked += bet.Stake
+		summary.Profit += bet.Profit
+	}
+
+	return summary
+}
+
+func getTextAboutBets(bets []database.Bet, language string) string {
+	if len(bets) == 0 {
+		return i18n.Translate(
+			language,
+			i18n.TEXT_ABOUT_NO_BETS,
+			i18n.Translate(language, i18n.TEXT_BUTTON_PLACED),
+		)
+	}
+
+	summary := getBetsSummary(bets)
+	var roi float64
+	if summary.Staked != 0 {
+		roi = summary.Profit / summary.Staked * 100
+	}
+
+	text := i18n.Translate(
+		language,
+		i18n.TEXT_ABOUT_MY_BETS,
+		summary.Total,
+		summary.Won,
+		summary.Lost,
+		summary.Open,
+		i18n.FormatNumber(language, summary.Staked, 2),
+		i18n.FormatSignedNumber(language, summary.Profit, 2),
+		i18n.FormatNumber(language, roi, 1),
+	)
+
+	for i, bet := range bets {
+		if i == MY_BETS_LIMIT {
+			break
+		}
+
+		text += i18n.Translate(
+			language,
+			i18n.TEXT_ABOUT_MY_BET,
+			i18n.FormatTime(language, bet.CreatedAt),
+			bet.EventID,
+			i18n.FormatNumber(language, bet.Stake, 2),
+			i18n.FormatNumber(language, bet.Odd, 2),
+			translateBetStatus(language, bet.Status),
+			i18n.FormatSignedNumber(language, bet.Profit, 2),
+		)
+	}
+
+	return text
+}
+
+func translateBetStatus(language string, status string) string {
+	switch status {
+	case database.BET_STATUS_OPEN:
+		return i18n.Translate(language, i18n.TEXT_BET_STATUS_OPEN)
+	case database.BET_STATUS_WON:
+		return i18n.Translate(language, i18n.TEXT_BET_STATUS_WON)
+	case database.BET_STATUS_LOST:
+		return i18n.Translate(language, i18n.TEXT_BET_STATUS_LOST)
+	}
+
+	return status
+}
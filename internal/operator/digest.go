@@ -0,0 +1,210 @@
+package operator
+
+import (
+	"sort"
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/constants"
+	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/daniilsolovey/BetBotGo/internal/i18n"
+	"github.com/daniilsolovey/BetBotGo/internal/requester"
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+	"github.com/reconquest/karma-go"
+	"github.com/reconquest/pkg/log"
+)
+
+// SendDigest sends today's candidates which have not been sent to the chat
+// yet and have not started, the first digest of the day is sent not earlier
+// than digest.hour of the chat timezone and the next ones are sent as updates.
+// Sent events are recorded in the same transaction as queued messages.
+func (operator *Operator) SendDigest() error {
+	if !operator.config.Digest.Enabled {
+		return nil
+	}
+
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to get current time for digest",
+		)
+	}
+
+	var (
+		sentChats int
+		digestErr error
+	)
+	eventsByDay := map[int64][]requester.EventWithOdds{}
+	err = operator.database.InTransaction(func(tx database.DatabaseInterface) error {
+		err := operator.delivery.WithDatabase(tx).SendReportToSubscribersFunc(
+			constants.REPORT_DIGEST,
+			func(subscriber database.Subscriber) string {
+				if digestErr != nil {
+					return ""
+				}
+
+				text, err := operator.getDigest(tx, subscriber.ChatID, timeNow, eventsByDay)
+				if err != nil {
+					digestErr = err
+					return ""
+				}
+
+				if text != "" {
+					sentChats++
+				}
+
+				return text
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		return digestErr
+	})
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to send digest to subscribers",
+		)
+	}
+
+	if sentChats != 0 {
+		log.Infof(nil, "digest sent to %d chats", sentChats)
+	}
+
+	return nil
+}
+
+// getDigest returns digest for the chat and marks its events as sent, empty
+// text is returned when it is too early in the chat timezone or there are no
+// new candidates. Events are cached in eventsByDay by beginning of the day.
+func (operator *Operator) getDigest(
+	tx database.DatabaseInterface,
+	chatID int64,
+	timeNow time.Time,
+	eventsByDay map[int64][]requester.EventWithOdds,
+) (string, error) {
+	location := operator.delivery.GetLocation(chatID)
+	localTime := timeNow.In(location)
+	if localTime.Hour() < operator.config.Digest.Hour {
+		return "", nil
+	}
+
+	day := tools.BeginningOfDay(localTime)
+	events, ok := eventsByDay[day.Unix()]
+	if !ok {
+		var err error
+		events, err = tx.GetUpcomingEventsForDay(localTime)
+		if err != nil {
+			return "", karma.Format(
+				err,
+				"unable to get today's events for digest",
+			)
+		}
+
+		eventsByDay[day.Unix()] = events
+	}
+
+	sentEventIDs, err := tx.GetDigestEventIDs(chatID, day)
+	if err != nil {
+		return "", err
+	}
+
+	candidates := getDigestCandidates(events, sentEventIDs, timeNow)
+	if len(candidates) == 0 {
+		return "", nil
+	}
+
+	var eventIDs []string
+	for _, event := range candidates {
+		eventIDs = append(eventIDs, event.EventID)
+	}
+
+	err = tx.InsertDigestEvents(chatID, eventIDs, timeNow)
+	if err != nil {
+		return "", err
+	}
+
+	return getTextAboutDigest(
+		candidates,
+		len(sentEventIDs) != 0,
+		timeNow,
+		location,
+		operator.delivery.GetLanguage(chatID),
+	), nil
+}
+
+func getDigestCandidates(
+	events []requester.EventWithOdds,
+	sentEventIDs []string,
+	timeNow time.Time,
+) []requester.EventWithOdds {
+	sent := map[string]bool{}
+	for _, eventID := range sentEventIDs {
+		sent[eventID] = true
+	}
+
+	var candidates []requester.EventWithOdds
+	for _, event := range events {
+		if sent[event.EventID] || event.EventStartTime.Before(timeNow) {
+			continue
+		}
+
+		candidates = append(candidates, event)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].EventStartTime.Before(candidates[j].EventStartTime)
+	})
+
+	return candidates
+}
+
+// getTextAboutDigest groups candidates by league, leagues are ordered by
+// their first match.
+func getTextAboutDigest(
+	candidates []requester.EventWithOdds,
+	isUpdate bool,
+	day time.Time,
+	location *time.Location,
+	language string,
+) string {
+	var leagues []string
+	eventsByLeague := map[string][]requester.EventWithOdds{}
+	for _, event := range candidates {
+		if _, ok := eventsByLeague[event.League.Name]; !ok {
+			leagues = append(leagues, event.League.Name)
+		}
+
+		eventsByLeague[event.League.Name] = append(eventsByLeague[event.League.Name], event)
+	}
+
+	header := i18n.TEXT_ABOUT_DIGEST
+	if isUpdate {
+		header = i18n.TEXT_ABOUT_DIGEST_UPDATE
+	}
+
+	text := i18n.Translate(language, header, i18n.FormatDate(language, day.In(location)))
+	for _, league := range leagues {
+		text += i18n.Translate(language, i18n.TEXT_ABOUT_DIGEST_LEAGUE, league)
+		for _, event := range eventsByLeague[league] {
+			favorite, odd := event.HomeCommandName, event.HomeOdd
+			if event.Favorite == constants.FAVORITE_IS_AWAY {
+				favorite, odd = event.AwayCommandName, event.AwayOdd
+			}
+
+			text += i18n.Translate(
+				language,
+				i18n.TEXT_ABOUT_DIGEST_EVENT,
+				event.EventStartTime.In(location).Format("15:04"),
+				event.HomeCommandName,
+				event.AwayCommandName,
+				favorite,
+				i18n.FormatNumber(language, odd, 2),
+			)
+		}
+	}
+
+	return text
+}
@@ -0,0 +1,123 @@
+package operator
+
+import (
+	"strings"
+
+	"github.com/daniilsolovey/BetBotGo/internal/constants"
+	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/daniilsolovey/BetBotGo/internal/requester"
+	"github.com/reconquest/karma-go"
+	"github.com/reconquest/pkg/log"
+)
+
+func (operator *Operator) RefreshEventRules() error {
+	rules, err := operator.database.GetEventRules()
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to get event rules",
+		)
+	}
+
+	operator.eventRulesMutex.Lock()
+	operator.eventRules = rules
+	operator.eventRulesMutex.Unlock()
+
+	log.Infof(nil, "event rules refreshed, rules: %d", len(rules))
+	return nil
+}
+
+// ApplyEventRules removes skipped and banned events from selected events and
+// adds events which are monitored by admin regardless of filters.
+func (operator *Operator) ApplyEventRules(
+	eventsWithOdds []requester.EventWithOdds,
+	selectedEvents []requester.EventWithOdds,
+) []requester.EventWithOdds {
+	operator.eventRulesMutex.Lock()
+	rules := operator.eventRules
+	operator.eventRulesMutex.Unlock()
+
+	return handleEventsByRules(eventsWithOdds, selectedEvents, rules)
+}
+
+func handleEventsByRules(
+	eventsWithOdds []requester.EventWithOdds,
+	selectedEvents []requester.EventWithOdds,
+	rules []database.EventRule,
+) []requester.EventWithOdds {
+	var result []requester.EventWithOdds
+	selected := map[string]bool{}
+	for _, event := range selectedEvents {
+		if isEventExcluded(event, rules) && !hasEventRule(rules, constants.RULE_MONITOR_EVENT, event.EventID) {
+			continue
+		}
+
+		selected[event.EventID] = true
+		result = append(result, event)
+	}
+
+	for _, event := range eventsWithOdds {
+		if selected[event.EventID] || !hasEventRule(rules, constants.RULE_MONITOR_EVENT, event.EventID) {
+			continue
+		}
+
+		monitoredEvent, ok := getEventWithFavorite(event)
+		if !ok {
+			log.Warningf(nil, "monitored event has no odds, event_id: %s", event.EventID)
+			continue
+		}
+
+		result = append(result, monitoredEvent)
+	}
+
+	return result
+}
+
+// isEventExcludedByRules checks rules stored in the database, so /skip,
+// /ban_team and /ban_league handled by any instance stop the signal.
+func (operator *Operator) isEventExcludedByRules(event requester.EventWithOdds) bool {
+	rules, err := operator.database.GetEventRules()
+	if err != nil {
+		log.Errorf(err, "unable to check event rules, event_id: %s", event.EventID)
+		return false
+	}
+
+	return isEventExcluded(event, rules) &&
+		!hasEventRule(rules, constants.RULE_MONITOR_EVENT, event.EventID)
+}
+
+func isEventExcluded(event requester.EventWithOdds, rules []database.EventRule) bool {
+	return hasEventRule(rules, constants.RULE_SKIP_EVENT, event.EventID) ||
+		hasEventRule(rules, constants.RULE_BAN_LEAGUE, event.League.ID) ||
+		hasEventRule(rules, constants.RULE_BAN_TEAM, event.HomeCommandName) ||
+		hasEventRule(rules, constants.RULE_BAN_TEAM, event.AwayCommandName)
+}
+
+func hasEventRule(rules []database.EventRule, kind, value string) bool {
+	for _, rule := range rules {
+		if rule.Kind == kind && strings.EqualFold(rule.Value, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getEventWithFavorite fills favorite and odds like sortEventsByOdds does, but
+// without limit on favorite odd.
+func getEventWithFavorite(event requester.EventWithOdds) (requester.EventWithOdds, bool) {
+	homeOdd, awayOdd, ok, err := getPrimaryOdds(event)
+	if err != nil {
+		log.Errorf(err, "unable to get primary odds, event_id: %s", event.EventID)
+		return event, false
+	}
+
+	if !ok {
+		return event, false
+	}
+
+	event.Favorite = getFavorite(homeOdd, awayOdd)
+	event.HomeOdd = homeOdd
+	event.AwayOdd = awayOdd
+	return event, true
+}
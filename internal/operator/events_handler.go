@@ -7,27 +7,32 @@ import (
 	"strings"
 	"time"
 
+	"github.com/daniilsolovey/BetBotGo/internal/config"
 	"github.com/daniilsolovey/BetBotGo/internal/constants"
+	"github.com/daniilsolovey/BetBotGo/internal/database"
 	"github.com/daniilsolovey/BetBotGo/internal/requester"
 	"github.com/daniilsolovey/BetBotGo/internal/tools"
 	"github.com/reconquest/karma-go"
 )
 
-func getUpcomingEventsForToday(upcomingEvents *requester.UpcomingEvents) (*requester.UpcomingEvents, error) {
+func getUpcomingEventsInWindow(
+	upcomingEvents *requester.UpcomingEvents,
+	windowEnd time.Time,
+) (*requester.UpcomingEvents, error) {
 	var result requester.UpcomingEvents
-	moscowLocation, err := tools.GetTimeMoscowLocation()
+	location, err := tools.GetLocation()
 	if err != nil {
 		return nil, karma.Format(
 			err,
-			"unable to get moscow location",
+			"unable to get operating location",
 		)
 	}
 
-	timeNow, err := tools.GetCurrentMoscowTime()
+	timeNow, err := tools.GetCurrentTime()
 	if err != nil {
 		return nil, karma.Format(
 			err,
-			"unable to get moscow time",
+			"unable to get current time",
 		)
 	}
 
@@ -41,9 +46,8 @@ func getUpcomingEventsForToday(upcomingEvents *requester.UpcomingEvents) (*reque
 			)
 		}
 
-		convertedTime := time.Unix(parsedTime, 0).In(moscowLocation)
-		if convertedTime.After(timeNow) &&
-			convertedTime.Before(timeNow.Truncate(24*time.Hour).Add(21*time.Hour).Add(1*time.Second)) {
+		convertedTime := time.Unix(parsedTime, 0).In(location)
+		if convertedTime.After(timeNow) && !convertedTime.After(windowEnd) {
 			event.HumanTime = convertedTime
 			result.Results = append(result.Results, event)
 		}
@@ -52,8 +56,22 @@ func getUpcomingEventsForToday(upcomingEvents *requester.UpcomingEvents) (*reque
 	return &result, nil
 }
 
-func Truncate(t time.Time) time.Time {
-	return t.Truncate(24 * time.Hour)
+func getDiscoveryWindowEnd(timeNow time.Time, discovery config.Discovery) time.Time {
+	if discovery.UntilHour > 0 {
+		return tools.BeginningOfDay(timeNow).AddDate(0, 0, 1).
+			Add(time.Duration(discovery.UntilHour) * time.Hour)
+	}
+
+	return timeNow.Add(discovery.Lookahead)
+}
+
+func isEventInMonitoringHorizon(
+	event requester.EventWithOdds,
+	timeNow time.Time,
+	horizon time.Duration,
+) bool {
+	return event.EventStartTime.After(timeNow.Add(-MONITORING_LIVE_EVENT_TIME_DELAY)) &&
+		!event.EventStartTime.After(timeNow.Add(horizon))
 }
 
 func sortEventsByOdds(eventsWithOdds []requester.EventWithOdds) ([]requester.EventWithOdds, error) {
@@ -99,65 +117,73 @@ func sortEventsByOdds(eventsWithOdds []requester.EventWithOdds) ([]requester.Eve
 	return result, nil
 }
 
-func handleEventsByLeagues(events []requester.EventWithOdds) []requester.EventWithOdds {
-	leagues := strings.Split(constants.LEAGUES, ",")
+func handleEventsByLeagues(
+	events []requester.EventWithOdds,
+	leagues map[string]database.League,
+) []requester.EventWithOdds {
 	var result []requester.EventWithOdds
 	for _, event := range events {
-		for _, league := range leagues {
-			if strings.Contains(event.League.Name, league) {
-				if isSpecificLeagueContainsWomen(event.League.Name, league) {
-					continue
-				}
+		league, ok := leagues[event.League.ID]
+		if !ok {
+			continue
+		}
 
-				result = append(result, event)
-			}
+		if league.Tier < 1 || league.Tier > constants.LEAGUE_TIER_MAX {
+			continue
+		}
+
+		if league.Gender == constants.GENDER_WOMEN &&
+			league.Tier > constants.LEAGUE_TIER_WOMEN_MAX {
+			continue
 		}
+
+		result = append(result, event)
 	}
 
 	return result
 }
 
-func handleEventsByCountries(events []requester.EventWithOdds) []requester.EventWithOdds {
-	countries := strings.Split(constants.COUNTRIES, ",")
+func handleEventsByCountries(
+	events []requester.EventWithOdds,
+	leagues map[string]database.League,
+) []requester.EventWithOdds {
+	countryCodes := strings.Split(constants.COUNTRY_CODES, ",")
+	menOnlyCountryCodes := strings.Split(constants.MEN_ONLY_COUNTRY_CODES, ",")
 	var result []requester.EventWithOdds
 	for _, event := range events {
-		for _, country := range countries {
-			if strings.Contains(event.League.Name, country) {
-				if isSpecificCountryContainsWomen(event.League.Name, country) {
-					continue
-				}
-				result = append(result, event)
-			}
+		league, ok := leagues[event.League.ID]
+		if !ok {
+			continue
 		}
-	}
 
-	return result
-}
+		if league.Youth {
+			continue
+		}
 
-func isSpecificLeagueContainsWomen(leagueName, league string) bool {
-	leagues := strings.Split(constants.SPECIFIC_LEAGUES, ",")
-	if tools.Find(leagues, league) {
-		if strings.Contains(leagueName, constants.WOMEN) {
-			return true
-		} else {
-			return false
+		countryCode := getCountryCode(event, league)
+		if !tools.Find(countryCodes, countryCode) {
+			continue
 		}
+
+		if league.Gender == constants.GENDER_WOMEN &&
+			tools.Find(menOnlyCountryCodes, countryCode) {
+			continue
+		}
+
+		result = append(result, event)
 	}
 
-	return false
+	return result
 }
 
-func isSpecificCountryContainsWomen(leagueName, country string) bool {
-	countries := strings.Split(constants.SPECIFIC_COUNTRIES, ",")
-	if tools.Find(countries, country) {
-		if strings.Contains(leagueName, constants.WOMEN) {
-			return true
-		} else {
-			return false
-		}
+// getCountryCode returns country code of the league, international leagues
+// don't have it, so country code of the home team is used for them.
+func getCountryCode(event requester.EventWithOdds, league database.League) string {
+	if league.CC != "" {
+		return strings.ToLower(league.CC)
 	}
 
-	return false
+	return strings.ToLower(event.HomeCommandCC)
 }
 
 func convertStringToFloat(data string) (float64, error) {
@@ -218,7 +244,7 @@ func handleLiveEventOdds(event requester.EventWithOdds) (bool, int, error) {
 		return false, 3, nil
 	}
 
-	if event.Favorite != winner && getNumberOfSet(setData) == 2 && mainOdd > 1.5 {
+	if event.Favorite != winner && getNumberOfSet(setData) == 2 && mainOdd > constants.LIVE_ODD_VALUE_MIN {
 		return true, 2, nil
 	} else {
 		return false, 2, nil
@@ -0,0 +1,418 @@
+package operator
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/constants"
+	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/daniilsolovey/BetBotGo/internal/i18n"
+	"github.com/daniilsolovey/BetBotGo/internal/requester"
+	"github.com/daniilsolovey/BetBotGo/internal/statistics"
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+	"github.com/daniilsolovey/BetBotGo/internal/transport"
+	"github.com/reconquest/karma-go"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+const (
+	COMMAND_TODAY   = "/today"
+	COMMAND_LIVE    = "/live"
+	COMMAND_STATS   = "/stats"
+	COMMAND_HISTORY = "/history"
+	COMMAND_CHARTS  = "/charts"
+	COMMAND_HELP    = "/help"
+
+	STATS_PERIOD_DAY   = "day"
+	STATS_PERIOD_WEEK  = "week"
+	STATS_PERIOD_MONTH = "month"
+	STATS_DATE_FORMAT  = "2006-01-02"
+
+	HISTORY_DEFAULT_LIMIT = 10
+	HISTORY_MAX_LIMIT     = 50
+)
+
+// Command describes bot command for routing and /help, the command is
+// available for users with Role and more privileged ones. ChatWide commands
+// change the chat for all its members, so in groups the sender needs own
+// subscriber role, membership in the subscribed group is not enough.
+type Command struct {
+	Name        string
+	Description string
+	Role        string
+	ChatWide    bool
+	Handler     func(*tb.Message) error
+}
+
+// GetCommands returns all commands of the bot, main registers them in
+// telegram and /help lists them in the same order.
+func (operator *Operator) GetCommands() []Command {
+	return []Command{
+		{Name: COMMAND_START, Description: i18n.TEXT_COMMAND_START, Role: database.ROLE_GUEST, Handler: operator.Start},
+		{Name: COMMAND_STOP, Description: i18n.TEXT_COMMAND_STOP, Role: database.ROLE_GUEST, ChatWide: true, Handler: operator.Unsubscribe},
+		{Name: COMMAND_TODAY, Description: i18n.TEXT_COMMAND_TODAY, Role: database.ROLE_SUBSCRIBER, Handler: operator.Today},
+		{Name: COMMAND_TOMORROW, Description: i18n.TEXT_COMMAND_TOMORROW, Role: database.ROLE_SUBSCRIBER, Handler: operator.Tomorrow},
+		{Name: COMMAND_LIVE, Description: i18n.TEXT_COMMAND_LIVE, Role: database.ROLE_SUBSCRIBER, Handler: operator.Live},
+		{Name: COMMAND_STATS, Description: i18n.TEXT_COMMAND_STATS, Role: database.ROLE_SUBSCRIBER, Handler: operator.Stats},
+		{Name: COMMAND_HISTORY, Description: i18n.TEXT_COMMAND_HISTORY, Role: database.ROLE_SUBSCRIBER, Handler: operator.History},
+		{Name: COMMAND_CHARTS, Description: i18n.TEXT_COMMAND_CHARTS, Role: database.ROLE_SUBSCRIBER, Handler: operator.Charts},
+		{Name: COMMAND_MY_BETS, Description: i18n.TEXT_COMMAND_MY_BETS, Role: database.ROLE_SUBSCRIBER, Handler: operator.MyBets},
+		{Name: COMMAND_SETTINGS, Description: i18n.TEXT_COMMAND_SETTINGS, Role: database.ROLE_SUBSCRIBER, ChatWide: true, Handler: operator.Settings},
+		{Name: COMMAND_TIMEZONE, Description: i18n.TEXT_COMMAND_TIMEZONE, Role: database.ROLE_SUBSCRIBER, ChatWide: true, Handler: operator.SetTimezone},
+		{Name: COMMAND_LANGUAGE, Description: i18n.TEXT_COMMAND_LANGUAGE, Role: database.ROLE_GUEST, ChatWide: true, Handler: operator.SetLanguage},
+		{Name: COMMAND_HELP, Description: i18n.TEXT_COMMAND_HELP, Role: database.ROLE_GUEST, Handler: operator.Help},
+
+		{Name: COMMAND_MONITOR, Description: i18n.TEXT_COMMAND_MONITOR, Role: database.ROLE_ADMIN, Handler: operator.Monitor},
+		{Name: COMMAND_SKIP, Description: i18n.TEXT_COMMAND_SKIP, Role: database.ROLE_ADMIN, Handler: operator.Skip},
+		{Name: COMMAND_BAN_TEAM, Description: i18n.TEXT_COMMAND_BAN_TEAM, Role: database.ROLE_ADMIN, Handler: operator.BanTeam},
+		{Name: COMMAND_BAN_LEAGUE, Description: i18n.TEXT_COMMAND_BAN_LEAGUE, Role: database.ROLE_ADMIN, Handler: operator.BanLeague},
+		{Name: COMMAND_CREATE_CODE, Description: i18n.TEXT_COMMAND_CREATE_CODE, Role: database.ROLE_ADMIN, Handler: operator.CreateCode},
+		{Name: COMMAND_ROLE, Description: i18n.TEXT_COMMAND_ROLE, Role: database.ROLE_ADMIN, Handler: operator.SetRole},
+	}
+}
+
+func (operator *Operator) Help(message *tb.Message) error {
+	language := operator.getLanguage(message)
+	role, err := operator.getRole(message)
+	if err != nil {
+		return err
+	}
+
+	text := i18n.Translate(language, i18n.TEXT_ABOUT_HELP)
+	var adminText string
+	for _, command := range operator.GetCommands() {
+		line := i18n.Translate(
+			language,
+			i18n.TEXT_ABOUT_HELP_COMMAND,
+			command.Name,
+			i18n.Translate(language, command.Description),
+		)
+		if !database.IsRoleAllowed(role, command.Role) {
+			continue
+		}
+
+		if command.Role == database.ROLE_ADMIN {
+			adminText += line
+		} else {
+			text += line
+		}
+	}
+
+	if adminText != "" {
+		text += i18n.Translate(language, i18n.TEXT_ABOUT_HELP_ADMIN) + adminText
+	}
+
+	return operator.sendPages(message.Chat, text)
+}
+
+func (operator *Operator) Today(message *tb.Message) error {
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return err
+	}
+
+	return operator.sendEventsForDay(message.Chat, timeNow, operator.getLanguage(message))
+}
+
+func (operator *Operator) Live(message *tb.Message) error {
+	language := operator.getLanguage(message)
+	events := operator.getLiveEvents()
+	if len(events) == 0 {
+		return operator.transport.SendMessage(
+			message.Chat,
+			i18n.Translate(language, i18n.TEXT_ABOUT_NO_LIVE),
+		)
+	}
+
+	text := i18n.Translate(language, i18n.TEXT_ABOUT_LIVE_EVENTS)
+	for _, event := range events {
+		var score, homeOdd, awayOdd string
+		if len(event.ResultEventWithOdds.Odds.Odds91_1) != 0 {
+			odds := event.ResultEventWithOdds.Odds.Odds91_1[0]
+			score = odds.SS
+			homeOdd = formatOdd(language, odds.HomeOd)
+			awayOdd = formatOdd(language, odds.AwayOd)
+		}
+
+		text += i18n.Translate(
+			language,
+			i18n.TEXT_ABOUT_LIVE_EVENT,
+			event.HomeCommandName,
+			event.AwayCommandName,
+			event.League.Name,
+			translateFavorite(language, event.Favorite),
+			score,
+			getNumberOfSet(score),
+			homeOdd,
+			awayOdd,
+		)
+	}
+
+	return operator.sendPages(message.Chat, text)
+}
+
+func (operator *Operator) Stats(message *tb.Message) error {
+	language := operator.getLanguage(message)
+	location := operator.delivery.GetLocation(message.Chat.ID)
+
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return err
+	}
+
+	from, to, ok := parseStatsPeriod(message.Payload, timeNow.In(location))
+	if !ok {
+		return operator.transport.SendMessage(
+			message.Chat,
+			i18n.Translate(language, i18n.TEXT_ABOUT_STATS_USAGE),
+		)
+	}
+
+	results, err := operator.database.GetLiveEventsResults(from, to)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to get live events results for statistics",
+		)
+	}
+
+	result := statistics.GetResults(results)
+	return operator.transport.SendMessage(
+		message.Chat,
+		i18n.Translate(
+			language,
+			i18n.TEXT_ABOUT_STATS,
+			i18n.FormatDate(language, from),
+			i18n.FormatDate(language, to.Add(-time.Nanosecond)),
+			result.Win,
+			result.Lose,
+			i18n.FormatNumber(language, result.AverageOdd, 2),
+		),
+	)
+}
+
+// Charts sends charts of results for the period, the week is the default
+// period.
+func (operator *Operator) Charts(message *tb.Message) error {
+	language := operator.getLanguage(message)
+	location := operator.delivery.GetLocation(message.Chat.ID)
+
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return err
+	}
+
+	payload := message.Payload
+	if strings.TrimSpace(payload) == "" {
+		payload = STATS_PERIOD_WEEK
+	}
+
+	from, to, ok := parseStatsPeriod(payload, timeNow.In(location))
+	if !ok || to.Sub(from) > statistics.CHART_MAX_DAYS*24*time.Hour {
+		return operator.transport.SendMessage(
+			message.Chat,
+			i18n.Translate(language, i18n.TEXT_ABOUT_CHARTS_USAGE, statistics.CHART_MAX_DAYS),
+		)
+	}
+
+	results, err := operator.database.GetLiveEventsResults(from, to)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to get live events results for charts",
+		)
+	}
+
+	if len(results) == 0 {
+		return operator.transport.SendMessage(
+			message.Chat,
+			i18n.Translate(
+				language,
+				i18n.TEXT_ABOUT_NO_RESULTS,
+				i18n.FormatDate(language, from),
+				i18n.FormatDate(language, to.Add(-time.Nanosecond)),
+			),
+		)
+	}
+
+	renderedCharts, err := statistics.GetCharts(results, from, to, location)
+	if err != nil {
+		return err
+	}
+
+	for _, chart := range renderedCharts {
+		_, _, err := operator.transport.SendPhoto(
+			message.Chat,
+			transport.Photo{Data: chart.Image},
+			statistics.GetChartCaption(language, chart.Name, from, to),
+			nil,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (operator *Operator) History(message *tb.Message) error {
+	language := operator.getLanguage(message)
+
+	limit := HISTORY_DEFAULT_LIMIT
+	argument := strings.TrimSpace(message.Payload)
+	if argument != "" {
+		var err error
+		limit, err = strconv.Atoi(argument)
+		if err != nil || limit < 1 || limit > HISTORY_MAX_LIMIT {
+			return operator.transport.SendMessage(
+				message.Chat,
+				i18n.Translate(language, i18n.TEXT_ABOUT_HISTORY_USAGE, HISTORY_MAX_LIMIT),
+			)
+		}
+	}
+
+	results, err := operator.database.GetLastLiveEventsResults(limit)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to get last live events results",
+		)
+	}
+
+	return operator.sendPages(
+		message.Chat,
+		getTextAboutHistory(results, operator.delivery.GetLocation(message.Chat.ID), language),
+	)
+}
+
+// sendPages sends text split into several messages when it exceeds telegram
+// message limit.
+func (operator *Operator) sendPages(recipient tb.Recipient, text string) error {
+	for _, page := range transport.SplitMessage(text, transport.MESSAGE_LENGTH_LIMIT) {
+		err := operator.transport.SendMessage(recipient, page)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (operator *Operator) setLiveEvent(event requester.EventWithOdds) {
+	operator.liveEventsMutex.Lock()
+	defer operator.liveEventsMutex.Unlock()
+
+	if operator.liveEvents == nil {
+		operator.liveEvents = map[string]requester.EventWithOdds{}
+	}
+
+	operator.liveEvents[event.EventID] = event
+}
+
+func (operator *Operator) deleteLiveEvent(eventID string) {
+	operator.liveEventsMutex.Lock()
+	defer operator.liveEventsMutex.Unlock()
+
+	delete(operator.liveEvents, eventID)
+}
+
+func (operator *Operator) getLiveEvents() []requester.EventWithOdds {
+	operator.liveEventsMutex.Lock()
+	defer operator.liveEventsMutex.Unlock()
+
+	var events []requester.EventWithOdds
+	for _, event := range operator.liveEvents {
+		events = append(events, event)
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].EventStartTime.Equal(events[j].EventStartTime) {
+			return events[i].EventID < events[j].EventID
+		}
+
+		return events[i].EventStartTime.Before(events[j].EventStartTime)
+	})
+
+	return events
+}
+
+// parseStatsPeriod returns [from, to) for "day", "week", "month" or
+// "<from> <to>" dates, periods end with the current day.
+func parseStatsPeriod(payload string, timeNow time.Time) (time.Time, time.Time, bool) {
+	today := tools.BeginningOfDay(timeNow)
+	tomorrow := today.AddDate(0, 0, 1)
+
+	arguments := strings.Fields(strings.ToLower(payload))
+	switch len(arguments) {
+	case 0:
+		return today, tomorrow, true
+
+	case 1:
+		switch arguments[0] {
+		case STATS_PERIOD_DAY:
+			return today, tomorrow, true
+		case STATS_PERIOD_WEEK:
+			return today.AddDate(0, 0, -6), tomorrow, true
+		case STATS_PERIOD_MONTH:
+			return today.AddDate(0, -1, 1), tomorrow, true
+		}
+
+	case 2:
+		from, err := time.ParseInLocation(STATS_DATE_FORMAT, arguments[0], timeNow.Location())
+		if err != nil {
+			return time.Time{}, time.Time{}, false
+		}
+
+		to, err := time.ParseInLocation(STATS_DATE_FORMAT, arguments[1], timeNow.Location())
+		if err != nil || to.Before(from) {
+			return time.Time{}, time.Time{}, false
+		}
+
+		return from, to.AddDate(0, 0, 1), true
+	}
+
+	return time.Time{}, time.Time{}, false
+}
+
+func getTextAboutHistory(
+	results []requester.LiveEventResult,
+	location *time.Location,
+	language string,
+) string {
+	if len(results) == 0 {
+		return i18n.Translate(language, i18n.TEXT_ABOUT_NO_HISTORY)
+	}
+
+	text := i18n.Translate(language, i18n.TEXT_ABOUT_HISTORY)
+	for _, result := range results {
+		odd := result.LastHomeOdd
+		if result.Favorite == constants.FAVORITE_IS_AWAY {
+			odd = result.LastAwayOdd
+		}
+
+		var outcome string
+		switch result.WinnerInSecondSet {
+		case "":
+			outcome = i18n.Translate(language, i18n.TEXT_SIGNAL_RESULT_OPEN)
+		case result.Favorite:
+			outcome = i18n.Translate(language, i18n.TEXT_SIGNAL_RESULT_WON, result.Score)
+		default:
+			outcome = i18n.Translate(language, i18n.TEXT_SIGNAL_RESULT_LOST, result.Score)
+		}
+
+		text += i18n.Translate(
+			language,
+			i18n.TEXT_ABOUT_SIGNAL_RESULT,
+			i18n.FormatTime(language, result.CreatedAt.In(location)),
+			result.HomeCommandName,
+			result.AwayCommandName,
+			translateFavorite(language, result.Favorite),
+			i18n.FormatNumber(language, odd, 2),
+			outcome,
+		)
+	}
+
+	return text
+}
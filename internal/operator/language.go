@@ -0,0 +1,110 @@
+package operator
+
+import (
+	"strings"
+
+	"github.com/daniilsolovey/BetBotGo/internal/constants"
+	"github.com/daniilsolovey/BetBotGo/internal/i18n"
+	"github.com/reconquest/pkg/log"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+const (
+	COMMAND_LANGUAGE = "/language"
+)
+
+func (operator *Operator) SetLanguage(message *tb.Message) error {
+	language := strings.ToLower(strings.TrimSpace(message.Payload))
+	if !i18n.IsSupported(language) {
+		return operator.transport.SendMessage(
+			message.Chat,
+			i18n.Translate(
+				operator.getLanguage(message),
+				i18n.TEXT_ABOUT_LANGUAGE_USAGE,
+				strings.Join(i18n.GetLanguages(), "|"),
+			),
+		)
+	}
+
+	err := operator.database.SetChatLanguage(message.Chat.ID, language)
+	if err != nil {
+		return err
+	}
+
+	return operator.transport.SendMessage(
+		message.Chat,
+		i18n.Translate(language, i18n.TEXT_ABOUT_LANGUAGE_CHANGED),
+	)
+}
+
+// getLanguage returns language chosen for the chat by /language, otherwise
+// language of telegram client of the sender.
+func (operator *Operator) getLanguage(message *tb.Message) string {
+	var languageCode string
+	if message.Sender != nil {
+		languageCode = message.Sender.LanguageCode
+	}
+
+	if message.Chat == nil {
+		return operator.getChatLanguage(int64(message.Sender.ID), languageCode)
+	}
+
+	return operator.getChatLanguage(message.Chat.ID, languageCode)
+}
+
+func (operator *Operator) getChatLanguage(chatID int64, languageCode string) string {
+	language, err := operator.database.GetChatLanguage(chatID)
+	if err != nil {
+		log.Error(err)
+	}
+
+	if i18n.IsSupported(language) {
+		return language
+	}
+
+	language = i18n.GetLanguage(languageCode)
+	if language != "" {
+		return language
+	}
+
+	return i18n.DefaultLanguage
+}
+
+// rememberLanguage saves language of telegram client for the chat, so
+// messages sent to all subscribers use it until /language is called.
+func (operator *Operator) rememberLanguage(message *tb.Message, chatID int64) {
+	if message.Sender == nil {
+		return
+	}
+
+	language := i18n.GetLanguage(message.Sender.LanguageCode)
+	if language == "" {
+		return
+	}
+
+	current, err := operator.database.GetChatLanguage(chatID)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	if current != "" {
+		return
+	}
+
+	err = operator.database.SetChatLanguage(chatID, language)
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+func translateFavorite(language string, favorite string) string {
+	switch favorite {
+	case constants.FAVORITE_IS_HOME:
+		return i18n.Translate(language, i18n.TEXT_FAVORITE_HOME)
+	case constants.FAVORITE_IS_AWAY:
+		return i18n.Translate(language, i18n.TEXT_FAVORITE_AWAY)
+	}
+
+	return favorite
+}
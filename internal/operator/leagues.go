@@ -0,0 +1,106 @@
+package operator
+
+import (
+	"strings"
+
+	"github.com/daniilsolovey/BetBotGo/internal/constants"
+	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/daniilsolovey/BetBotGo/internal/requester"
+	"github.com/reconquest/karma-go"
+	"github.com/reconquest/pkg/log"
+)
+
+func (operator *Operator) RefreshLeagues(events []requester.EventWithOdds) error {
+	knownLeagues := operator.getLeagues()
+
+	var newLeagues []database.League
+	for _, event := range events {
+		if event.League.ID == "" {
+			continue
+		}
+
+		if _, ok := knownLeagues[event.League.ID]; ok {
+			continue
+		}
+
+		newLeagues = append(newLeagues, newLeagueFromEvent(event))
+	}
+
+	err := operator.database.InsertLeagues(newLeagues)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to add new leagues to catalogue",
+		)
+	}
+
+	leagues, err := operator.database.GetLeagues()
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to get leagues catalogue",
+		)
+	}
+
+	catalogue := make(map[string]database.League, len(leagues))
+	for _, league := range leagues {
+		catalogue[league.ID] = league
+	}
+
+	operator.leaguesMutex.Lock()
+	operator.leagues = catalogue
+	operator.leaguesMutex.Unlock()
+
+	log.Infof(nil, "leagues catalogue refreshed, leagues: %d", len(leagues))
+	return nil
+}
+
+// getLeagues returns leagues catalogue, the map is replaced on refresh and
+// must not be modified.
+func (operator *Operator) getLeagues() map[string]database.League {
+	operator.leaguesMutex.RLock()
+	defer operator.leaguesMutex.RUnlock()
+
+	return operator.leagues
+}
+
+func newLeagueFromEvent(event requester.EventWithOdds) database.League {
+	league := database.League{
+		ID:     event.League.ID,
+		Name:   event.League.Name,
+		CC:     strings.ToLower(event.League.CC),
+		Gender: constants.GENDER_MEN,
+		Tier:   guessLeagueTier(event.League.Name),
+		Youth:  containsAny(event.League.Name, constants.YOUTH_MARKERS),
+	}
+
+	if strings.Contains(event.League.Name, constants.WOMEN) {
+		league.Gender = constants.GENDER_WOMEN
+	}
+
+	return league
+}
+
+// guessLeagueTier is used only for leagues which are seen for the first time,
+// admins are able to correct tier later.
+func guessLeagueTier(leagueName string) int {
+	if containsAny(leagueName, constants.LEAGUES_TIER_1) {
+		return 1
+	}
+
+	if containsAny(leagueName, constants.LEAGUES_TIER_2) {
+		return 2
+	}
+
+	return 0
+}
+
+func containsAny(name, markers string) bool {
+	for _, marker := range strings.Split(markers, ",") {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+
+	return false
+}
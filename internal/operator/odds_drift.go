@@ -0,0 +1,181 @@
+package operator
+
+import (
+	"math"
+
+	"github.com/daniilsolovey/BetBotGo/internal/constants"
+	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/daniilsolovey/BetBotGo/internal/i18n"
+	"github.com/daniilsolovey/BetBotGo/internal/requester"
+	"github.com/reconquest/pkg/log"
+)
+
+type OddsDrift struct {
+	Kind            string
+	OpeningHomeOdd  float64
+	OpeningAwayOdd  float64
+	CurrentHomeOdd  float64
+	CurrentAwayOdd  float64
+	OpeningFavorite string
+	CurrentFavorite string
+}
+
+// HandleOddsDrift saves odds history of stored events and alerts about
+// drift, failed events are logged and don't stop checking the others.
+func (operator *Operator) HandleOddsDrift(events []requester.EventWithOdds) {
+	for _, event := range events {
+		homeOdd, awayOdd, ok, err := getPrimaryOdds(event)
+		if err != nil {
+			log.Errorf(err, "unable to get primary odds, event_id: %s", event.EventID)
+			continue
+		}
+
+		if !ok {
+			continue
+		}
+
+		storedEvent, err := operator.database.GetEventByID(event.EventID)
+		if err != nil {
+			log.Errorf(err, "unable to get stored event, event_id: %s", event.EventID)
+			continue
+		}
+
+		if storedEvent == nil {
+			continue
+		}
+
+		err = operator.database.InsertEventOddsHistory(event.EventID, homeOdd, awayOdd)
+		if err != nil {
+			log.Errorf(err, "unable to save odds history, event_id: %s", event.EventID)
+			continue
+		}
+
+		drift := getOddsDrift(
+			*storedEvent,
+			homeOdd,
+			awayOdd,
+			operator.config.OddsDrift.Threshold,
+		)
+		if drift == nil {
+			continue
+		}
+
+		sent, err := operator.database.SendSignalOnce(
+			event.EventID,
+			constants.STRATEGY_SECOND_SET,
+			drift.Kind,
+			func(tx database.DatabaseInterface) error {
+				return operator.SendMessageAboutOddsDriftToTelegram(tx, *storedEvent, *drift)
+			},
+		)
+		if err != nil {
+			log.Errorf(err, "unable to send odds drift alert, event_id: %s", event.EventID)
+			continue
+		}
+
+		if sent {
+			log.Infof(nil, "odds drift alert sent to telegram, event_id: %s", event.EventID)
+		}
+	}
+}
+
+func (operator *Operator) SendMessageAboutOddsDriftToTelegram(
+	tx database.DatabaseInterface,
+	event requester.EventWithOdds,
+	drift OddsDrift,
+) error {
+	return operator.delivery.WithDatabase(tx).SendAlertFunc(func(chatID int64) string {
+		location := operator.delivery.GetLocation(chatID)
+		language := operator.delivery.GetLanguage(chatID)
+		return i18n.Translate(
+			language,
+			i18n.TEXT_ABOUT_ODDS_DRIFT,
+			translateOddsDriftKind(language, drift.Kind),
+			event.EventID,
+			event.League.Name,
+			event.HomeCommandName,
+			event.AwayCommandName,
+			i18n.FormatNumber(language, drift.OpeningHomeOdd, 3),
+			i18n.FormatNumber(language, drift.OpeningAwayOdd, 3),
+			i18n.FormatNumber(language, drift.CurrentHomeOdd, 3),
+			i18n.FormatNumber(language, drift.CurrentAwayOdd, 3),
+			i18n.FormatTime(language, event.EventStartTime.In(location)),
+			translateFavorite(language, drift.OpeningFavorite),
+			translateFavorite(language, drift.CurrentFavorite),
+		)
+	})
+}
+
+func translateOddsDriftKind(language string, kind string) string {
+	if kind == constants.SIGNAL_TYPE_FLIP {
+		return i18n.Translate(language, i18n.TEXT_ODDS_DRIFT_KIND_FLIP)
+	}
+
+	return i18n.Translate(language, i18n.TEXT_ODDS_DRIFT_KIND_DRIFT)
+}
+
+func getOddsDrift(
+	storedEvent requester.EventWithOdds,
+	homeOdd, awayOdd, threshold float64,
+) *OddsDrift {
+	drift := OddsDrift{
+		OpeningHomeOdd:  storedEvent.HomeOdd,
+		OpeningAwayOdd:  storedEvent.AwayOdd,
+		CurrentHomeOdd:  homeOdd,
+		CurrentAwayOdd:  awayOdd,
+		OpeningFavorite: storedEvent.Favorite,
+		CurrentFavorite: getFavorite(homeOdd, awayOdd),
+	}
+
+	if drift.CurrentFavorite != drift.OpeningFavorite {
+		drift.Kind = constants.SIGNAL_TYPE_FLIP
+		return &drift
+	}
+
+	openingOdd, currentOdd := storedEvent.HomeOdd, homeOdd
+	if storedEvent.Favorite == constants.FAVORITE_IS_AWAY {
+		openingOdd, currentOdd = storedEvent.AwayOdd, awayOdd
+	}
+
+	if openingOdd == 0 {
+		return nil
+	}
+
+	if math.Abs(currentOdd-openingOdd)/openingOdd >= threshold {
+		drift.Kind = constants.SIGNAL_TYPE_DRIFT
+		return &drift
+	}
+
+	return nil
+}
+
+func getFavorite(homeOdd, awayOdd float64) string {
+	if homeOdd < awayOdd {
+		return constants.FAVORITE_IS_HOME
+	}
+
+	return constants.FAVORITE_IS_AWAY
+}
+
+func getPrimaryOdds(event requester.EventWithOdds) (float64, float64, bool, error) {
+	primaryOdds := event.ResultEventWithOdds.Odds.Odds91_1
+	if len(primaryOdds) == 0 {
+		return 0, 0, false, nil
+	}
+
+	if primaryOdds[0].HomeOd == "-" || primaryOdds[0].AwayOd == "-" {
+		return 0, 0, false, nil
+	}
+
+	homeOdd, err := convertStringToFloat(primaryOdds[0].HomeOd)
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	awayOdd, err := convertStringToFloat(primaryOdds[0].AwayOd)
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	return homeOdd, awayOdd, true, nil
+}
@@ -1,12 +1,18 @@
 package operator
 
 import (
-	"fmt"
+	"context"
+	"sync"
 	"time"
 
 	"github.com/daniilsolovey/BetBotGo/internal/config"
+	"github.com/daniilsolovey/BetBotGo/internal/constants"
 	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/daniilsolovey/BetBotGo/internal/delivery"
+	"github.com/daniilsolovey/BetBotGo/internal/i18n"
+	"github.com/daniilsolovey/BetBotGo/internal/notifier"
 	"github.com/daniilsolovey/BetBotGo/internal/requester"
+	"github.com/daniilsolovey/BetBotGo/internal/templates"
 	"github.com/daniilsolovey/BetBotGo/internal/tools"
 	"github.com/daniilsolovey/BetBotGo/internal/transport"
 	"github.com/reconquest/karma-go"
@@ -26,24 +32,55 @@ const (
 
 type Operator struct {
 	config                     *config.Config
-	database                   *database.Database
+	database                   database.DatabaseInterface
 	requester                  requester.RequesterInterface
 	transport                  transport.Transport
+	delivery                   *delivery.Delivery
+	templates                  *templates.Templates
 	RoutineCache               []string
 	allEventsOnCurrentDayCache []string
+	leagues                    map[string]database.League
+	leaguesMutex               sync.RWMutex
+	eventRules                 []database.EventRule
+	eventRulesMutex            sync.Mutex
+	context                    context.Context
+	cancel                     context.CancelFunc
+	routines                   sync.WaitGroup
+	routinesMutex              sync.Mutex
+	eventContexts              map[string]*eventRoutines
+	activeRoutines             int64
+	isLeader                   func() bool
+	botUsername                string
+	betDialogs                 map[int64]betDialog
+	betDialogsMutex            sync.Mutex
+	settingDialogs             map[int64]settingDialog
+	settingDialogsMutex        sync.Mutex
+	liveEvents                 map[string]requester.EventWithOdds
+	liveEventsMutex            sync.Mutex
+	notifier                   *notifier.Router
 }
 
 func NewOperator(
 	config *config.Config,
-	database *database.Database,
+	database database.DatabaseInterface,
 	requester requester.RequesterInterface,
 	transport transport.Transport,
+	templates *templates.Templates,
 ) *Operator {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Operator{
 		config:    config,
 		database:  database,
 		requester: requester,
 		transport: transport,
+		delivery:  delivery.NewDelivery(database),
+		templates: templates,
+		context:   ctx,
+		cancel:    cancel,
+
+		eventContexts:  map[string]*eventRoutines{},
+		betDialogs:     map[int64]betDialog{},
+		settingDialogs: map[int64]settingDialog{},
 	}
 }
 
@@ -89,11 +126,20 @@ func (operator *Operator) IsAllEventsCacheContainsEvent(eventID string) bool {
 	return false
 }
 func (operator *Operator) HandleEventsByLeagues(events []requester.EventWithOdds) []requester.EventWithOdds {
-	return handleEventsByLeagues(events)
+	return handleEventsByLeagues(events, operator.getLeagues())
 }
 
 func (operator *Operator) GetEvents() ([]requester.EventWithOdds, error) {
-	log.Info("receiving events for today")
+	eventsWithOdds, err := operator.GetEventsWithOdds()
+	if err != nil {
+		return nil, err
+	}
+
+	return operator.SelectEvents(eventsWithOdds)
+}
+
+func (operator *Operator) GetEventsWithOdds() ([]requester.EventWithOdds, error) {
+	log.Info("receiving upcoming events")
 	upcomingEvents, err := operator.requester.GetUpcomingEvents()
 	if err != nil {
 		return nil, karma.Format(
@@ -101,22 +147,32 @@ func (operator *Operator) GetEvents() ([]requester.EventWithOdds, error) {
 			"unable to get upcoming events",
 		)
 	}
-	log.Info("handle upcoming events and receiving events for today")
+	log.Info("handle upcoming events and receiving events in discovery window")
 	if upcomingEvents == nil {
 		return nil, nil
 	}
 
-	upcomingEventsForToday, err := getUpcomingEventsForToday(upcomingEvents)
+	timeNow, err := tools.GetCurrentTime()
 	if err != nil {
 		return nil, karma.Format(
 			err,
-			"unable to get upcoming events for today",
+			"unable to get current time for discovery window",
 		)
 	}
 
-	log.Info("upcoming events for today received successfully")
+	windowEnd := getDiscoveryWindowEnd(timeNow, operator.config.Discovery)
+	upcomingEventsInWindow, err := getUpcomingEventsInWindow(upcomingEvents, windowEnd)
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to get upcoming events until %s",
+			windowEnd,
+		)
+	}
 
-	eventsWithOdds, err := operator.requester.GetEventOddsByEventIDs(upcomingEventsForToday)
+	log.Infof(nil, "upcoming events until %s received successfully", windowEnd)
+
+	eventsWithOdds, err := operator.requester.GetEventOddsByEventIDs(upcomingEventsInWindow)
 	if err != nil {
 		return nil, karma.Format(
 			err,
@@ -124,6 +180,10 @@ func (operator *Operator) GetEvents() ([]requester.EventWithOdds, error) {
 		)
 	}
 
+	return eventsWithOdds, nil
+}
+
+func (operator *Operator) SelectEvents(eventsWithOdds []requester.EventWithOdds) ([]requester.EventWithOdds, error) {
 	sortedEventsWithOdds, err := sortEventsByOdds(eventsWithOdds)
 	if err != nil {
 		return nil, karma.Format(
@@ -132,27 +192,71 @@ func (operator *Operator) GetEvents() ([]requester.EventWithOdds, error) {
 		)
 	}
 
-	return handleEventsByCountries(sortedEventsWithOdds), nil
+	return handleEventsByCountries(sortedEventsWithOdds, operator.getLeagues()), nil
 }
 
-func (operator *Operator) SendMessageAboutWinnerToTelegram(event requester.EventWithOdds) error {
-	text := fmt.Sprintf(
-		TEXT_ABOUT_WINNER,
-		event.EventID,
-		event.League.Name,
-		event.ResultEventWithOdds.Odds.Odds91_1[0].HomeOd,
-		event.ResultEventWithOdds.Odds.Odds91_1[0].AwayOd,
-		event.HomeCommandName,
-		event.AwayCommandName,
-		event.Favorite,
+// SendMessageAboutWinnerToTelegram queues signal to outbox in the given
+// transaction, see database.SendSignalOnce.
+func (operator *Operator) SendMessageAboutWinnerToTelegram(
+	tx database.DatabaseInterface,
+	event requester.EventWithOdds,
+) ([]delivery.SentMessage, error) {
+	return operator.delivery.WithDatabase(tx).SendSignalToSubscribers(
+		operator.getSignal(event),
+		func(subscriber database.Subscriber) delivery.Message {
+			return operator.getMessageAboutSignal(event, subscriber.ChatID, "")
+		},
 	)
+}
+
+// notifySignal sends signal to notification channels, it is called only
+// after signal is committed.
+func (operator *Operator) notifySignal(event requester.EventWithOdds) {
+	message := operator.getMessageAboutSignal(event, 0, "")
+	operator.notifier.Notify(notifier.Notification{
+		Kind: notifier.KIND_SIGNAL,
+		Text: message.Text,
+		HTML: message.HTML,
+	})
+}
+
+// SetNotifier enables notifications about signals and errors to additional
+// channels.
+func (operator *Operator) SetNotifier(router *notifier.Router) {
+	operator.notifier = router
+}
+
+// SetBroadcastRoutes enables sending of signals, alerts and errors to
+// broadcast chats.
+func (operator *Operator) SetBroadcastRoutes(routes map[string][]int64) {
+	operator.delivery.SetBroadcastRoutes(routes)
+}
+
+func (operator *Operator) NotifyError(err error) {
+	operator.notifier.NotifyError(err)
+	operator.delivery.Broadcast(delivery.CATEGORY_ERROR, delivery.Message{
+		Text: i18n.Translate(i18n.DefaultLanguage, i18n.TEXT_ABOUT_ERROR, err.Error()),
+	})
+}
 
-	err := operator.transport.SendMessage(TEMP_RECIPIENT, text)
+func (operator *Operator) getSignal(event requester.EventWithOdds) delivery.Signal {
+	odds := event.ResultEventWithOdds.Odds.Odds91_1[0]
+	favoriteOdd := odds.HomeOd
+	if event.Favorite == constants.FAVORITE_IS_AWAY {
+		favoriteOdd = odds.AwayOd
+	}
+
+	liveOdd, err := convertStringToFloat(favoriteOdd)
 	if err != nil {
-		return err
+		log.Errorf(err, "unable to parse favorite odd, event_id: %s", event.EventID)
 	}
 
-	return nil
+	return delivery.Signal{
+		EventID:     event.EventID,
+		LeagueID:    event.League.ID,
+		CountryCode: getCountryCode(event, operator.getLeagues()[event.League.ID]),
+		LiveOdd:     liveOdd,
+	}
 }
 
 func (operator *Operator) CreateRoutinesForHandleLiveEvents(events []requester.EventWithOdds) error {
@@ -161,19 +265,28 @@ func (operator *Operator) CreateRoutinesForHandleLiveEvents(events []requester.E
 	}
 
 	log.Info("creating routines for each event")
-	timeNow, err := tools.GetCurrentMoscowTime()
+	timeNow, err := tools.GetCurrentTime()
 	if err != nil {
 		return karma.Format(
 			err,
-			"unable to get moscow time for check that event ready to start in go-routine",
+			"unable to get current time for check that event ready to start in go-routine",
 		)
 	}
 
+	operator.routinesMutex.Lock()
+	defer operator.routinesMutex.Unlock()
+
 	for _, event := range events {
-		if event.EventStartTime.After(timeNow) ||
-			event.EventStartTime.Before(event.EventStartTime.Add(MONITORING_LIVE_EVENT_TIME_DELAY)) {
+		if isEventInMonitoringHorizon(event, timeNow, operator.config.Discovery.MonitoringHorizon) {
 			if !operator.IsRoutineCacheContainsEvent(event.EventID) {
-				go operator.routineStartHandleLiveOdds(event)
+				event := event
+				ctx := operator.newEventContext(event.EventID)
+				operator.startEventRoutine(ctx, func() {
+					err := operator.routineStartHandleLiveOdds(ctx, event)
+					if err != nil {
+						log.Error(err)
+					}
+				})
 				eventForCache := []requester.EventWithOdds{event}
 				operator.AddEventsIDsAboutCreatedRoutines(eventForCache)
 			} else {
@@ -185,67 +298,112 @@ func (operator *Operator) CreateRoutinesForHandleLiveEvents(events []requester.E
 	return nil
 }
 
-func (operator *Operator) routineFinalHandleLiveOdds(event requester.EventWithOdds) {
-	liveEvent, secondSetIsFinished := operator.createHandlerFinalOdds(event)
+func (operator *Operator) routineFinalHandleLiveOdds(
+	ctx context.Context,
+	event requester.EventWithOdds,
+	messages []delivery.SentMessage,
+) {
+	defer operator.deleteLiveEvent(event.EventID)
+
+	updater := operator.newSignalUpdater(event, messages)
+	liveEvent, secondSetIsFinished := operator.createHandlerFinalOdds(ctx, event, updater)
 	if secondSetIsFinished {
 		setData := liveEvent.ResultEventWithOdds.Odds.Odds91_1[0].SS
 		winner := getWinnerInSecondSet(setData)
 		log.Infof(nil, "final set data: %s", setData)
 		log.Infof(nil, "winner: %s", winner)
+		updater.finish(*liveEvent, winner)
 		//write to database result of second set
 		err := operator.database.UpdateLiveEventsResultsScoreAndWinnerFields(event.EventID, setData, winner)
 		if err != nil {
 			log.Errorf(err, "unable to update live events results score and winner fields")
+		} else {
+			operator.SettleBets()
 		}
 	}
 }
 
-func (operator *Operator) routineStartHandleLiveOdds(event requester.EventWithOdds) error {
+func (operator *Operator) routineStartHandleLiveOdds(ctx context.Context, event requester.EventWithOdds) error {
 	log.Infof(nil, "creating routine for event_id: %s", event.EventID)
-	timeNow, err := tools.GetCurrentMoscowTime()
+	timeNow, err := tools.GetCurrentTime()
 	if err != nil {
 		return karma.Format(
 			err,
-			"unable to get moscow time",
+			"unable to get current time",
 		)
 	}
 
 	if timeNow.Before(event.EventStartTime) {
 		diff := event.EventStartTime.Sub(timeNow)
 		log.Warningf(nil, "waiting time for routine: %s ", diff.String())
-		time.Sleep(diff)
+		operator.sleep(ctx, diff)
+	}
+
+	if operator.isStopped(ctx) {
+		log.Infof(nil, "routine stopped by shutdown before event start, event_id: %s", event.EventID)
+		return nil
+	}
+
+	liveEvent, liveEventResult := operator.createHandlerLiveOdds(ctx, event)
+	if liveEventResult && operator.isEventExcludedByRules(event) {
+		log.Infof(nil, "event is skipped or banned, signal is not sent, event_id: %s", event.EventID)
+		operator.deleteLiveEvent(event.EventID)
+		return nil
 	}
 
-	liveEvent, liveEventResult := operator.createHandlerLiveOdds(event)
 	if liveEventResult {
 		// liveEvent.EventID = event.EventID
 		// liveEvent.League.Name = event.League.Name
 		// liveEvent.HomeCommandName = event.HomeCommandName
 		// liveEvent.AwayCommandName = event.AwayCommandName
 		// liveEvent.Favorite = event.Favorite
-		err := operator.SendMessageAboutWinnerToTelegram(*liveEvent)
+		var messages []delivery.SentMessage
+		sent, err := operator.database.SendSignalOnce(
+			liveEvent.EventID,
+			constants.STRATEGY_SECOND_SET,
+			constants.SIGNAL_TYPE_BET,
+			func(tx database.DatabaseInterface) error {
+				var err error
+				messages, err = operator.SendMessageAboutWinnerToTelegram(tx, *liveEvent)
+				return err
+			},
+		)
 		if err != nil {
 			log.Error(err)
+		} else if !sent {
+			log.Infof(nil, "signal for live event has been sent before, event_id: %s", liveEvent.EventID)
 		} else {
 			log.Infof(nil, "live event sent to telegram, event_id: %s", liveEvent.EventID)
-		}
+			operator.notifySignal(*liveEvent)
 
-		err = operator.database.InsertLiveEventResult(*liveEvent)
-		if err != nil {
-			log.Error(err)
-		} else {
-			log.Infof(nil, "live event inserted to database, event_id: %s", liveEvent.EventID)
+			err = operator.database.InsertLiveEventResult(*liveEvent)
+			if err != nil {
+				log.Error(err)
+			} else {
+				log.Infof(nil, "live event inserted to database, event_id: %s", liveEvent.EventID)
+			}
+
+			operator.startEventRoutine(ctx, func() {
+				operator.routineWatchSignal(ctx, *liveEvent, messages)
+			})
 		}
 
-		go operator.routineFinalHandleLiveOdds(*liveEvent)
+		operator.startEventRoutine(ctx, func() {
+			operator.routineFinalHandleLiveOdds(ctx, *liveEvent, messages)
+		})
+	} else {
+		operator.deleteLiveEvent(event.EventID)
 	}
 
 	log.Infof(nil, "routine successfully finished for event_id: %s", event.EventID)
 	return nil
 }
 
-func (operator *Operator) createHandlerLiveOdds(event requester.EventWithOdds) (*requester.EventWithOdds, bool) {
-	startTime, err := tools.GetCurrentMoscowTime()
+func (operator *Operator) createHandlerLiveOdds(
+	ctx context.Context,
+	event requester.EventWithOdds,
+) (*requester.EventWithOdds, bool) {
+	startTime, err := tools.GetCurrentTime()
 	if err != nil {
 		log.Error(err)
 		return nil, false
@@ -254,10 +412,15 @@ func (operator *Operator) createHandlerLiveOdds(event requester.EventWithOdds) (
 	log.Infof(nil, "routine for receiving winner started, start_time: %s, event: %v", startTime.String(), event)
 
 	for {
-		timeNow, err := tools.GetCurrentMoscowTime()
+		if operator.isStopped(ctx) {
+			log.Infof(nil, "routine for receiving winner stopped by shutdown, event_id: %s", event.EventID)
+			return nil, false
+		}
+
+		timeNow, err := tools.GetCurrentTime()
 		if err != nil {
 			log.Error(err)
-			time.Sleep(REQUEST_FREQUENCY_DELAY)
+			operator.sleep(ctx, REQUEST_FREQUENCY_DELAY)
 			continue
 		}
 
@@ -269,19 +432,24 @@ func (operator *Operator) createHandlerLiveOdds(event requester.EventWithOdds) (
 		liveEvent, err := operator.requester.GetLiveEventByID(event.EventID)
 		if err != nil {
 			log.Errorf(err, "unable to get live event data by event_id: %s", event.EventID)
-			time.Sleep(REQUEST_FREQUENCY_DELAY)
+			operator.sleep(ctx, REQUEST_FREQUENCY_DELAY)
 			continue
 		}
 
 		liveEvent.EventID = event.EventID
-		liveEvent.League.Name = event.League.Name
+		liveEvent.League = event.League
 		liveEvent.HomeCommandName = event.HomeCommandName
 		liveEvent.AwayCommandName = event.AwayCommandName
+		liveEvent.HomeCommandCC = event.HomeCommandCC
+		liveEvent.EventStartTime = event.EventStartTime
+		liveEvent.HomeOdd = event.HomeOdd
+		liveEvent.AwayOdd = event.AwayOdd
 		liveEvent.Favorite = event.Favorite
+		operator.setLiveEvent(*liveEvent)
 
 		log.Infof(nil, "handle live odds for event_id: %s", liveEvent.EventID)
 
-		liveEventResult := operator.getWinnerOfSecondSet(*liveEvent)
+		liveEventResult := operator.getWinnerOfSecondSet(ctx, *liveEvent)
 		switch liveEventResult {
 		case CODE_FINISHED_WITH_ERROR:
 			return liveEvent, false
@@ -290,24 +458,33 @@ func (operator *Operator) createHandlerLiveOdds(event requester.EventWithOdds) (
 		case CODE_IS_WINNER_TRUE:
 			return liveEvent, true
 		case "":
-			time.Sleep(REQUEST_FREQUENCY_DELAY)
+			operator.sleep(ctx, REQUEST_FREQUENCY_DELAY)
 			continue
 		}
 	}
 }
 
-func (operator *Operator) createHandlerFinalOdds(event requester.EventWithOdds) (*requester.EventWithOdds, bool) {
-	startTime, err := tools.GetCurrentMoscowTime()
+func (operator *Operator) createHandlerFinalOdds(
+	ctx context.Context,
+	event requester.EventWithOdds,
+	updater *signalUpdater,
+) (*requester.EventWithOdds, bool) {
+	startTime, err := tools.GetCurrentTime()
 	if err != nil {
 		log.Error(err)
 	}
 
 	log.Infof(nil, "routine for second final set started, start_time: %s, event: %v", startTime.String(), event)
 	for {
-		timeNow, err := tools.GetCurrentMoscowTime()
+		if operator.isStopped(ctx) {
+			log.Infof(nil, "routine for handle final odds stopped by shutdown, event_id: %s", event.EventID)
+			return nil, false
+		}
+
+		timeNow, err := tools.GetCurrentTime()
 		if err != nil {
 			log.Error(err)
-			time.Sleep(REQUEST_FREQUENCY_DELAY)
+			operator.sleep(ctx, REQUEST_FREQUENCY_DELAY)
 			continue
 		}
 
@@ -320,23 +497,29 @@ func (operator *Operator) createHandlerFinalOdds(event requester.EventWithOdds)
 		liveEvent, err := operator.requester.GetLiveEventByID(event.EventID)
 		if err != nil {
 			log.Errorf(err, "unable to get live event data by event_id: %s", event.EventID)
-			time.Sleep(REQUEST_FREQUENCY_DELAY)
+			operator.sleep(ctx, REQUEST_FREQUENCY_DELAY)
 			continue
 		}
 
 		log.Infof(nil, "handle final live odds for event_id: %s", liveEvent.EventID)
 		liveEvent.EventID = event.EventID
-		liveEvent.League.Name = event.League.Name
+		liveEvent.League = event.League
 		liveEvent.HomeCommandName = event.HomeCommandName
 		liveEvent.AwayCommandName = event.AwayCommandName
+		liveEvent.HomeCommandCC = event.HomeCommandCC
+		liveEvent.HomeOdd = event.HomeOdd
+		liveEvent.AwayOdd = event.AwayOdd
 		liveEvent.Favorite = event.Favorite
+		liveEvent.EventStartTime = event.EventStartTime
+		operator.setLiveEvent(*liveEvent)
+		updater.update(*liveEvent)
 
 		liveEventResult := operator.getFinalResultsOfSecondSet(*liveEvent)
 		switch liveEventResult {
 		case CODE_NUMBER_OF_SET_3:
 			return liveEvent, true
 		case "":
-			time.Sleep(REQUEST_FREQUENCY_DELAY)
+			operator.sleep(ctx, REQUEST_FREQUENCY_DELAY)
 			continue
 		}
 	}
@@ -356,11 +539,14 @@ func (operator *Operator) getFinalResultsOfSecondSet(liveEvent requester.EventWi
 	return ""
 }
 
-func (operator *Operator) getWinnerOfSecondSet(liveEvent requester.EventWithOdds) string {
+func (operator *Operator) getWinnerOfSecondSet(
+	ctx context.Context,
+	liveEvent requester.EventWithOdds,
+) string {
 	liveEventResult, numberOfSet, err := handleLiveEventOdds(liveEvent)
 	if err != nil {
 		log.Errorf(err, "unable to handle live event and receive winner event_id: %s", liveEvent.EventID)
-		time.Sleep(2 * REQUEST_FREQUENCY_DELAY)
+		operator.sleep(ctx, 2*REQUEST_FREQUENCY_DELAY)
 		return ""
 	}
 
@@ -3,13 +3,15 @@ package operator
 import (
 	"encoding/json"
 	"io/ioutil"
-	"sort"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/alecthomas/assert"
+	"github.com/daniilsolovey/BetBotGo/internal/config"
 	"github.com/daniilsolovey/BetBotGo/internal/constants"
+	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/daniilsolovey/BetBotGo/internal/i18n"
 	"github.com/daniilsolovey/BetBotGo/internal/requester"
 	"github.com/daniilsolovey/BetBotGo/internal/tools"
 	"github.com/reconquest/karma-go"
@@ -21,6 +23,7 @@ const (
 	TEST_EVENT_1_PATH = "../../testdata/1_event.json"
 	TEST_EVENT_2_PATH = "../../testdata/2_event.json"
 	TEST_EVENT_3_PATH = "../../testdata/3_event.json"
+	TEST_LEAGUE_ID    = "1111"
 )
 
 type TestRequester struct {
@@ -101,11 +104,11 @@ func (testRequester *TestRequester) GetEventOddsByEventIDs(events *requester.Upc
 	}
 
 	event1.EventStartTime = events.Results[0].HumanTime
-	event1.League.Name = constants.COUNTRIES
+	event1.League.ID = TEST_LEAGUE_ID
 	event2.EventStartTime = events.Results[1].HumanTime
-	event2.League.Name = constants.COUNTRIES
+	event2.League.ID = TEST_LEAGUE_ID
 	event3.EventStartTime = events.Results[2].HumanTime
-	event3.League.Name = constants.COUNTRIES
+	event3.League.ID = TEST_LEAGUE_ID
 	event1.EventID = events.Results[0].ID
 	event2.EventID = events.Results[1].ID
 	event3.EventID = events.Results[2].ID
@@ -120,15 +123,18 @@ func (testRequester *TestRequester) GetLiveEventByID(eventID string) (*requester
 		log.Fatal(err)
 	}
 
-	upcomingEventsForToday, err := getUpcomingEventsForToday(upcomingEvents)
+	upcomingEventsInWindow, err := getUpcomingEventsInWindow(
+		upcomingEvents,
+		tools.TimeNow().Add(24*time.Hour),
+	)
 	if err != nil {
 		return nil, karma.Format(
 			err,
-			"unable to get upcoming events for today",
+			"unable to get upcoming events in window",
 		)
 	}
 
-	events, err := testRequester.GetEventOddsByEventIDs(upcomingEventsForToday)
+	events, err := testRequester.GetEventOddsByEventIDs(upcomingEventsInWindow)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -151,7 +157,14 @@ func TestOperator_GetEvents_ReturnWinnerResult(
 
 	requester := createRequester()
 
-	operator := NewOperator(nil, nil, requester, nil)
+	config := &config.Config{
+		Discovery: config.Discovery{Lookahead: 24 * time.Hour},
+	}
+
+	operator := NewOperator(config, nil, requester, nil, nil)
+	operator.leagues = map[string]database.League{
+		TEST_LEAGUE_ID: {ID: TEST_LEAGUE_ID, CC: "it", Gender: constants.GENDER_MEN, Tier: 1},
+	}
 
 	events, err := operator.GetEvents()
 	if err != nil {
@@ -466,202 +479,570 @@ func TestOperator_sortEventsByOdds_MissEvent(
 func TestOperator_sortEventsByCountries_ReturnExpectedListWithCountries(
 	t *testing.T,
 ) {
-	countries := strings.Split(constants.COUNTRIES, ",")
-	sort.Strings(countries)
-
-	testName1 := "Match Italy Men"
-	event1 := requester.EventWithOdds{
-		EventID: "1111",
+	leagues := map[string]database.League{
+		"1": {ID: "1", CC: "it", Gender: constants.GENDER_MEN, Tier: 1},
+		"2": {ID: "2", CC: "ru", Gender: constants.GENDER_WOMEN, Tier: 1},
+		"3": {ID: "3", CC: "kz", Gender: constants.GENDER_MEN, Tier: 1},
+		"4": {ID: "4", CC: "ua", Gender: constants.GENDER_WOMEN, Tier: 1},
+		"5": {ID: "5", CC: "ua", Gender: constants.GENDER_MEN, Tier: 1},
+		"6": {ID: "6", CC: "it", Gender: constants.GENDER_MEN, Tier: 1, Youth: true},
+		"7": {ID: "7", CC: "", Gender: constants.GENDER_MEN, Tier: 1},
 	}
-	event1.League.Name = testName1
 
-	testName2 := "League Russia"
-	event2 := requester.EventWithOdds{
-		EventID: "2222",
+	var events []requester.EventWithOdds
+	for _, id := range []string{"1", "2", "3", "4", "5", "6", "7", "8"} {
+		event := requester.EventWithOdds{EventID: id}
+		event.League.ID = id
+		events = append(events, event)
 	}
-	event2.League.Name = testName2
 
-	testName3 := "League Kazakhstan"
-	event3 := requester.EventWithOdds{
-		EventID: "3333",
-	}
-	event3.League.Name = testName3
+	events[6].HomeCommandCC = "pl"
 
-	testName4 := "League Ukraine Women"
-	event4 := requester.EventWithOdds{
-		EventID: "4444",
-	}
-	event4.League.Name = testName4
+	sortedEventsByCountries := handleEventsByCountries(events, leagues)
 
-	testName5 := "League Ukraine Men"
-	event5 := requester.EventWithOdds{
-		EventID: "5555",
+	var testResult []string
+	for _, event := range sortedEventsByCountries {
+		testResult = append(testResult, event.EventID)
 	}
-	event5.League.Name = testName5
 
-	testName6 := "Spain Women"
-	event6 := requester.EventWithOdds{
-		EventID: "6666",
-	}
-	event6.League.Name = testName6
+	assert.Equal(t, []string{"1", "2", "5", "7"}, testResult)
+}
 
-	testName7 := "Spain"
-	event7 := requester.EventWithOdds{
-		EventID: "7777",
+func TestOperator_sortEventsByLeagues_ReturnExpectedListWithLeagues(
+	t *testing.T,
+) {
+	leagues := map[string]database.League{
+		"1": {ID: "1", CC: "it", Gender: constants.GENDER_MEN, Tier: 1},
+		"2": {ID: "2", CC: "it", Gender: constants.GENDER_MEN, Tier: 2},
+		"3": {ID: "3", CC: "it", Gender: constants.GENDER_MEN, Tier: 3},
+		"4": {ID: "4", CC: "ru", Gender: constants.GENDER_WOMEN, Tier: 1},
+		"5": {ID: "5", CC: "ru", Gender: constants.GENDER_WOMEN, Tier: 2},
+		"6": {ID: "6", CC: "kz", Gender: constants.GENDER_MEN, Tier: 0},
 	}
-	event7.League.Name = testName7
 
 	var events []requester.EventWithOdds
-	events = append(events, event1, event2, event3, event4, event5, event6, event7)
-	sortedEventsByLeagues := handleEventsByCountries(events)
-	assert.NotEmpty(t, sortedEventsByLeagues)
+	for _, id := range []string{"1", "2", "3", "4", "5", "6", "7"} {
+		event := requester.EventWithOdds{EventID: id}
+		event.League.ID = id
+		events = append(events, event)
+	}
+
+	sortedEventsByLeagues := handleEventsByLeagues(events, leagues)
 
 	var testResult []string
 	for _, event := range sortedEventsByLeagues {
-		for _, country := range countries {
-			if strings.Contains(event.League.Name, country) {
-				testResult = append(testResult, event.League.Name)
-			}
-		}
+		testResult = append(testResult, event.EventID)
 	}
 
-	if !tools.Find(testResult, testName1) {
-		assert.Fail(t, "should contain this country:", testName1)
-	}
+	assert.Equal(t, []string{"1", "2", "4"}, testResult)
+}
 
-	if !tools.Find(testResult, testName2) {
-		assert.Fail(t, "should contain this country:", testName2)
-	}
+func TestOperator_newLeagueFromEvent_ReturnGuessedAttributes(
+	t *testing.T,
+) {
+	event := requester.EventWithOdds{}
+	event.League.ID = "1"
+	event.League.Name = "Italy A2 Women"
+	event.League.CC = "IT"
+
+	league := newLeagueFromEvent(event)
+	assert.Equal(t, "it", league.CC)
+	assert.Equal(t, constants.GENDER_WOMEN, league.Gender)
+	assert.Equal(t, 2, league.Tier)
+	assert.Equal(t, false, league.Youth)
+
+	event.League.Name = "Russia Youth League"
+	league = newLeagueFromEvent(event)
+	assert.Equal(t, constants.GENDER_MEN, league.Gender)
+	assert.Equal(t, 0, league.Tier)
+	assert.Equal(t, true, league.Youth)
+}
 
-	if tools.Find(testResult, testName3) {
-		assert.Fail(t, "should not contain this country:", testName3)
-	}
+func TestOperator_getOddsDrift_ReturnDriftOrFlip(
+	t *testing.T,
+) {
+	storedEvent := requester.EventWithOdds{
+		EventID:  "1111",
+		Favorite: "home",
+		HomeOdd:  1.2,
+		AwayOdd:  4,
+	}
+
+	drift := getOddsDrift(storedEvent, 1.25, 3.8, 0.15)
+	assert.Nil(t, drift)
+
+	drift = getOddsDrift(storedEvent, 1.4, 2.9, 0.15)
+	assert.NotNil(t, drift)
+	assert.Equal(t, constants.SIGNAL_TYPE_DRIFT, drift.Kind)
+	assert.Equal(t, 1.2, drift.OpeningHomeOdd)
+	assert.Equal(t, 1.4, drift.CurrentHomeOdd)
+
+	drift = getOddsDrift(storedEvent, 2.1, 1.7, 0.15)
+	assert.NotNil(t, drift)
+	assert.Equal(t, constants.SIGNAL_TYPE_FLIP, drift.Kind)
+	assert.Equal(t, "home", drift.OpeningFavorite)
+	assert.Equal(t, "away", drift.CurrentFavorite)
+}
 
-	if tools.Find(testResult, testName4) {
-		assert.Fail(t, "should not contain this country:", testName4)
+func TestOperator_getSignalInvalidationReason_ReturnReason(
+	t *testing.T,
+) {
+	event := requester.EventWithOdds{
+		EventID:             "1111",
+		Favorite:            "home",
+		ResultEventWithOdds: requester.ResultEventWithOdds{Odds: requester.Odds{Odds91_1: []requester.OddsNumber{requester.OddsNumber{}}}},
 	}
 
-	if !tools.Find(testResult, testName5) {
-		assert.Fail(t, "should contain this country: ", testName5)
-	}
+	event.ResultEventWithOdds.Odds.Odds91_1[0].HomeOd = "1.8"
+	event.ResultEventWithOdds.Odds.Odds91_1[0].AwayOd = "1.9"
+	event.ResultEventWithOdds.Odds.Odds91_1[0].SS = "21-25,3-1"
 
-	if tools.Find(testResult, testName6) {
-		assert.Fail(t, "should not contain this country:", testName6)
-	}
+	reason, finished := getSignalInvalidationReason(event)
+	assert.Equal(t, "", reason)
+	assert.Equal(t, false, finished)
 
-	if !tools.Find(testResult, testName7) {
-		assert.Fail(t, "should contain this country: ", testName7)
-	}
+	event.ResultEventWithOdds.Odds.Odds91_1[0].HomeOd = "1.4"
+	reason, finished = getSignalInvalidationReason(event)
+	assert.Equal(t, REASON_ODD_BELOW_CUTOFF, reason)
+	assert.Equal(t, false, finished)
+
+	event.ResultEventWithOdds.Odds.Odds91_1[0].HomeOd = "-"
+	reason, finished = getSignalInvalidationReason(event)
+	assert.Equal(t, REASON_MARKET_SUSPENDED, reason)
+	assert.Equal(t, false, finished)
+
+	event.ResultEventWithOdds.Odds.Odds91_1[0].SS = "21-25,25-20,0-0"
+	reason, finished = getSignalInvalidationReason(event)
+	assert.Equal(t, "", reason)
+	assert.Equal(t, true, finished)
 
+	event.ResultEventWithOdds.Odds.Odds91_1 = nil
+	reason, finished = getSignalInvalidationReason(event)
+	assert.Equal(t, REASON_MATCH_ABANDONED, reason)
+	assert.Equal(t, false, finished)
 }
 
-func TestOperator_sortEventsByLeagues_ReturnExpectedListWithCountries(
+func TestOperator_signalWatch_AbandonAfterEmptyPollsInRow(
 	t *testing.T,
 ) {
-	leagues := strings.Split(constants.LEAGUES, ",")
-	sort.Strings(leagues)
+	getEvent := func(homeOdd string) requester.EventWithOdds {
+		event := requester.EventWithOdds{EventID: "1111", Favorite: "home"}
+		if homeOdd != "" {
+			event.ResultEventWithOdds.Odds.Odds91_1 = []requester.OddsNumber{
+				{HomeOd: homeOdd, AwayOd: "2.1", SS: "21-25,3-1"},
+			}
+		}
 
-	testName1 := "Italy Cup Men"
-	event1 := requester.EventWithOdds{
-		EventID: "1111",
+		return event
 	}
-	event1.League.Name = testName1
 
-	testName2 := "Russia Super League Men"
-	event2 := requester.EventWithOdds{
-		EventID: "2222",
+	watch := &signalWatch{signal: getEvent("1.8"), threshold: 0.2}
+	for i := 0; i < MATCH_ABANDONED_EMPTY_POLLS-1; i++ {
+		reason, update, finished := watch.check(getEvent(""))
+		assert.Equal(t, "", reason)
+		assert.False(t, update)
+		assert.False(t, finished)
 	}
-	event2.League.Name = testName2
 
-	testName3 := "League Kazakhstan"
-	event3 := requester.EventWithOdds{
-		EventID: "3333",
-	}
-	event3.League.Name = testName3
+	reason, update, _ := watch.check(getEvent("1.9"))
+	assert.Equal(t, "", reason)
+	assert.False(t, update)
 
-	testName4 := "France Pro A"
-	event4 := requester.EventWithOdds{
-		EventID: "4444",
+	for i := 0; i < MATCH_ABANDONED_EMPTY_POLLS-1; i++ {
+		reason, _, _ = watch.check(getEvent(""))
+		assert.Equal(t, "", reason)
 	}
-	event4.League.Name = testName4
 
-	testName5 := "Turkey Cup"
-	event5 := requester.EventWithOdds{
-		EventID: "5555",
-	}
-	event5.League.Name = testName5
+	reason, _, _ = watch.check(getEvent(""))
+	assert.Equal(t, REASON_MATCH_ABANDONED, reason)
+}
 
-	testName6 := "Russia Cup"
-	event6 := requester.EventWithOdds{
-		EventID: "6666",
-	}
-	event6.League.Name = testName6
+func TestOperator_signalWatch_UpdateWhenFavoriteOddMoves(
+	t *testing.T,
+) {
+	signal := requester.EventWithOdds{EventID: "1111", Favorite: "away"}
+	signal.ResultEventWithOdds.Odds.Odds91_1 = []requester.OddsNumber{
+		{HomeOd: "2.1", AwayOd: "1.8", SS: "21-25,3-1"},
+	}
+
+	event := signal
+	event.ResultEventWithOdds.Odds.Odds91_1 = []requester.OddsNumber{
+		{HomeOd: "2.3", AwayOd: "1.9", SS: "21-25,5-3"},
+	}
+
+	watch := &signalWatch{signal: signal, threshold: 0.2}
+	reason, update, _ := watch.check(event)
+	assert.Equal(t, "", reason)
+	assert.False(t, update)
+
+	event.ResultEventWithOdds.Odds.Odds91_1[0].AwayOd = "2.05"
+	reason, update, _ = watch.check(event)
+	assert.Equal(t, "", reason)
+	assert.True(t, update)
+	assert.Equal(
+		t,
+		"UPDATE! Favorite odd has changed\n"+
+			"  event_id: 1111\n"+
+			"  favorite odd: 1.80 -> 2.05\n"+
+			"  home odd: 2.30\n"+
+			"  away odd: 2.05\n"+
+			"  score: 21-25,5-3\n",
+		getTextAboutSignalUpdate(signal, event, i18n.LANGUAGE_EN),
+	)
+
+	watch.updated = true
+	_, update, _ = watch.check(event)
+	assert.False(t, update)
+}
 
-	testName7 := "Russia Cup Women"
-	event7 := requester.EventWithOdds{
-		EventID: "7777",
+func TestOperator_getUpcomingEventsInWindow_ReturnEventsBeforeWindowEnd(
+	t *testing.T,
+) {
+	tools.TimeNow = func() time.Time {
+		return time.Date(2021, 9, 03, 16, 0, 0, 0, time.UTC)
 	}
-	event7.League.Name = testName7
 
-	testName8 := "Turkey Efeler League Men"
-	event8 := requester.EventWithOdds{
-		EventID: "8888",
+	upcomingEvents := &requester.UpcomingEvents{
+		Results: []requester.Result{
+			{ID: "1", Time: "1630684800"}, // 2021-09-03 16:00 UTC
+			{ID: "2", Time: "1630686600"}, // 2021-09-03 16:30 UTC
+			{ID: "3", Time: "1630706400"}, // 2021-09-03 22:00 UTC
+			{ID: "4", Time: "1630742400"}, // 2021-09-04 08:00 UTC
+		},
 	}
-	event8.League.Name = testName8
 
-	testName9 := "Turkey Efeler League Women"
-	event9 := requester.EventWithOdds{
-		EventID: "9999",
+	getIDs := func(windowEnd time.Time) []string {
+		events, err := getUpcomingEventsInWindow(upcomingEvents, windowEnd)
+		assert.NoError(t, err)
+
+		var ids []string
+		for _, event := range events.Results {
+			ids = append(ids, event.ID)
+		}
+
+		return ids
 	}
-	event9.League.Name = testName9
 
-	var events []requester.EventWithOdds
-	events = append(events, event1, event2, event3, event4, event5, event6, event7, event8, event9)
-	sortedEventsByLeagues := handleEventsByLeagues(events)
-	assert.NotEmpty(t, sortedEventsByLeagues)
+	assert.Equal(t, []string{"2"}, getIDs(tools.TimeNow().Add(3*time.Hour)))
+	assert.Equal(t, []string{"2", "3", "4"}, getIDs(tools.TimeNow().Add(24*time.Hour)))
+}
 
-	var testResult []string
-	for _, event := range sortedEventsByLeagues {
-		for _, country := range leagues {
-			if strings.Contains(event.League.Name, country) {
-				testResult = append(testResult, event.League.Name)
-			}
+func TestOperator_getDiscoveryWindowEnd_ReturnLookaheadOrUntilHour(
+	t *testing.T,
+) {
+	defer func() {
+		tools.Timezone = tools.DEFAULT_TIMEZONE
+	}()
+
+	location, err := tools.GetLocation()
+	assert.NoError(t, err)
+
+	timeNow := time.Date(2021, 9, 03, 22, 30, 0, 0, location)
+
+	windowEnd := getDiscoveryWindowEnd(timeNow, config.Discovery{Lookahead: 12 * time.Hour})
+	assert.Equal(t, time.Date(2021, 9, 04, 10, 30, 0, 0, location), windowEnd)
+
+	windowEnd = getDiscoveryWindowEnd(timeNow, config.Discovery{Lookahead: 12 * time.Hour, UntilHour: 6})
+	assert.Equal(t, time.Date(2021, 9, 04, 6, 0, 0, 0, location), windowEnd)
+
+	tools.Timezone = "America/New_York"
+	location, err = tools.GetLocation()
+	assert.NoError(t, err)
+
+	timeNow = time.Date(2021, 9, 03, 22, 30, 0, 0, location)
+	windowEnd = getDiscoveryWindowEnd(timeNow, config.Discovery{UntilHour: 6})
+	assert.Equal(t, time.Date(2021, 9, 04, 6, 0, 0, 0, location), windowEnd)
+}
+
+func TestOperator_isEventInMonitoringHorizon_ReturnTrueForNearEvents(
+	t *testing.T,
+) {
+	timeNow := time.Date(2021, 9, 03, 16, 0, 0, 0, time.UTC)
+	event := requester.EventWithOdds{EventID: "1111"}
+
+	event.EventStartTime = timeNow.Add(2 * time.Hour)
+	assert.Equal(t, true, isEventInMonitoringHorizon(event, timeNow, 6*time.Hour))
+
+	event.EventStartTime = timeNow.Add(-10 * time.Minute)
+	assert.Equal(t, true, isEventInMonitoringHorizon(event, timeNow, 6*time.Hour))
+
+	event.EventStartTime = timeNow.Add(-time.Hour)
+	assert.Equal(t, false, isEventInMonitoringHorizon(event, timeNow, 6*time.Hour))
+
+	event.EventStartTime = timeNow.Add(8 * time.Hour)
+	assert.Equal(t, false, isEventInMonitoringHorizon(event, timeNow, 6*time.Hour))
+}
+
+func TestOperator_handleEventsByRules_ExcludeBannedAndAddMonitored(
+	t *testing.T,
+) {
+	newEvent := func(eventID, leagueID, home, away, homeOdd, awayOdd string) requester.EventWithOdds {
+		event := requester.EventWithOdds{
+			EventID:             eventID,
+			HomeCommandName:     home,
+			AwayCommandName:     away,
+			ResultEventWithOdds: requester.ResultEventWithOdds{Odds: requester.Odds{Odds91_1: []requester.OddsNumber{requester.OddsNumber{}}}},
 		}
+		event.League.ID = leagueID
+		event.ResultEventWithOdds.Odds.Odds91_1[0].HomeOd = homeOdd
+		event.ResultEventWithOdds.Odds.Odds91_1[0].AwayOd = awayOdd
+		return event
 	}
 
-	if !tools.Find(testResult, testName1) {
-		assert.Fail(t, "should contain this country:", testName1)
+	eventsWithOdds := []requester.EventWithOdds{
+		newEvent("1", "10", "Modena", "Verona", "1.2", "4.0"),
+		newEvent("2", "10", "Trento", "Monza", "1.1", "6.0"),
+		newEvent("3", "20", "Zenit", "Dinamo", "1.25", "3.5"),
+		newEvent("4", "30", "Olsztyn", "Katowice", "1.8", "1.95"),
+		newEvent("5", "10", "Perugia", "Piacenza", "1.15", "5.0"),
 	}
-
-	if !tools.Find(testResult, testName2) {
-		assert.Fail(t, "should contain this country:", testName2)
+	selectedEvents := []requester.EventWithOdds{
+		eventsWithOdds[0], eventsWithOdds[1], eventsWithOdds[2], eventsWithOdds[4],
 	}
 
-	if tools.Find(testResult, testName3) {
-		assert.Fail(t, "should not contain this country:", testName3)
+	rules := []database.EventRule{
+		{Kind: constants.RULE_BAN_TEAM, Value: "monza"},
+		{Kind: constants.RULE_BAN_LEAGUE, Value: "20"},
+		{Kind: constants.RULE_SKIP_EVENT, Value: "5"},
+		{Kind: constants.RULE_MONITOR_EVENT, Value: "4"},
 	}
 
-	if !tools.Find(testResult, testName4) {
-		assert.Fail(t, "should contain this country:", testName4)
-	}
+	result := handleEventsByRules(eventsWithOdds, selectedEvents, rules)
+	assert.Equal(t, 2, len(result))
+	assert.Equal(t, "1", result[0].EventID)
+	assert.Equal(t, "4", result[1].EventID)
+	assert.Equal(t, constants.FAVORITE_IS_HOME, result[1].Favorite)
+	assert.Equal(t, 1.8, result[1].HomeOdd)
+	assert.Equal(t, 1.95, result[1].AwayOdd)
+}
 
-	if !tools.Find(testResult, testName5) {
-		assert.Fail(t, "should contain this country: ", testName5)
-	}
+func TestOperator_parseCreateCodeArguments_ReturnDaysAndUses(
+	t *testing.T,
+) {
+	days, uses, ok := parseCreateCodeArguments("30")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 30, days)
+	assert.Equal(t, 1, uses)
 
-	if !tools.Find(testResult, testName6) {
-		assert.Fail(t, "should contain this country:", testName6)
-	}
+	days, uses, ok = parseCreateCodeArguments("7 0")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 7, days)
+	assert.Equal(t, 0, uses)
 
-	if tools.Find(testResult, testName7) {
-		assert.Fail(t, "should not contain this country: ", testName7)
-	}
+	_, _, ok = parseCreateCodeArguments("0")
+	assert.Equal(t, false, ok)
+
+	_, _, ok = parseCreateCodeArguments("7 -1")
+	assert.Equal(t, false, ok)
+
+	_, _, ok = parseCreateCodeArguments("7 1 1")
+	assert.Equal(t, false, ok)
+}
+
+func TestOperator_applySetting_ChangePreferences(
+	t *testing.T,
+) {
+	preferences := database.GetDefaultPreferences(1)
+
+	preferences, err := applySetting(preferences, SETTING_COUNTRIES, "IT, pl")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"it", "pl"}, preferences.Countries)
+
+	preferences, err = applySetting(preferences, SETTING_QUIET_HOURS, "23-8")
+	assert.NoError(t, err)
+	assert.Equal(t, 23, preferences.QuietHoursFrom)
+	assert.Equal(t, 8, preferences.QuietHoursTo)
+
+	preferences, err = applySetting(preferences, SETTING_REPORTS, constants.REPORT_DAILY)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{constants.REPORT_DAILY}, preferences.Reports)
+
+	_, err = applySetting(preferences, SETTING_QUIET_HOURS, "23-25")
+	assert.Error(t, err)
+
+	_, err = applySetting(preferences, SETTING_REPORTS, "monthly")
+	assert.Error(t, err)
+
+	preferences, err = applySetting(preferences, SETTING_COUNTRIES, SETTING_VALUE_ALL)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(preferences.Countries))
+}
+
+func TestOperator_parseBetArguments_ReturnStakeAndOdd(
+	t *testing.T,
+) {
+	stake, odd, ok := parseBetArguments("100")
+	assert.True(t, ok)
+	assert.Equal(t, 100.0, stake)
+	assert.Equal(t, 0.0, odd)
+
+	stake, odd, ok = parseBetArguments(" 50,5 1,85 ")
+	assert.True(t, ok)
+	assert.Equal(t, 50.5, stake)
+	assert.Equal(t, 1.85, odd)
 
-	if !tools.Find(testResult, testName8) {
-		assert.Fail(t, "should contain this country: ", testName8)
+	_, _, ok = parseBetArguments("100 0.9")
+	assert.False(t, ok)
+
+	_, _, ok = parseBetArguments("-1")
+	assert.False(t, ok)
+
+	_, _, ok = parseBetArguments("hello")
+	assert.False(t, ok)
+}
+
+func TestOperator_getBetsSummary_SkipOpenBetsInProfit(
+	t *testing.T,
+) {
+	summary := getBetsSummary([]database.Bet{
+		{Stake: 100, Odd: 1.5, Status: database.BET_STATUS_WON, Profit: 50},
+		{Stake: 20, Odd: 1.7, Status: database.BET_STATUS_LOST, Profit: -20},
+		{Stake: 10, Odd: 1.9, Status: database.BET_STATUS_OPEN},
+	})
+
+	assert.Equal(t, BetsSummary{
+		Total:  3,
+		Won:    1,
+		Lost:   1,
+		Open:   1,
+		Staked: 120,
+		Profit: 30,
+	}, summary)
+}
+
+func TestOperator_parseStatsPeriod_ReturnPeriodEndingToday(
+	t *testing.T,
+) {
+	location := time.FixedZone("MSK", 3*60*60)
+	timeNow := time.Date(2021, 9, 5, 18, 30, 0, 0, location)
+	today := time.Date(2021, 9, 5, 0, 0, 0, 0, location)
+	tomorrow := today.AddDate(0, 0, 1)
+
+	from, to, ok := parseStatsPeriod("", timeNow)
+	assert.True(t, ok)
+	assert.Equal(t, today, from)
+	assert.Equal(t, tomorrow, to)
+
+	from, to, ok = parseStatsPeriod("Week", timeNow)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2021, 8, 30, 0, 0, 0, 0, location), from)
+	assert.Equal(t, tomorrow, to)
+
+	from, _, ok = parseStatsPeriod("month", timeNow)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2021, 8, 6, 0, 0, 0, 0, location), from)
+
+	from, to, ok = parseStatsPeriod("2021-09-01 2021-09-03", timeNow)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2021, 9, 1, 0, 0, 0, 0, location), from)
+	assert.Equal(t, time.Date(2021, 9, 4, 0, 0, 0, 0, location), to)
+
+	_, _, ok = parseStatsPeriod("2021-09-03 2021-09-01", timeNow)
+	assert.False(t, ok)
+
+	_, _, ok = parseStatsPeriod("year", timeNow)
+	assert.False(t, ok)
+}
+
+func TestOperator_GetCommands_HaveUniqueNamesAndDescriptions(
+	t *testing.T,
+) {
+	operator := &Operator{}
+
+	names := map[string]bool{}
+	for _, command := range operator.GetCommands() {
+		assert.False(t, names[command.Name], command.Name)
+		names[command.Name] = true
+
+		assert.True(t, database.IsKnownRole(command.Role), command.Name)
+
+		assert.NotEqual(t, command.Description, i18n.Translate(i18n.LANGUAGE_EN, command.Description))
+		assert.NotEqual(t, command.Description, i18n.Translate(i18n.LANGUAGE_RU, command.Description))
 	}
+}
 
-	if tools.Find(testResult, testName9) {
-		assert.Fail(t, "should not contain this country: ", testName9)
+func TestOperator_GetCommandName_WithoutBotUsername(
+	t *testing.T,
+) {
+	assert.Equal(t, "/today", getCommandName("/today"))
+	assert.Equal(t, "/stats", getCommandName("/stats@BetBot week"))
+	assert.Equal(t, "", getCommandName(""))
+}
+
+func TestOperator_getTextAboutDigest_GroupsCandidatesByLeague(
+	t *testing.T,
+) {
+	location := time.FixedZone("MSK", 3*60*60)
+	timeNow := time.Date(2021, 9, 5, 10, 0, 0, 0, location)
+
+	newEvent := func(id, league string, hour int, favorite string) requester.EventWithOdds {
+		event := requester.EventWithOdds{
+			EventID:         id,
+			EventStartTime:  time.Date(2021, 9, 5, hour, 0, 0, 0, location),
+			Favorite:        favorite,
+			HomeCommandName: "Home " + id,
+			AwayCommandName: "Away " + id,
+			HomeOdd:         1.2,
+			AwayOdd:         1.35,
+		}
+		event.League.Name = league
+
+		return event
 	}
+
+	candidates := getDigestCandidates(
+		[]requester.EventWithOdds{
+			newEvent("1", "Italy A1", 20, constants.FAVORITE_IS_HOME),
+			newEvent("2", "Poland Plus Liga", 18, constants.FAVORITE_IS_AWAY),
+			newEvent("3", "Italy A1", 19, constants.FAVORITE_IS_HOME),
+			newEvent("4", "Italy A1", 21, constants.FAVORITE_IS_HOME),
+			newEvent("5", "Italy A1", 9, constants.FAVORITE_IS_HOME),
+		},
+		[]string{"4"},
+		timeNow,
+	)
+
+	assert.Equal(
+		t,
+		"☀️ Candidates for Sep 5, 2021:\n"+
+			"\n🏆 Poland Plus Liga\n"+
+			"  18:00 Home 2 - Away 2, favorite: Away 2, odd: 1.35\n"+
+			"\n🏆 Italy A1\n"+
+			"  19:00 Home 3 - Away 3, favorite: Home 3, odd: 1.20\n"+
+			"  20:00 Home 1 - Away 1, favorite: Home 1, odd: 1.20\n",
+		getTextAboutDigest(candidates, false, timeNow, location, i18n.LANGUAGE_EN),
+	)
+
+	assert.True(t, strings.HasPrefix(
+		getTextAboutDigest(candidates, true, timeNow, location, i18n.LANGUAGE_EN),
+		"🆕 New candidates for Sep 5, 2021:\n",
+	))
+}
+
+func TestOperator_startEventRoutine_ReleaseContextAfterLastRoutine(
+	t *testing.T,
+) {
+	operator := NewOperator(&config.Config{}, nil, nil, nil, nil)
+
+	operator.routinesMutex.Lock()
+	ctx := operator.newEventContext("1")
+	operator.routinesMutex.Unlock()
+
+	finished := make(chan struct{})
+	operator.startEventRoutine(ctx, func() {
+		operator.startEventRoutine(ctx, func() {
+			<-finished
+		})
+	})
+
+	operator.routinesMutex.Lock()
+	assert.Equal(t, 1, len(operator.eventContexts))
+	operator.routinesMutex.Unlock()
+	assert.NoError(t, ctx.Err())
+
+	close(finished)
+	operator.routines.Wait()
+
+	assert.Equal(t, 0, len(operator.eventContexts))
+	assert.Error(t, ctx.Err())
 }
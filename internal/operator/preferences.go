@@ -0,0 +1,350 @@
+package operator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/constants"
+	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/daniilsolovey/BetBotGo/internal/i18n"
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+const (
+	COMMAND_SETTINGS       = "/settings"
+	BUTTON_SETTING         = "setting"
+	SETTING_DIALOG_TIMEOUT = 10 * time.Minute
+
+	SETTING_LEAGUES     = "leagues"
+	SETTING_COUNTRIES   = "countries"
+	SETTING_MIN_ODD     = "min_odd"
+	SETTING_MAX_SIGNALS = "max_signals"
+	SETTING_QUIET_HOURS = "quiet"
+	SETTING_REPORTS     = "reports"
+
+	SETTING_VALUE_ALL = "all"
+	SETTING_VALUE_OFF = "off"
+)
+
+var SETTINGS = []string{
+	SETTING_LEAGUES,
+	SETTING_COUNTRIES,
+	SETTING_MIN_ODD,
+	SETTING_MAX_SIGNALS,
+	SETTING_QUIET_HOURS,
+	SETTING_REPORTS,
+}
+
+// settingDialog waits for new value of the setting pressed under /settings.
+type settingDialog struct {
+	chatID    int64
+	name      string
+	expiresAt time.Time
+}
+
+// settingError describes invalid setting with translatable message.
+type settingError struct {
+	key       string
+	arguments []interface{}
+}
+
+func (err settingError) Error() string {
+	return i18n.Translate(i18n.LANGUAGE_EN, err.key, err.arguments...)
+}
+
+func (err settingError) Translate(language string) string {
+	return i18n.Translate(language, err.key, err.arguments...)
+}
+
+func newSettingError(key string, arguments ...interface{}) error {
+	return settingError{key: key, arguments: arguments}
+}
+
+func (operator *Operator) Settings(message *tb.Message) error {
+	var name, value string
+	arguments := strings.Fields(message.Payload)
+	if len(arguments) != 0 {
+		name = arguments[0]
+		value = strings.Join(arguments[1:], "")
+	}
+
+	return operator.changeSetting(message.Chat, name, value, operator.getLanguage(message))
+}
+
+// Setting is called by button of the setting under /settings, the next text
+// of the user in the chat becomes new value of the setting.
+func (operator *Operator) Setting(callback *tb.Callback) (string, error) {
+	if !tools.Find(SETTINGS, callback.Data) {
+		return "", nil
+	}
+
+	chat := callback.Message.Chat
+	allowed := operator.Authorize(
+		&tb.Message{Sender: callback.Sender, Chat: chat, Text: COMMAND_SETTINGS},
+		database.ROLE_SUBSCRIBER,
+	)
+	if !allowed {
+		return "", nil
+	}
+
+	operator.settingDialogsMutex.Lock()
+	operator.settingDialogs[int64(callback.Sender.ID)] = settingDialog{
+		chatID:    chat.ID,
+		name:      callback.Data,
+		expiresAt: tools.TimeNow().Add(SETTING_DIALOG_TIMEOUT),
+	}
+	operator.settingDialogsMutex.Unlock()
+
+	language := operator.getChatLanguage(chat.ID, callback.Sender.LanguageCode)
+	return "", operator.transport.SendMessage(
+		chat,
+		i18n.Translate(language, i18n.TEXT_ABOUT_SETTING_DIALOG, callback.Data),
+	)
+}
+
+// changeSetting applies the setting when name is given and answers with all
+// settings of the chat and buttons to change them.
+func (operator *Operator) changeSetting(
+	chat *tb.Chat,
+	name string,
+	value string,
+	language string,
+) error {
+	preferences, err := operator.database.GetSubscriberPreferences(chat.ID)
+	if err != nil {
+		return err
+	}
+
+	var text string
+	if name != "" {
+		preferences, err = applySetting(preferences, name, value)
+		if err != nil {
+			reason := err.Error()
+			if err, ok := err.(settingError); ok {
+				reason = err.Translate(language)
+			}
+
+			text = i18n.Translate(language, i18n.TEXT_ABOUT_SETTING_INVALID, reason)
+		} else {
+			err = operator.database.SetSubscriberPreferences(preferences)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	text += getTextAboutSettings(preferences, language) + "\n" +
+		i18n.Translate(language, i18n.TEXT_ABOUT_SETTINGS_USAGE, constants.REPORT_TYPES)
+	_, err = operator.transport.SendMessageWithOptions(
+		chat,
+		text,
+		&tb.SendOptions{
+			ReplyMarkup: &tb.ReplyMarkup{InlineKeyboard: getSettingsButtons()},
+		},
+	)
+	return err
+}
+
+// handleSettingDialog changes the setting pressed by the sender in the chat,
+// it returns false when there is no such dialog.
+func (operator *Operator) handleSettingDialog(message *tb.Message) (bool, error) {
+	userID := int64(message.Sender.ID)
+
+	operator.settingDialogsMutex.Lock()
+	dialog, ok := operator.settingDialogs[userID]
+	if ok && tools.TimeNow().After(dialog.expiresAt) {
+		delete(operator.settingDialogs, userID)
+		ok = false
+	}
+
+	if ok && dialog.chatID == message.Chat.ID {
+		delete(operator.settingDialogs, userID)
+	} else {
+		ok = false
+	}
+	operator.settingDialogsMutex.Unlock()
+
+	if !ok {
+		return false, nil
+	}
+
+	return true, operator.changeSetting(
+		message.Chat,
+		dialog.name,
+		strings.Join(strings.Fields(message.Text), ""),
+		operator.getLanguage(message),
+	)
+}
+
+func getSettingsButtons() [][]tb.InlineButton {
+	var rows [][]tb.InlineButton
+	for i, name := range SETTINGS {
+		button := tb.InlineButton{Unique: BUTTON_SETTING, Text: name, Data: name}
+		if i%2 == 0 {
+			rows = append(rows, []tb.InlineButton{button})
+		} else {
+			rows[len(rows)-1] = append(rows[len(rows)-1], button)
+		}
+	}
+
+	return rows
+}
+
+func applySetting(
+	preferences database.Preferences,
+	name string,
+	value string,
+) (database.Preferences, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return preferences, newSettingError(i18n.TEXT_SETTING_VALUE_REQUIRED)
+	}
+
+	switch name {
+	case SETTING_LEAGUES:
+		preferences.Leagues = parseSettingList(value)
+
+	case SETTING_COUNTRIES:
+		preferences.Countries = parseSettingList(value)
+
+	case SETTING_MIN_ODD:
+		if value == SETTING_VALUE_OFF {
+			preferences.MinLiveOdd = 0
+			break
+		}
+
+		minOdd, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", "."), 64)
+		if err != nil || minOdd < 0 {
+			return preferences, newSettingError(i18n.TEXT_SETTING_INVALID_MIN_ODD)
+		}
+
+		preferences.MinLiveOdd = minOdd
+
+	case SETTING_MAX_SIGNALS:
+		if value == SETTING_VALUE_OFF {
+			preferences.MaxSignalsPerDay = 0
+			break
+		}
+
+		maxSignals, err := strconv.Atoi(value)
+		if err != nil || maxSignals < 0 {
+			return preferences, newSettingError(i18n.TEXT_SETTING_INVALID_MAX_SIGNALS)
+		}
+
+		preferences.MaxSignalsPerDay = maxSignals
+
+	case SETTING_QUIET_HOURS:
+		if value == SETTING_VALUE_OFF {
+			preferences.QuietHoursFrom = 0
+			preferences.QuietHoursTo = 0
+			break
+		}
+
+		from, to, err := parseQuietHours(value)
+		if err != nil {
+			return preferences, err
+		}
+
+		preferences.QuietHoursFrom = from
+		preferences.QuietHoursTo = to
+
+	case SETTING_REPORTS:
+		if value == SETTING_VALUE_OFF {
+			preferences.Reports = []string{}
+			break
+		}
+
+		reports := parseSettingList(value)
+		if reports == nil {
+			reports = strings.Split(constants.REPORT_TYPES, ",")
+		}
+
+		for _, report := range reports {
+			if !tools.Find(strings.Split(constants.REPORT_TYPES, ","), report) {
+				return preferences, newSettingError(i18n.TEXT_SETTING_UNKNOWN_REPORT, report)
+			}
+		}
+
+		preferences.Reports = reports
+
+	default:
+		return preferences, newSettingError(i18n.TEXT_SETTING_UNKNOWN, name)
+	}
+
+	return preferences, nil
+}
+
+// parseSettingList returns nil for "all" which means that filter is disabled.
+func parseSettingList(value string) []string {
+	if value == SETTING_VALUE_ALL {
+		return nil
+	}
+
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
+func parseQuietHours(value string) (int, int, error) {
+	bounds := strings.Split(value, "-")
+	if len(bounds) != 2 {
+		return 0, 0, newSettingError(i18n.TEXT_SETTING_INVALID_QUIET_HOURS)
+	}
+
+	from, err := strconv.Atoi(bounds[0])
+	if err != nil || from < 0 || from > 23 {
+		return 0, 0, newSettingError(i18n.TEXT_SETTING_INVALID_QUIET_HOURS)
+	}
+
+	to, err := strconv.Atoi(bounds[1])
+	if err != nil || to < 0 || to > 23 {
+		return 0, 0, newSettingError(i18n.TEXT_SETTING_INVALID_QUIET_HOURS)
+	}
+
+	return from, to, nil
+}
+
+func getTextAboutSettings(preferences database.Preferences, language string) string {
+	formatList := func(list []string) string {
+		if len(list) == 0 {
+			return SETTING_VALUE_ALL
+		}
+
+		return strings.Join(list, ",")
+	}
+
+	maxSignals := SETTING_VALUE_OFF
+	if preferences.MaxSignalsPerDay > 0 {
+		maxSignals = strconv.Itoa(preferences.MaxSignalsPerDay)
+	}
+
+	quietHours := SETTING_VALUE_OFF
+	if preferences.QuietHoursFrom != preferences.QuietHoursTo {
+		quietHours = fmt.Sprintf("%d-%d", preferences.QuietHoursFrom, preferences.QuietHoursTo)
+	}
+
+	reports := SETTING_VALUE_OFF
+	if len(preferences.Reports) != 0 {
+		reports = strings.Join(preferences.Reports, ",")
+	}
+
+	return i18n.Translate(
+		language,
+		i18n.TEXT_ABOUT_SETTINGS,
+		formatList(preferences.Leagues),
+		formatList(preferences.Countries),
+		i18n.FormatNumber(language, preferences.MinLiveOdd, 2),
+		maxSignals,
+		quietHours,
+		reports,
+	)
+}
@@ -0,0 +1,232 @@
+package operator
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/daniilsolovey/BetBotGo/internal/i18n"
+	"github.com/reconquest/karma-go"
+	"github.com/reconquest/pkg/log"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+const (
+	COMMAND_ROLE = "/role"
+)
+
+var ErrNotEnoughRights = errors.New("not enough rights")
+
+// BootstrapRoles makes telegram.owner the owner and adds telegram.admins as
+// admins unless they have got another role before.
+func (operator *Operator) BootstrapRoles() error {
+	if operator.config.Telegram.Owner != 0 {
+		err := operator.database.SetOwner(operator.config.Telegram.Owner)
+		if err != nil {
+			return err
+		}
+	} else {
+		log.Warning("telegram.owner is not set, admin roles can't be granted or revoked")
+	}
+
+	for _, admin := range operator.config.Telegram.Admins {
+		err := operator.database.AddUserRole(database.UserRole{
+			UserID: admin,
+			Role:   database.ROLE_ADMIN,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Authorize is called by transport before every command handler, rejected
+// attempts are logged and answered.
+func (operator *Operator) Authorize(message *tb.Message, required string) bool {
+	getRole := operator.getRole
+	if operator.isGroupChatWideCommand(message) {
+		getRole = operator.getSenderRole
+		if !database.IsRoleAllowed(required, database.ROLE_SUBSCRIBER) {
+			required = database.ROLE_SUBSCRIBER
+		}
+	}
+
+	if required == database.ROLE_GUEST {
+		return true
+	}
+
+	role, err := getRole(message)
+	if err != nil {
+		log.Errorf(err, "unable to get role, rejecting %s", getCommandName(message.Text))
+		return false
+	}
+
+	if database.IsRoleAllowed(role, required) {
+		return true
+	}
+
+	var userID int64
+	var username string
+	if message.Sender != nil {
+		userID = int64(message.Sender.ID)
+		username = message.Sender.Username
+	}
+
+	log.Warningf(
+		karma.
+			Describe("user_id", userID).
+			Describe("username", username).
+			Describe("chat_id", message.Chat.ID).
+			Describe("role", role).
+			Describe("required_role", required).
+			Reason(ErrNotEnoughRights),
+		"command rejected: %s",
+		getCommandName(message.Text),
+	)
+
+	err = operator.transport.SendMessage(
+		message.Chat,
+		i18n.Translate(operator.getLanguage(message), i18n.TEXT_ABOUT_ACCESS_DENIED, required),
+	)
+	if err != nil {
+		log.Errorf(err, "unable to answer rejected command, chat_id: %d", message.Chat.ID)
+	}
+
+	return false
+}
+
+func (operator *Operator) SetRole(message *tb.Message) error {
+	return operator.handleAdminCommand(message, COMMAND_ROLE, "user_id role", operator.setRole)
+}
+
+// getRole returns stored role of the sender, users without stored role are
+// subscribers in subscribed chats. Stored role is used as is, so /role guest
+// revokes access of subscribed chat member.
+func (operator *Operator) getRole(message *tb.Message) (string, error) {
+	role := database.ROLE_GUEST
+	if message.Sender != nil {
+		storedRole, err := operator.database.GetUserRole(int64(message.Sender.ID))
+		if err != nil {
+			return "", err
+		}
+
+		if storedRole != "" {
+			return storedRole, nil
+		}
+	}
+
+	if message.Chat == nil {
+		return role, nil
+	}
+
+	hasAccess, err := operator.hasAccess(message.Chat.ID)
+	if err != nil {
+		return "", err
+	}
+
+	if hasAccess {
+		return database.ROLE_SUBSCRIBER, nil
+	}
+
+	return role, nil
+}
+
+// getSenderRole returns role of the sender regardless of the chat, sender
+// is subscriber when the private chat with the sender is subscribed.
+func (operator *Operator) getSenderRole(message *tb.Message) (string, error) {
+	if message.Sender == nil {
+		return database.ROLE_GUEST, nil
+	}
+
+	return operator.getRole(&tb.Message{
+		Sender: message.Sender,
+		Chat:   &tb.Chat{ID: int64(message.Sender.ID), Type: tb.ChatPrivate},
+	})
+}
+
+func (operator *Operator) isGroupChatWideCommand(message *tb.Message) bool {
+	if message.Chat == nil {
+		return false
+	}
+
+	if message.Chat.Type != tb.ChatGroup && message.Chat.Type != tb.ChatSuperGroup {
+		return false
+	}
+
+	name := getCommandName(message.Text)
+	for _, command := range operator.GetCommands() {
+		if command.Name == name {
+			return command.ChatWide
+		}
+	}
+
+	return false
+}
+
+func (operator *Operator) setRole(
+	argument string,
+	userID int64,
+	language string,
+) (string, error) {
+	fields := strings.Fields(argument)
+	usage := i18n.Translate(
+		language,
+		i18n.TEXT_ABOUT_ROLE_USAGE,
+		strings.Join(database.ROLES[:len(database.ROLES)-1], ", "),
+	)
+	if len(fields) != 2 {
+		return usage, nil
+	}
+
+	targetID, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return usage, nil
+	}
+
+	role := fields[1]
+	if !database.IsKnownRole(role) || role == database.ROLE_OWNER {
+		return usage, nil
+	}
+
+	actorRole, err := operator.database.GetUserRole(userID)
+	if err != nil {
+		return "", err
+	}
+
+	targetRole, err := operator.database.GetUserRole(targetID)
+	if err != nil {
+		return "", err
+	}
+
+	if targetRole == database.ROLE_OWNER {
+		return i18n.Translate(language, i18n.TEXT_ABOUT_ROLE_NOT_ALLOWED), nil
+	}
+
+	isAdminChange := role == database.ROLE_ADMIN || targetRole == database.ROLE_ADMIN
+	if isAdminChange && actorRole != database.ROLE_OWNER {
+		return i18n.Translate(language, i18n.TEXT_ABOUT_ROLE_NOT_ALLOWED), nil
+	}
+
+	err = operator.database.SetUserRole(database.UserRole{
+		UserID:    targetID,
+		Role:      role,
+		GrantedBy: userID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return i18n.Translate(language, i18n.TEXT_ABOUT_ROLE_SET, targetID, role), nil
+}
+
+func getCommandName(text string) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	return strings.Split(fields[0], "@")[0]
+}
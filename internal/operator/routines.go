@@ -0,0 +1,152 @@
+package operator
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+)
+
+func (operator *Operator) startRoutine(routine func()) {
+	operator.routines.Add(1)
+	atomic.AddInt64(&operator.activeRoutines, 1)
+	tools.Go(func() {
+		defer func() {
+			atomic.AddInt64(&operator.activeRoutines, -1)
+			operator.routines.Done()
+		}()
+
+		routine()
+	})
+}
+
+// eventRoutines tracks routines started with context of the event, the
+// context is released when the last of them finishes.
+type eventRoutines struct {
+	eventID string
+	cancel  context.CancelFunc
+	running int64
+}
+
+type eventRoutinesKey struct{}
+
+// newEventContext creates context for routines of the event, it is canceled
+// on shutdown or by /skip. Must be called with routinesMutex locked.
+func (operator *Operator) newEventContext(eventID string) context.Context {
+	ctx, cancel := context.WithCancel(operator.context)
+	routines := &eventRoutines{eventID: eventID, cancel: cancel}
+	operator.eventContexts[eventID] = routines
+	return context.WithValue(ctx, eventRoutinesKey{}, routines)
+}
+
+// startEventRoutine starts routine which belongs to the event of ctx, the
+// event context is canceled and forgotten when all its routines finish.
+func (operator *Operator) startEventRoutine(ctx context.Context, routine func()) {
+	routines, ok := ctx.Value(eventRoutinesKey{}).(*eventRoutines)
+	if !ok {
+		operator.startRoutine(routine)
+		return
+	}
+
+	atomic.AddInt64(&routines.running, 1)
+	operator.startRoutine(func() {
+		defer func() {
+			if atomic.AddInt64(&routines.running, -1) == 0 {
+				operator.releaseEventContext(routines)
+			}
+		}()
+
+		routine()
+	})
+}
+
+func (operator *Operator) releaseEventContext(routines *eventRoutines) {
+	operator.routinesMutex.Lock()
+	defer operator.routinesMutex.Unlock()
+
+	routines.cancel()
+	if operator.eventContexts[routines.eventID] == routines {
+		delete(operator.eventContexts, routines.eventID)
+	}
+}
+
+// CancelEventRoutines stops routines of the event and removes it from routine
+// cache, so routines can be created again.
+func (operator *Operator) CancelEventRoutines(eventID string) bool {
+	operator.routinesMutex.Lock()
+	defer operator.routinesMutex.Unlock()
+
+	var routineCache []string
+	for _, value := range operator.RoutineCache {
+		if value != eventID {
+			routineCache = append(routineCache, value)
+		}
+	}
+	operator.RoutineCache = routineCache
+
+	operator.deleteLiveEvent(eventID)
+
+	routines, ok := operator.eventContexts[eventID]
+	if !ok {
+		return false
+	}
+
+	routines.cancel()
+	delete(operator.eventContexts, eventID)
+	return true
+}
+
+func (operator *Operator) sleep(ctx context.Context, duration time.Duration) {
+	tools.Sleep(ctx, duration)
+}
+
+func (operator *Operator) isStopped(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+
+	return operator.isLeader != nil && !operator.isLeader()
+}
+
+// SetLeaderCheck makes routines stop when instance loses leadership, so live
+// monitoring is continued by the new leader.
+func (operator *Operator) SetLeaderCheck(isLeader func() bool) {
+	operator.isLeader = isLeader
+}
+
+func (operator *Operator) ResetRoutineCache() {
+	operator.routinesMutex.Lock()
+	defer operator.routinesMutex.Unlock()
+
+	operator.RoutineCache = nil
+	for eventID, routines := range operator.eventContexts {
+		routines.cancel()
+		delete(operator.eventContexts, eventID)
+	}
+}
+
+func (operator *Operator) Stop() {
+	operator.cancel()
+}
+
+// WaitRoutines waits until stopped routines finish their current iteration
+// and returns number of routines which are still running after deadline.
+func (operator *Operator) WaitRoutines(ctx context.Context) int64 {
+	done := make(chan struct{})
+	go func() {
+		operator.routines.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	return atomic.LoadInt64(&operator.activeRoutines)
+}
+
+func (operator *Operator) GetActiveRoutinesCount() int64 {
+	return atomic.LoadInt64(&operator.activeRoutines)
+}
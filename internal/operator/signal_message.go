@@ -0,0 +1,175 @@
+package operator
+
+import (
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/constants"
+	"github.com/daniilsolovey/BetBotGo/internal/delivery"
+	"github.com/daniilsolovey/BetBotGo/internal/i18n"
+	"github.com/daniilsolovey/BetBotGo/internal/requester"
+	"github.com/daniilsolovey/BetBotGo/internal/templates"
+	"github.com/reconquest/pkg/log"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+const (
+	BUTTON_PLACED  = "placed"
+	BUTTON_SKIP    = "skip"
+	BUTTON_DETAILS = "details"
+)
+
+func (operator *Operator) SkipSignal(callback *tb.Callback) (string, error) {
+	if operator.isBroadcastCallback(callback) {
+		return operator.getTextAboutBroadcastCallback(callback), nil
+	}
+
+	log.Infof(nil, "signal skipped by chat_id: %d, event_id: %s", callback.Message.Chat.ID, callback.Data)
+	language := operator.getChatLanguage(callback.Message.Chat.ID, callback.Sender.LanguageCode)
+	return i18n.Translate(language, i18n.TEXT_ABOUT_SIGNAL_SKIPPED), nil
+}
+
+func (operator *Operator) Details(callback *tb.Callback) (string, error) {
+	if operator.isBroadcastCallback(callback) {
+		return operator.getTextAboutBroadcastCallback(callback), nil
+	}
+
+	chatID := callback.Message.Chat.ID
+	language := operator.getChatLanguage(chatID, callback.Sender.LanguageCode)
+
+	event, err := operator.database.GetEventByID(callback.Data)
+	if err != nil {
+		return "", err
+	}
+
+	if event == nil {
+		return i18n.Translate(language, i18n.TEXT_ABOUT_EVENT_NOT_FOUND), nil
+	}
+
+	text, err := operator.templates.Render(
+		language,
+		templates.TEMPLATE_SIGNAL_DETAILS,
+		operator.getSignalTemplateData(*event, operator.delivery.GetLocation(chatID)),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = operator.transport.SendMessageWithOptions(
+		callback.Message.Chat,
+		text,
+		&tb.SendOptions{
+			ParseMode: tb.ModeHTML,
+			ReplyTo:   callback.Message,
+		},
+	)
+	return "", err
+}
+
+// getMessageAboutSignal renders signal for the chat, outcome is set when the
+// second set is settled.
+func (operator *Operator) getMessageAboutSignal(
+	event requester.EventWithOdds,
+	chatID int64,
+	outcome string,
+) delivery.Message {
+	language := operator.delivery.GetLanguage(chatID)
+	data := operator.getSignalTemplateData(event, operator.delivery.GetLocation(chatID))
+	data.Outcome = outcome
+	text, err := operator.templates.Render(language, templates.TEMPLATE_SIGNAL, data)
+	if err != nil {
+		log.Errorf(err, "unable to render signal, event_id: %s", event.EventID)
+		return delivery.Message{
+			Text: i18n.Translate(
+				language,
+				i18n.TEXT_ABOUT_SIGNAL_FALLBACK,
+				data.FavoriteName,
+				event.EventID,
+			),
+		}
+	}
+
+	if operator.delivery.IsBroadcastChat(chatID) {
+		// everyone would press buttons in channel and get answers there
+		return delivery.Message{Text: text, HTML: true}
+	}
+
+	buttons := getSignalButtons(event.EventID, language)
+	if outcome != "" {
+		// buttons about placing the bet are useless for settled signal
+		buttons = buttons[1:]
+	}
+
+	return delivery.Message{
+		Text:    text,
+		HTML:    true,
+		Buttons: buttons,
+	}
+}
+
+// isBroadcastCallback returns true for buttons of signals which had been sent
+// to broadcast chats with buttons.
+func (operator *Operator) isBroadcastCallback(callback *tb.Callback) bool {
+	return callback.Message != nil && operator.delivery.IsBroadcastChat(callback.Message.Chat.ID)
+}
+
+func (operator *Operator) getTextAboutBroadcastCallback(callback *tb.Callback) string {
+	return i18n.Translate(
+		operator.getChatLanguage(int64(callback.Sender.ID), callback.Sender.LanguageCode),
+		i18n.TEXT_ABOUT_BUTTONS_IN_PRIVATE_CHAT,
+	)
+}
+
+func (operator *Operator) getSignalTemplateData(
+	event requester.EventWithOdds,
+	location *time.Location,
+) templates.Signal {
+	favoriteName := event.HomeCommandName
+	if event.Favorite == constants.FAVORITE_IS_AWAY {
+		favoriteName = event.AwayCommandName
+	}
+
+	data := templates.Signal{
+		EventID:      event.EventID,
+		LeagueName:   event.League.Name,
+		CountryCode:  getCountryCode(event, operator.getLeagues()[event.League.ID]),
+		HomeName:     event.HomeCommandName,
+		AwayName:     event.AwayCommandName,
+		FavoriteName: favoriteName,
+		OpeningOdds:  templates.Odds{Home: event.HomeOdd, Away: event.AwayOdd},
+		StartTime:    event.EventStartTime.In(location),
+	}
+
+	if len(event.ResultEventWithOdds.Odds.Odds91_1) != 0 {
+		odds := event.ResultEventWithOdds.Odds.Odds91_1[0]
+		data.Score = odds.SS
+		data.CurrentSet = getNumberOfSet(odds.SS)
+		data.LiveOdds.Home, _ = convertStringToFloat(odds.HomeOd)
+		data.LiveOdds.Away, _ = convertStringToFloat(odds.AwayOd)
+	}
+
+	return data
+}
+
+func getSignalButtons(eventID string, language string) [][]tb.InlineButton {
+	return [][]tb.InlineButton{
+		{
+			{
+				Unique: BUTTON_PLACED,
+				Text:   i18n.Translate(language, i18n.TEXT_BUTTON_PLACED),
+				Data:   eventID,
+			},
+			{
+				Unique: BUTTON_SKIP,
+				Text:   i18n.Translate(language, i18n.TEXT_BUTTON_SKIP),
+				Data:   eventID,
+			},
+		},
+		{
+			{
+				Unique: BUTTON_DETAILS,
+				Text:   i18n.Translate(language, i18n.TEXT_BUTTON_DETAILS),
+				Data:   eventID,
+			},
+		},
+	}
+}
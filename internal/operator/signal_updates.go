@@ -0,0 +1,87 @@
+package operator
+
+import (
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/delivery"
+	"github.com/daniilsolovey/BetBotGo/internal/requester"
+	"github.com/daniilsolovey/BetBotGo/internal/templates"
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+	"github.com/reconquest/pkg/log"
+)
+
+// signalUpdater edits sent signal messages with live score and odds until
+// the second set is settled, edits are skipped when nothing has changed and
+// throttled by signals.edit_interval.
+type signalUpdater struct {
+	operator *Operator
+	messages []delivery.SentMessage
+	state    string
+	editedAt time.Time
+}
+
+func (operator *Operator) newSignalUpdater(
+	event requester.EventWithOdds,
+	messages []delivery.SentMessage,
+) *signalUpdater {
+	return &signalUpdater{
+		operator: operator,
+		messages: messages,
+		state:    getSignalState(event),
+		editedAt: tools.TimeNow(),
+	}
+}
+
+func (updater *signalUpdater) update(event requester.EventWithOdds) {
+	if updater == nil || len(updater.messages) == 0 {
+		return
+	}
+
+	state := getSignalState(event)
+	if state == updater.state {
+		return
+	}
+
+	timeNow := tools.TimeNow()
+	if timeNow.Sub(updater.editedAt) < updater.operator.config.Signals.EditInterval {
+		return
+	}
+
+	updater.edit(event, "")
+	updater.state = state
+	updater.editedAt = timeNow
+}
+
+func (updater *signalUpdater) finish(event requester.EventWithOdds, winner string) {
+	if updater == nil || len(updater.messages) == 0 {
+		return
+	}
+
+	outcome := templates.OUTCOME_LOST
+	if winner == event.Favorite {
+		outcome = templates.OUTCOME_WON
+	}
+
+	updater.edit(event, outcome)
+}
+
+func (updater *signalUpdater) edit(event requester.EventWithOdds, outcome string) {
+	err := updater.operator.delivery.EditMessagesFunc(
+		updater.messages,
+		func(message delivery.SentMessage) delivery.Message {
+			return updater.operator.getMessageAboutSignal(event, message.ChatID, outcome)
+		},
+	)
+	if err != nil {
+		log.Errorf(err, "unable to update signal messages, event_id: %s", event.EventID)
+	}
+}
+
+func getSignalState(event requester.EventWithOdds) string {
+	if len(event.ResultEventWithOdds.Odds.Odds91_1) == 0 {
+		return ""
+	}
+
+	odds := event.ResultEventWithOdds.Odds.Odds91_1[0]
+	return odds.SS + " " + odds.HomeOd + " " + odds.AwayOd
+}
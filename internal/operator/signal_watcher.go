@@ -0,0 +1,289 @@
+package operator
+
+import (
+	"context"
+	"math"
+	"strconv"
+
+	"github.com/daniilsolovey/BetBotGo/internal/constants"
+	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/daniilsolovey/BetBotGo/internal/delivery"
+	"github.com/daniilsolovey/BetBotGo/internal/i18n"
+	"github.com/daniilsolovey/BetBotGo/internal/requester"
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+	"github.com/reconquest/pkg/log"
+)
+
+const (
+	REASON_MARKET_SUSPENDED = i18n.TEXT_REASON_MARKET_SUSPENDED
+	REASON_ODD_BELOW_CUTOFF = i18n.TEXT_REASON_ODD_BELOW_CUTOFF
+	REASON_MATCH_ABANDONED  = i18n.TEXT_REASON_MATCH_ABANDONED
+
+	MATCH_ABANDONED_EMPTY_POLLS = 3
+)
+
+// signalWatch keeps state of the watched signal between polls of live event.
+type signalWatch struct {
+	signal     requester.EventWithOdds
+	threshold  float64
+	emptyPolls int
+	updated    bool
+}
+
+// check returns reason to cancel the signal, whether the signal should be
+// updated and whether watching is finished. Bet api misses odds of running
+// match from time to time, so match is abandoned only after several polls
+// without odds in a row.
+func (watch *signalWatch) check(
+	event requester.EventWithOdds,
+) (reason string, update bool, finished bool) {
+	reason, finished = getSignalInvalidationReason(event)
+	if reason == REASON_MATCH_ABANDONED {
+		watch.emptyPolls++
+		if watch.emptyPolls < MATCH_ABANDONED_EMPTY_POLLS {
+			return "", false, false
+		}
+
+		return reason, false, false
+	}
+
+	watch.emptyPolls = 0
+	if reason != "" || finished || watch.updated || watch.threshold <= 0 {
+		return reason, false, finished
+	}
+
+	signalOdd, ok := getFavoriteOdd(watch.signal)
+	if !ok {
+		return "", false, false
+	}
+
+	liveOdd, ok := getFavoriteOdd(event)
+	if !ok {
+		return "", false, false
+	}
+
+	return "", math.Abs(liveOdd-signalOdd) >= watch.threshold, false
+}
+
+func (operator *Operator) routineWatchSignal(
+	ctx context.Context,
+	event requester.EventWithOdds,
+	messages []delivery.SentMessage,
+) {
+	startTime, err := tools.GetCurrentTime()
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	watch := &signalWatch{
+		signal:    event,
+		threshold: operator.config.Signals.UpdateThreshold,
+	}
+
+	log.Infof(nil, "routine for watching signal started, event_id: %s", event.EventID)
+	for {
+		if operator.isStopped(ctx) {
+			log.Infof(nil, "routine for watching signal stopped by shutdown, event_id: %s", event.EventID)
+			return
+		}
+
+		timeNow, err := tools.GetCurrentTime()
+		if err != nil {
+			log.Error(err)
+			operator.sleep(ctx, REQUEST_FREQUENCY_DELAY)
+			continue
+		}
+
+		if timeNow.After(startTime.Add(operator.config.Signals.InvalidationWindow)) {
+			log.Infof(nil, "routine for watching signal finished by timeout, event_id: %s", event.EventID)
+			return
+		}
+
+		liveEvent, err := operator.requester.GetLiveEventByID(event.EventID)
+		if err != nil {
+			log.Errorf(err, "unable to get live event data by event_id: %s", event.EventID)
+			operator.sleep(ctx, REQUEST_FREQUENCY_DELAY)
+			continue
+		}
+
+		liveEvent.Favorite = event.Favorite
+		reason, update, finished := watch.check(*liveEvent)
+		if finished {
+			log.Infof(nil, "routine for watching signal finished by second set, event_id: %s", event.EventID)
+			return
+		}
+
+		if update {
+			watch.updated = operator.sendSignalUpdate(event, *liveEvent, messages)
+		}
+
+		if reason == "" {
+			operator.sleep(ctx, REQUEST_FREQUENCY_DELAY)
+			continue
+		}
+
+		sent, err := operator.database.SendSignalOnce(
+			event.EventID,
+			constants.STRATEGY_SECOND_SET,
+			constants.SIGNAL_TYPE_CANCEL,
+			func(tx database.DatabaseInterface) error {
+				return operator.delivery.WithDatabase(tx).ReplyToMessagesFunc(
+					messages,
+					func(message delivery.SentMessage) string {
+						return getTextAboutSignalCancel(
+							*liveEvent,
+							reason,
+							operator.delivery.GetLanguage(message.ChatID),
+						)
+					},
+				)
+			},
+		)
+		if err != nil {
+			log.Errorf(err, "unable to send signal cancel, event_id: %s", event.EventID)
+		} else if sent {
+			log.Infof(nil, "signal cancel sent to telegram, event_id: %s, reason: %s", event.EventID, reason)
+		}
+
+		return
+	}
+}
+
+// sendSignalUpdate replies to signal messages with changed odds, it returns
+// false when update should be tried again on the next poll.
+func (operator *Operator) sendSignalUpdate(
+	signal requester.EventWithOdds,
+	liveEvent requester.EventWithOdds,
+	messages []delivery.SentMessage,
+) bool {
+	sent, err := operator.database.SendSignalOnce(
+		signal.EventID,
+		constants.STRATEGY_SECOND_SET,
+		constants.SIGNAL_TYPE_UPDATE,
+		func(tx database.DatabaseInterface) error {
+			return operator.delivery.WithDatabase(tx).ReplyToMessagesFunc(
+				messages,
+				func(message delivery.SentMessage) string {
+					return getTextAboutSignalUpdate(
+						signal,
+						liveEvent,
+						operator.delivery.GetLanguage(message.ChatID),
+					)
+				},
+			)
+		},
+	)
+	if err != nil {
+		log.Errorf(err, "unable to send signal update, event_id: %s", signal.EventID)
+		return false
+	}
+
+	if sent {
+		log.Infof(nil, "signal update sent to telegram, event_id: %s", signal.EventID)
+	}
+
+	return true
+}
+
+func getTextAboutSignalUpdate(
+	signal requester.EventWithOdds,
+	event requester.EventWithOdds,
+	language string,
+) string {
+	odds := event.ResultEventWithOdds.Odds.Odds91_1[0]
+
+	return i18n.Translate(
+		language,
+		i18n.TEXT_ABOUT_SIGNAL_UPDATE,
+		event.EventID,
+		formatOdd(language, getFavoriteOddText(signal)),
+		formatOdd(language, getFavoriteOddText(event)),
+		formatOdd(language, odds.HomeOd),
+		formatOdd(language, odds.AwayOd),
+		odds.SS,
+	)
+}
+
+func getTextAboutSignalCancel(
+	event requester.EventWithOdds,
+	reason string,
+	language string,
+) string {
+	var homeOdd, awayOdd, score string
+	if len(event.ResultEventWithOdds.Odds.Odds91_1) != 0 {
+		homeOdd = event.ResultEventWithOdds.Odds.Odds91_1[0].HomeOd
+		awayOdd = event.ResultEventWithOdds.Odds.Odds91_1[0].AwayOd
+		score = event.ResultEventWithOdds.Odds.Odds91_1[0].SS
+	}
+
+	return i18n.Translate(
+		language,
+		i18n.TEXT_ABOUT_SIGNAL_CANCEL,
+		event.EventID,
+		i18n.Translate(language, reason),
+		formatOdd(language, homeOdd),
+		formatOdd(language, awayOdd),
+		score,
+	)
+}
+
+func getSignalInvalidationReason(event requester.EventWithOdds) (string, bool) {
+	if len(event.ResultEventWithOdds.Odds.Odds91_1) == 0 {
+		return REASON_MATCH_ABANDONED, false
+	}
+
+	odds := event.ResultEventWithOdds.Odds.Odds91_1[0]
+	if getNumberOfSet(odds.SS) >= 3 {
+		return "", true
+	}
+
+	favoriteOdd := getFavoriteOddText(event)
+	if favoriteOdd == "-" {
+		return REASON_MARKET_SUSPENDED, false
+	}
+
+	mainOdd, err := convertStringToFloat(favoriteOdd)
+	if err != nil {
+		log.Errorf(err, "unable to parse favorite odd, event_id: %s", event.EventID)
+		return "", false
+	}
+
+	if mainOdd <= constants.LIVE_ODD_VALUE_MIN {
+		return REASON_ODD_BELOW_CUTOFF, false
+	}
+
+	return "", false
+}
+
+func getFavoriteOddText(event requester.EventWithOdds) string {
+	if len(event.ResultEventWithOdds.Odds.Odds91_1) == 0 {
+		return ""
+	}
+
+	odds := event.ResultEventWithOdds.Odds.Odds91_1[0]
+	if event.Favorite == constants.FAVORITE_IS_AWAY {
+		return odds.AwayOd
+	}
+
+	return odds.HomeOd
+}
+
+func getFavoriteOdd(event requester.EventWithOdds) (float64, bool) {
+	odd, err := strconv.ParseFloat(getFavoriteOddText(event), 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return odd, true
+}
+
+// formatOdd formats odd received from bet api, suspended odds are kept as is.
+func formatOdd(language string, odd string) string {
+	value, err := convertStringToFloat(odd)
+	if err != nil {
+		return odd
+	}
+
+	return i18n.FormatNumber(language, value, 2)
+}
@@ -1,46 +1,201 @@
 package operator
 
 import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/daniilsolovey/BetBotGo/internal/delivery"
+	"github.com/daniilsolovey/BetBotGo/internal/i18n"
+	"github.com/daniilsolovey/BetBotGo/internal/requester"
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
 	"github.com/reconquest/karma-go"
 	"github.com/reconquest/pkg/log"
-	"gopkg.in/tucnak/telebot.v2"
 	tb "gopkg.in/tucnak/telebot.v2"
 )
 
-var TEMP_RECIPIENT telebot.Recipient
-
 const (
-	TEXT_ABOUT_WINNER = "WARNING! Делай ставку!\n" +
-		"  event_id: %s\n" +
-		"  league_name: %s\n" +
-		"  last_odd_home: %s\n" +
-		"  last_odd_away: %s\n" +
-		"  home_command_name: %s\n" +
-		"  away_command_name: %s\n" +
-		"  favorite: %s\n"
+	COMMAND_START    = "/start"
+	COMMAND_STOP     = "/stop"
+	COMMAND_TIMEZONE = "/timezone"
+	COMMAND_TOMORROW = "/tomorrow"
 )
 
 func (operator *Operator) Start(message *tb.Message) error {
-	text := "Hi! I am a telegram bot and I can notify you about all events for today" +
-		" on volleyball"
-	var recipient telebot.Recipient
-	var recipientID int
-	if message.Chat != nil {
-		recipient = message.Chat
-		TEMP_RECIPIENT = message.Chat
-		recipientID = int(message.Chat.ID)
-	} else {
-		recipient = message.Sender
-		TEMP_RECIPIENT = message.Sender
-		recipientID = message.Sender.ID
+	subscriber := getSubscriber(message)
+	operator.rememberLanguage(message, subscriber.ChatID)
+	language := operator.getLanguage(message)
+
+	code := strings.TrimSpace(message.Payload)
+	if code != "" {
+		return operator.redeemAccessCode(subscriber, code, language)
 	}
 
-	err := operator.transport.SendMessage(recipient, text)
+	if operator.config.Access.CodesRequired {
+		hasAccess, err := operator.hasAccess(subscriber.ChatID)
+		if err != nil {
+			return err
+		}
+
+		text := i18n.Translate(language, i18n.TEXT_ABOUT_START)
+		if !hasAccess {
+			text = i18n.Translate(language, i18n.TEXT_ABOUT_ACCESS_CODE_REQUIRED)
+		}
+
+		return operator.transport.SendMessage(delivery.GetRecipient(subscriber.ChatID), text)
+	}
+
+	err := operator.database.UpsertSubscriber(subscriber)
+	if err != nil {
+		return err
+	}
+
+	log.Infof(
+		karma.
+			Describe("chat_id", subscriber.ChatID).
+			Describe("type", subscriber.Type).
+			Describe("username", subscriber.Username),
+		"subscriber started the bot",
+	)
+
+	err = operator.transport.SendMessage(
+		delivery.GetRecipient(subscriber.ChatID),
+		i18n.Translate(language, i18n.TEXT_ABOUT_START),
+	)
 	if err != nil {
 		return karma.Format(err, "unable to send message to user: %d ",
-			recipientID)
+			subscriber.ChatID)
 	}
 
-	log.Warning("recipient ", recipient)
 	return nil
 }
+
+func (operator *Operator) Unsubscribe(message *tb.Message) error {
+	subscriber := getSubscriber(message)
+	_, err := operator.database.DeactivateSubscriber(subscriber.ChatID)
+	if err != nil {
+		return err
+	}
+
+	log.Infof(nil, "subscriber stopped the bot, chat_id: %d", subscriber.ChatID)
+	return operator.transport.SendMessage(
+		delivery.GetRecipient(subscriber.ChatID),
+		i18n.Translate(operator.getLanguage(message), i18n.TEXT_ABOUT_STOP),
+	)
+}
+
+func getSubscriber(message *tb.Message) database.Subscriber {
+	if message.Chat != nil {
+		subscriber := database.Subscriber{
+			ChatID:   message.Chat.ID,
+			Type:     string(message.Chat.Type),
+			Username: message.Chat.Username,
+		}
+		if subscriber.Username == "" && message.Sender != nil {
+			subscriber.Username = message.Sender.Username
+		}
+
+		return subscriber
+	}
+
+	return database.Subscriber{
+		ChatID:   int64(message.Sender.ID),
+		Type:     string(tb.ChatPrivate),
+		Username: message.Sender.Username,
+	}
+}
+
+func (operator *Operator) SetTimezone(message *tb.Message) error {
+	language := operator.getLanguage(message)
+	usage := i18n.Translate(language, i18n.TEXT_ABOUT_TIMEZONE_USAGE)
+	timezone := strings.TrimSpace(message.Payload)
+	if timezone == "" {
+		return operator.transport.SendMessage(message.Chat, usage)
+	}
+
+	_, err := tools.LoadLocation(timezone)
+	if err != nil {
+		return operator.transport.SendMessage(message.Chat, usage)
+	}
+
+	err = operator.database.SetChatTimezone(message.Chat.ID, timezone)
+	if err != nil {
+		return err
+	}
+
+	return operator.transport.SendMessage(
+		message.Chat,
+		i18n.Translate(language, i18n.TEXT_ABOUT_TIMEZONE_CHANGED, timezone),
+	)
+}
+
+func (operator *Operator) Tomorrow(message *tb.Message) error {
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return err
+	}
+
+	return operator.sendEventsForDay(
+		message.Chat,
+		timeNow.AddDate(0, 0, 1),
+		operator.getLanguage(message),
+	)
+}
+
+func (operator *Operator) sendEventsForDay(
+	chat *tb.Chat,
+	day time.Time,
+	language string,
+) error {
+	events, err := operator.database.GetUpcomingEventsForDay(day)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to get events for day: %s",
+			day.Format("2006-01-02"),
+		)
+	}
+
+	text := getTextAboutEventsForDay(
+		events,
+		day,
+		operator.delivery.GetLocation(chat.ID),
+		language,
+	)
+
+	return operator.sendPages(chat, text)
+}
+
+func getTextAboutEventsForDay(
+	events []requester.EventWithOdds,
+	day time.Time,
+	location *time.Location,
+	language string,
+) string {
+	date := i18n.FormatDate(language, day.In(location))
+	if len(events) == 0 {
+		return i18n.Translate(language, i18n.TEXT_ABOUT_NO_EVENTS_FOR_DAY, date)
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].EventStartTime.Before(events[j].EventStartTime)
+	})
+
+	text := i18n.Translate(language, i18n.TEXT_ABOUT_EVENTS_FOR_DAY, date)
+	for _, event := range events {
+		text += i18n.Translate(
+			language,
+			i18n.TEXT_ABOUT_EVENT_FOR_DAY,
+			event.EventStartTime.In(location).Format("15:04"),
+			event.HomeCommandName,
+			event.AwayCommandName,
+			event.League.Name,
+			translateFavorite(language, event.Favorite),
+			i18n.FormatNumber(language, event.HomeOdd, 2),
+			i18n.FormatNumber(language, event.AwayOdd, 2),
+		)
+	}
+
+	return text
+}
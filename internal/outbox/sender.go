@@ -0,0 +1,415 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/config"
+	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/daniilsolovey/BetBotGo/internal/delivery"
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+	"github.com/daniilsolovey/BetBotGo/internal/transport"
+	"github.com/reconquest/karma-go"
+	"github.com/reconquest/pkg/log"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+const (
+	GLOBAL_RATE_WINDOW       = time.Second
+	QUEUE_DEPTH_LOG_INTERVAL = time.Minute
+	CLEANUP_INTERVAL         = time.Hour
+)
+
+// Sender sends queued messages to telegram keeping per-chat and global rate
+// limits, messages are retried with backoff until they are sent or failed
+// permanently. Sending to all chats is paused when telegram asks to retry
+// after a while. Photo is uploaded once and sent to other chats by file id.
+type Sender struct {
+	config    config.Outbox
+	database  database.DatabaseInterface
+	transport transport.Transport
+	isLeader  func() bool
+
+	chatSentAt       map[int64]time.Time
+	globalSentAt     []time.Time
+	pausedUntil      time.Time
+	queueDepthLogged time.Time
+	cleanedUp        time.Time
+	photoFileIDs     map[int64]string
+}
+
+func NewSender(
+	config *config.Config,
+	database database.DatabaseInterface,
+	transport transport.Transport,
+	isLeader func() bool,
+) *Sender {
+	return &Sender{
+		config:       config.Outbox,
+		database:     database,
+		transport:    transport,
+		isLeader:     isLeader,
+		chatSentAt:   map[int64]time.Time{},
+		photoFileIDs: map[int64]string{},
+	}
+}
+
+func (sender *Sender) Run(ctx context.Context) {
+	log.Info("start cycle with sending outbox messages")
+	for {
+		if sender.isLeader() {
+			err := sender.SendPending(ctx)
+			if err != nil {
+				log.Error(err)
+			}
+
+			if tools.TimeNow().Sub(sender.queueDepthLogged) >= QUEUE_DEPTH_LOG_INTERVAL {
+				sender.LogQueueDepth()
+				sender.queueDepthLogged = tools.TimeNow()
+			}
+
+			if tools.TimeNow().Sub(sender.cleanedUp) >= CLEANUP_INTERVAL {
+				sender.CleanUp()
+				sender.cleanedUp = tools.TimeNow()
+			}
+		}
+
+		if !tools.Sleep(ctx, sender.config.PollInterval) {
+			log.Info("cycle with sending outbox messages stopped")
+			return
+		}
+	}
+}
+
+// SendPending sends one batch of due messages. Messages to the chat which has
+// been written to recently are left in the queue until the next call, the
+// database returns only the oldest pending message of every chat, so messages
+// of one chat are sent in the queue order.
+func (sender *Sender) SendPending(ctx context.Context) error {
+	if tools.TimeNow().Before(sender.pausedUntil) {
+		return nil
+	}
+
+	messages, err := sender.database.GetPendingOutboxMessages(
+		tools.TimeNow(),
+		sender.config.BatchSize,
+	)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to get pending outbox messages",
+		)
+	}
+
+	sender.forgetIdleChats()
+
+	postponedChats := map[int64]bool{}
+	for _, message := range messages {
+		if postponedChats[message.ChatID] {
+			continue
+		}
+
+		if !isTargetReady(message) {
+			continue
+		}
+
+		if !sender.isChatReady(message.ChatID) {
+			postponedChats[message.ChatID] = true
+			continue
+		}
+
+		if !sender.waitGlobalLimit(ctx) {
+			return nil
+		}
+
+		sent := sender.send(message)
+		if !sent {
+			postponedChats[message.ChatID] = true
+		}
+
+		if tools.TimeNow().Before(sender.pausedUntil) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// CleanUp deletes sent and failed messages older than outbox.retention and
+// photos which are not referred to by remaining messages.
+func (sender *Sender) CleanUp() {
+	if sender.config.Retention <= 0 {
+		return
+	}
+
+	before := tools.TimeNow().Add(-sender.config.Retention)
+	deleted, err := sender.database.DeleteOldOutboxMessages(before)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	if deleted != 0 {
+		log.Infof(nil, "%d old outbox messages deleted", deleted)
+	}
+
+	deleted, err = sender.database.DeleteUnusedOutboxPhotos(before)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	if deleted != 0 {
+		log.Infof(nil, "%d unused outbox photos deleted", deleted)
+	}
+
+	sender.photoFileIDs = map[int64]string{}
+}
+
+func (sender *Sender) LogQueueDepth() {
+	counts, err := sender.database.CountOutboxMessages()
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	log.Infof(
+		karma.
+			Describe("pending", counts[database.OUTBOX_STATUS_PENDING]).
+			Describe("failed", counts[database.OUTBOX_STATUS_FAILED]),
+		"outbox queue depth",
+	)
+}
+
+func (sender *Sender) send(message database.OutboxMessage) bool {
+	if message.EditOfID != 0 && message.EditOfStatus != database.OUTBOX_STATUS_SENT {
+		err := sender.database.MarkOutboxMessageFailed(message.ID, "edited message has not been sent")
+		if err != nil {
+			log.Error(err)
+		}
+
+		return true
+	}
+
+	recipient := delivery.GetRecipient(message.ChatID)
+
+	var messageID int
+	var err error
+	if message.EditOfID != 0 {
+		messageID = message.EditOfMessageID
+		err = sender.transport.EditMessage(recipient, messageID, message.Text, getSendOptions(message))
+		if err != nil && transport.IsMessageNotModified(err) {
+			err = nil
+		}
+	} else if message.PhotoID != 0 {
+		messageID, err = sender.sendPhoto(recipient, message)
+	} else {
+		messageID, err = sender.transport.SendMessageWithOptions(
+			recipient,
+			message.Text,
+			getSendOptions(message),
+		)
+	}
+
+	timeNow := tools.TimeNow()
+	sender.chatSentAt[message.ChatID] = timeNow
+	sender.globalSentAt = append(sender.globalSentAt, timeNow)
+
+	if err == nil {
+		err = sender.database.MarkOutboxMessageSent(message.ID, messageID, timeNow)
+		if err != nil {
+			log.Error(err)
+		}
+
+		return true
+	}
+
+	sender.handleSendError(message, err, timeNow)
+	return false
+}
+
+// sendPhoto uploads the photo only if it has not been uploaded for another
+// chat, file id is remembered for messages of the current batch too.
+func (sender *Sender) sendPhoto(
+	recipient tb.Recipient,
+	message database.OutboxMessage,
+) (int, error) {
+	fileID := message.PhotoFileID
+	if fileID == "" {
+		fileID = sender.photoFileIDs[message.PhotoID]
+	}
+
+	messageID, uploadedFileID, err := sender.transport.SendPhoto(
+		recipient,
+		transport.Photo{Data: message.Photo, FileID: fileID},
+		message.Text,
+		getSendOptions(message),
+	)
+	if err != nil || fileID != "" || uploadedFileID == "" {
+		return messageID, err
+	}
+
+	sender.photoFileIDs[message.PhotoID] = uploadedFileID
+	err = sender.database.SetOutboxPhotoFileID(message.PhotoID, uploadedFileID)
+	if err != nil {
+		log.Error(err)
+	}
+
+	return messageID, nil
+}
+
+func (sender *Sender) handleSendError(
+	message database.OutboxMessage,
+	sendErr error,
+	timeNow time.Time,
+) {
+	details := karma.
+		Describe("outbox_id", message.ID).
+		Describe("chat_id", message.ChatID).
+		Describe("attempts", message.Attempts+1)
+
+	if retryAfter, ok := transport.GetRetryAfter(sendErr); ok {
+		log.Warningf(
+			details.Describe("retry_after", retryAfter.String()).Reason(sendErr),
+			"telegram flood control, sending is paused",
+		)
+
+		sender.pausedUntil = timeNow.Add(retryAfter)
+
+		err := sender.database.MarkOutboxMessageRetry(
+			message.ID,
+			timeNow.Add(retryAfter),
+			sendErr.Error(),
+		)
+		if err != nil {
+			log.Error(err)
+		}
+
+		return
+	}
+
+	if transport.IsPermanentError(sendErr) || message.Attempts+1 >= sender.config.MaxAttempts {
+		log.Errorf(details.Reason(sendErr), "unable to send outbox message, marked as failed")
+
+		err := sender.database.MarkOutboxMessageFailed(message.ID, sendErr.Error())
+		if err != nil {
+			log.Error(err)
+		}
+
+		if transport.IsChatUnavailable(sendErr) {
+			sender.deactivateSubscriber(message.ChatID)
+		}
+
+		return
+	}
+
+	log.Warningf(details.Reason(sendErr), "unable to send outbox message, will retry")
+
+	err := sender.database.MarkOutboxMessageRetry(
+		message.ID,
+		timeNow.Add(getRetryBackoff(sender.config, message.Attempts)),
+		sendErr.Error(),
+	)
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+func (sender *Sender) deactivateSubscriber(chatID int64) {
+	deactivated, err := sender.database.DeactivateSubscriber(chatID)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	if deactivated {
+		log.Infof(nil, "subscriber is unavailable and deactivated, chat_id: %d", chatID)
+	}
+}
+
+func (sender *Sender) isChatReady(chatID int64) bool {
+	sentAt, ok := sender.chatSentAt[chatID]
+	if !ok {
+		return true
+	}
+
+	return tools.TimeNow().Sub(sentAt) >= sender.config.PerChatInterval
+}
+
+func (sender *Sender) forgetIdleChats() {
+	timeNow := tools.TimeNow()
+	for chatID, sentAt := range sender.chatSentAt {
+		if timeNow.Sub(sentAt) >= sender.config.PerChatInterval {
+			delete(sender.chatSentAt, chatID)
+		}
+	}
+}
+
+// waitGlobalLimit sleeps until one more message is allowed to be sent within
+// the global rate window, false is returned when context is canceled.
+func (sender *Sender) waitGlobalLimit(ctx context.Context) bool {
+	if sender.config.GlobalPerSecond <= 0 {
+		return true
+	}
+
+	for {
+		timeNow := tools.TimeNow()
+		for len(sender.globalSentAt) != 0 &&
+			timeNow.Sub(sender.globalSentAt[0]) >= GLOBAL_RATE_WINDOW {
+			sender.globalSentAt = sender.globalSentAt[1:]
+		}
+
+		if len(sender.globalSentAt) < sender.config.GlobalPerSecond {
+			return true
+		}
+
+		wait := GLOBAL_RATE_WINDOW - timeNow.Sub(sender.globalSentAt[0])
+		if !tools.Sleep(ctx, wait) {
+			return false
+		}
+	}
+}
+
+// isTargetReady returns false while the message which is replied to or edited
+// is still queued, reply to failed message is sent as usual message.
+func isTargetReady(message database.OutboxMessage) bool {
+	if message.ReplyToID != 0 && message.ReplyToStatus == database.OUTBOX_STATUS_PENDING {
+		return false
+	}
+
+	if message.EditOfID != 0 && message.EditOfStatus == database.OUTBOX_STATUS_PENDING {
+		return false
+	}
+
+	return true
+}
+
+func getSendOptions(message database.OutboxMessage) *tb.SendOptions {
+	options := &tb.SendOptions{}
+	if message.HTML {
+		options.ParseMode = tb.ModeHTML
+	}
+
+	if len(message.Buttons) != 0 {
+		options.ReplyMarkup = &tb.ReplyMarkup{InlineKeyboard: message.Buttons}
+	}
+
+	if message.ReplyToMessageID != 0 {
+		options.ReplyTo = &tb.Message{ID: message.ReplyToMessageID}
+	}
+
+	return options
+}
+
+func getRetryBackoff(config config.Outbox, attempts int) time.Duration {
+	backoff := config.RetryBackoff
+	for i := 0; i < attempts && backoff < config.MaxRetryBackoff; i++ {
+		backoff *= 2
+	}
+
+	if config.MaxRetryBackoff > 0 && backoff > config.MaxRetryBackoff {
+		return config.MaxRetryBackoff
+	}
+
+	return backoff
+}
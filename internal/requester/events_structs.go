@@ -47,6 +47,7 @@ type EventWithOdds struct {
 	Favorite          string
 	HomeCommandName   string
 	AwayCommandName   string
+	HomeCommandCC     string
 	HomeOdd           float64
 	AwayOdd           float64
 	WinnerInSecondSet string
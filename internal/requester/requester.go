@@ -112,6 +112,7 @@ func (requester *Requester) GetEventOddsByEventIDs(events *UpcomingEvents) ([]Ev
 		eventWithOdds.EventID = event.ID
 		eventWithOdds.HomeCommandName = event.Home.Name
 		eventWithOdds.AwayCommandName = event.Away.Name
+		eventWithOdds.HomeCommandCC = event.Home.CC
 
 		result = append(result, eventWithOdds)
 		response.Body.Close()
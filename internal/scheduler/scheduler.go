@@ -0,0 +1,239 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/config"
+	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/daniilsolovey/BetBotGo/internal/operator"
+	"github.com/daniilsolovey/BetBotGo/internal/statistics"
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+	"github.com/reconquest/pkg/log"
+)
+
+const (
+	RECEIVING_EVENTS_DURATION     = 5 * time.Minute
+	SUBSCRIPTIONS_EXPIRY_DURATION = time.Hour
+	ERROR_NOTIFY_INTERVAL         = 30 * time.Minute
+)
+
+type Scheduler struct {
+	config     *config.Config
+	database   database.DatabaseInterface
+	operator   *operator.Operator
+	statistics *statistics.Statistics
+	isLeader   func() bool
+
+	errorsMutex      sync.Mutex
+	errorsNotifiedAt map[string]time.Time
+}
+
+func NewScheduler(
+	config *config.Config,
+	database database.DatabaseInterface,
+	operator *operator.Operator,
+	statistics *statistics.Statistics,
+	isLeader func() bool,
+) *Scheduler {
+	return &Scheduler{
+		config:     config,
+		database:   database,
+		operator:   operator,
+		statistics: statistics,
+		isLeader:   isLeader,
+
+		errorsNotifiedAt: map[string]time.Time{},
+	}
+}
+
+func (scheduler *Scheduler) Start(ctx context.Context, wg *sync.WaitGroup) {
+	for _, loop := range []func(context.Context){
+		scheduler.runReceivingEvents,
+		scheduler.runStatisticOnPreviousDay,
+		scheduler.runStatisticOnPreviousWeek,
+		scheduler.runSubscriptionsExpiry,
+	} {
+		loop := loop
+		wg.Add(1)
+		tools.Go(func() {
+			defer wg.Done()
+			loop(ctx)
+		})
+	}
+}
+
+func (scheduler *Scheduler) ReceiveEvents() {
+	eventsWithOdds, err := scheduler.operator.GetEventsWithOdds()
+	if err != nil {
+		scheduler.handleError(err)
+	}
+
+	err = scheduler.operator.RefreshLeagues(eventsWithOdds)
+	if err != nil {
+		scheduler.handleError(err)
+	}
+
+	err = scheduler.operator.RefreshEventRules()
+	if err != nil {
+		scheduler.handleError(err)
+	}
+
+	scheduler.operator.HandleOddsDrift(eventsWithOdds)
+
+	events, err := scheduler.operator.SelectEvents(eventsWithOdds)
+	if err != nil {
+		scheduler.handleError(err)
+	}
+
+	handledEvents := scheduler.operator.ApplyEventRules(
+		eventsWithOdds,
+		scheduler.operator.HandleEventsByLeagues(events),
+	)
+
+	err = scheduler.database.InsertEventsForToday(handledEvents)
+	if err != nil {
+		scheduler.handleError(err)
+	}
+
+	err = scheduler.operator.CreateRoutinesForHandleLiveEvents(handledEvents)
+	if err != nil {
+		scheduler.handleError(err)
+	}
+
+	scheduler.operator.SettleBets()
+
+	err = scheduler.operator.SendDigest()
+	if err != nil {
+		scheduler.handleError(err)
+	}
+}
+
+func (scheduler *Scheduler) runReceivingEvents(ctx context.Context) {
+	log.Info("start cycle with receiving upcoming events")
+	wasLeader := false
+	for {
+		if !scheduler.isLeader() {
+			if wasLeader {
+				scheduler.operator.ResetRoutineCache()
+				wasLeader = false
+			}
+
+			log.Debug("instance is follower, skipping receiving upcoming events")
+			if !tools.Sleep(ctx, scheduler.config.Leader.RenewInterval) {
+				log.Info("cycle with receiving upcoming events stopped")
+				return
+			}
+
+			continue
+		}
+
+		wasLeader = true
+		scheduler.ReceiveEvents()
+
+		if !tools.Sleep(ctx, RECEIVING_EVENTS_DURATION) {
+			log.Info("cycle with receiving upcoming events stopped")
+			return
+		}
+	}
+}
+
+func (scheduler *Scheduler) runStatisticOnPreviousDay(ctx context.Context) {
+	log.Info("start cycle with receiving statistic on previous day")
+	for {
+		if !tools.Sleep(ctx, getWaitingTimeUntilNextDay()) {
+			log.Info("cycle with receiving statistic on previous day stopped")
+			return
+		}
+
+		if !scheduler.isLeader() {
+			log.Info("instance is follower, skipping statistic on previous day")
+			continue
+		}
+
+		err := scheduler.statistics.GetStatisticOnPreviousDayAndNotify()
+		if err != nil {
+			scheduler.handleError(err)
+		}
+	}
+}
+
+func (scheduler *Scheduler) runStatisticOnPreviousWeek(ctx context.Context) {
+	log.Info("start cycle with receiving statistic on previous week")
+	for {
+		timeNow, err := tools.GetCurrentTime()
+		if err != nil {
+			scheduler.handleError(err)
+		}
+
+		if timeNow.Weekday() == time.Monday && scheduler.isLeader() {
+			err = scheduler.statistics.GetStatisticOnPreviousWeekAndNotify()
+			if err != nil {
+				scheduler.handleError(err)
+			}
+		}
+
+		if !tools.Sleep(ctx, getWaitingTimeUntilNextDay()) {
+			log.Info("cycle with receiving statistic on previous week stopped")
+			return
+		}
+	}
+}
+
+func (scheduler *Scheduler) runSubscriptionsExpiry(ctx context.Context) {
+	log.Info("start cycle with handling subscriptions expiry")
+	for {
+		if scheduler.isLeader() {
+			err := scheduler.operator.HandleSubscriptionsExpiry()
+			if err != nil {
+				scheduler.handleError(err)
+			}
+		}
+
+		if !tools.Sleep(ctx, SUBSCRIPTIONS_EXPIRY_DURATION) {
+			log.Info("cycle with handling subscriptions expiry stopped")
+			return
+		}
+	}
+}
+
+// handleError logs error and notifies about it, the same error repeated by
+// every cycle is notified once in ERROR_NOTIFY_INTERVAL.
+func (scheduler *Scheduler) handleError(err error) {
+	log.Error(err)
+
+	if !scheduler.shouldNotifyError(err) {
+		return
+	}
+
+	scheduler.operator.NotifyError(err)
+}
+
+func (scheduler *Scheduler) shouldNotifyError(err error) bool {
+	scheduler.errorsMutex.Lock()
+	defer scheduler.errorsMutex.Unlock()
+
+	timeNow := tools.TimeNow()
+	for text, notifiedAt := range scheduler.errorsNotifiedAt {
+		if timeNow.Sub(notifiedAt) >= ERROR_NOTIFY_INTERVAL {
+			delete(scheduler.errorsNotifiedAt, text)
+		}
+	}
+
+	if _, ok := scheduler.errorsNotifiedAt[err.Error()]; ok {
+		return false
+	}
+
+	scheduler.errorsNotifiedAt[err.Error()] = timeNow
+	return true
+}
+
+func getWaitingTimeUntilNextDay() time.Duration {
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		log.Error(err)
+	}
+
+	return tools.BeginningOfDay(timeNow).AddDate(0, 0, 1).Sub(timeNow)
+}
@@ -0,0 +1,132 @@
+package simulation
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/requester"
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+	"github.com/reconquest/karma-go"
+)
+
+type Match struct {
+	ID         string
+	LeagueID   string
+	LeagueName string
+	LeagueCC   string
+	Home       string
+	Away       string
+	HomeCC     string
+	StartTime  time.Time
+	HomeOdd    string
+	AwayOdd    string
+	Timeline   []Snapshot
+}
+
+// Snapshot describes live odds and score since specified time after start of
+// the match until the next snapshot.
+type Snapshot struct {
+	After   time.Duration
+	HomeOdd string
+	AwayOdd string
+	Score   string
+}
+
+// BetApi returns scripted matches, live odds depend on current virtual
+// time. UpcomingEventsErr is returned instead of upcoming events when set.
+type BetApi struct {
+	Matches           []Match
+	UpcomingEventsErr error
+}
+
+func (betApi *BetApi) GetUpcomingEvents() (*requester.UpcomingEvents, error) {
+	if betApi.UpcomingEventsErr != nil {
+		return nil, betApi.UpcomingEventsErr
+	}
+
+	var result requester.UpcomingEvents
+	for _, match := range betApi.Matches {
+		result.Results = append(result.Results, requester.Result{
+			ID:   match.ID,
+			Time: strconv.FormatInt(match.StartTime.Unix(), 10),
+			League: requester.League{
+				ID:   match.LeagueID,
+				Name: match.LeagueName,
+				CC:   match.LeagueCC,
+			},
+			Home: requester.Home{Name: match.Home, CC: match.HomeCC},
+			Away: requester.Away{Name: match.Away},
+		})
+	}
+
+	return &result, nil
+}
+
+func (betApi *BetApi) GetEventOddsByEventIDs(
+	events *requester.UpcomingEvents,
+) ([]requester.EventWithOdds, error) {
+	var result []requester.EventWithOdds
+	for _, event := range events.Results {
+		match, err := betApi.getMatch(event.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		var eventWithOdds requester.EventWithOdds
+		eventWithOdds.ResultEventWithOdds.Odds.Odds91_1 = []requester.OddsNumber{
+			{HomeOd: match.HomeOdd, AwayOd: match.AwayOdd},
+		}
+		eventWithOdds.League = event.League
+		eventWithOdds.EventStartTime = event.HumanTime
+		eventWithOdds.EventID = event.ID
+		eventWithOdds.HomeCommandName = event.Home.Name
+		eventWithOdds.AwayCommandName = event.Away.Name
+		eventWithOdds.HomeCommandCC = event.Home.CC
+
+		result = append(result, eventWithOdds)
+	}
+
+	return result, nil
+}
+
+func (betApi *BetApi) GetLiveEventByID(eventID string) (*requester.EventWithOdds, error) {
+	match, err := betApi.getMatch(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	var eventWithOdds requester.EventWithOdds
+	eventWithOdds.EventID = eventID
+	eventWithOdds.ResultEventWithOdds.Odds.Odds91_1 = []requester.OddsNumber{
+		{HomeOd: match.HomeOdd, AwayOd: match.AwayOdd},
+	}
+
+	elapsed := tools.TimeNow().Sub(match.StartTime)
+	for _, snapshot := range match.Timeline {
+		if snapshot.After > elapsed {
+			break
+		}
+
+		eventWithOdds.ResultEventWithOdds.Odds.Odds91_1[0] = requester.OddsNumber{
+			HomeOd: snapshot.HomeOdd,
+			AwayOd: snapshot.AwayOdd,
+			SS:     snapshot.Score,
+		}
+	}
+
+	return &eventWithOdds, nil
+}
+
+func (betApi *BetApi) getMatch(eventID string) (*Match, error) {
+	for i := range betApi.Matches {
+		if betApi.Matches[i].ID == eventID {
+			return &betApi.Matches[i], nil
+		}
+	}
+
+	return nil, karma.Format(
+		nil,
+		"match not found, event_id: %s",
+		eventID,
+	)
+}
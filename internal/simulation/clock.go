@@ -0,0 +1,143 @@
+package simulation
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+)
+
+// Clock is a virtual clock with cooperative scheduler: routines started with
+// Go are running one at a time and virtual time moves forward only when all of
+// them are sleeping, so the same scenario always produces the same result.
+type Clock struct {
+	mutex    sync.Mutex
+	now      time.Time
+	runnable []*task
+	sleeping []*task
+	yield    chan struct{}
+}
+
+type task struct {
+	ctx      context.Context
+	wakeTime time.Time
+	wake     chan struct{}
+}
+
+func NewClock(now time.Time) *Clock {
+	return &Clock{
+		now:   now,
+		yield: make(chan struct{}),
+	}
+}
+
+// Install replaces time functions from tools package, returned function
+// restores previous ones.
+func (clock *Clock) Install() func() {
+	timeNow, sleep, goroutine := tools.TimeNow, tools.Sleep, tools.Go
+	tools.TimeNow = clock.Now
+	tools.Sleep = clock.Sleep
+	tools.Go = clock.Go
+
+	return func() {
+		tools.TimeNow, tools.Sleep, tools.Go = timeNow, sleep, goroutine
+	}
+}
+
+func (clock *Clock) Now() time.Time {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+
+	return clock.now
+}
+
+func (clock *Clock) Go(routine func()) {
+	task := newTask(context.Background(), time.Time{})
+
+	clock.mutex.Lock()
+	clock.runnable = append(clock.runnable, task)
+	clock.mutex.Unlock()
+
+	go func() {
+		<-task.wake
+		defer func() {
+			clock.yield <- struct{}{}
+		}()
+
+		routine()
+	}()
+}
+
+// Sleep must be called only from routines started with Go.
+func (clock *Clock) Sleep(ctx context.Context, duration time.Duration) bool {
+	clock.mutex.Lock()
+	task := newTask(ctx, clock.now.Add(duration))
+	clock.sleeping = append(clock.sleeping, task)
+	sort.SliceStable(clock.sleeping, func(i, j int) bool {
+		return clock.sleeping[i].wakeTime.Before(clock.sleeping[j].wakeTime)
+	})
+	clock.mutex.Unlock()
+
+	clock.yield <- struct{}{}
+	<-task.wake
+
+	return ctx.Err() == nil
+}
+
+// Run executes routines until all of them are finished or sleeping after
+// specified time, then sets virtual time to it.
+func (clock *Clock) Run(until time.Time) {
+	for {
+		task := clock.next(until)
+		if task == nil {
+			return
+		}
+
+		close(task.wake)
+		<-clock.yield
+	}
+}
+
+func (clock *Clock) next(until time.Time) *task {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+
+	if len(clock.runnable) != 0 {
+		task := clock.runnable[0]
+		clock.runnable = clock.runnable[1:]
+		return task
+	}
+
+	for i, task := range clock.sleeping {
+		if task.ctx.Err() != nil {
+			clock.sleeping = append(clock.sleeping[:i], clock.sleeping[i+1:]...)
+			return task
+		}
+	}
+
+	if len(clock.sleeping) == 0 || clock.sleeping[0].wakeTime.After(until) {
+		if until.After(clock.now) {
+			clock.now = until
+		}
+
+		return nil
+	}
+
+	task := clock.sleeping[0]
+	clock.sleeping = clock.sleeping[1:]
+	if task.wakeTime.After(clock.now) {
+		clock.now = task.wakeTime
+	}
+
+	return task
+}
+
+func newTask(ctx context.Context, wakeTime time.Time) *task {
+	return &task{
+		ctx:      ctx,
+		wakeTime: wakeTime,
+		wake:     make(chan struct{}),
+	}
+}
@@ -0,0 +1,128 @@
+package simulation
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/config"
+	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/daniilsolovey/BetBotGo/internal/delivery"
+	"github.com/daniilsolovey/BetBotGo/internal/operator"
+	"github.com/daniilsolovey/BetBotGo/internal/outbox"
+	"github.com/daniilsolovey/BetBotGo/internal/scheduler"
+	"github.com/daniilsolovey/BetBotGo/internal/statistics"
+	"github.com/daniilsolovey/BetBotGo/internal/templates"
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+)
+
+const (
+	RECIPIENT_ID = 1
+)
+
+// Simulation wires operator, statistics, scheduler and outbox sender onto
+// virtual clock, scripted bet api, in-memory store and recording transport.
+type Simulation struct {
+	Clock      *Clock
+	Store      *Store
+	BetApi     *BetApi
+	Transport  *Transport
+	Operator   *operator.Operator
+	Statistics *statistics.Statistics
+	Scheduler  *scheduler.Scheduler
+	Sender     *outbox.Sender
+	context    context.Context
+	cancel     context.CancelFunc
+	loops      sync.WaitGroup
+	restore    func()
+}
+
+func NewSimulation(
+	config *config.Config,
+	startTime time.Time,
+	matches []Match,
+) *Simulation {
+	clock := NewClock(startTime)
+	store := NewStore()
+	store.Subscribers = []database.Subscriber{
+		{ChatID: RECIPIENT_ID, Type: "private", IsActive: true, JoinedAt: startTime},
+	}
+	betApi := &BetApi{Matches: matches}
+	transport := &Transport{}
+
+	messageTemplates, err := templates.NewTemplates(config.Templates.Directory)
+	if err != nil {
+		panic(err)
+	}
+
+	broadcastRoutes, err := delivery.GetBroadcastRoutes(config.Broadcast)
+	if err != nil {
+		panic(err)
+	}
+
+	newOperator := operator.NewOperator(config, store, betApi, transport, messageTemplates)
+	newStatistics := statistics.NewStatistics(store)
+	newOperator.SetBroadcastRoutes(broadcastRoutes)
+	newStatistics.SetBroadcastRoutes(broadcastRoutes)
+
+	err = newOperator.BootstrapRoles()
+	if err != nil {
+		panic(err)
+	}
+
+	isLeader := func() bool {
+		return true
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Simulation{
+		Clock:      clock,
+		Store:      store,
+		BetApi:     betApi,
+		Transport:  transport,
+		Operator:   newOperator,
+		Statistics: newStatistics,
+		Scheduler: scheduler.NewScheduler(
+			config, store, newOperator, newStatistics, isLeader,
+		),
+		Sender:  outbox.NewSender(config, store, transport, isLeader),
+		context: ctx,
+		cancel:  cancel,
+		restore: installGlobals(clock, config.Timezone),
+	}
+}
+
+func (simulation *Simulation) Start() {
+	simulation.Scheduler.Start(simulation.context, &simulation.loops)
+
+	simulation.loops.Add(1)
+	tools.Go(func() {
+		defer simulation.loops.Done()
+		simulation.Sender.Run(simulation.context)
+	})
+}
+
+func (simulation *Simulation) RunUntil(until time.Time) {
+	simulation.Clock.Run(until)
+}
+
+// Stop cancels all loops and routines, waits until they are finished and
+// restores global state.
+func (simulation *Simulation) Stop() {
+	simulation.cancel()
+	simulation.Operator.Stop()
+	simulation.Clock.Run(simulation.Clock.Now())
+	simulation.loops.Wait()
+	simulation.restore()
+}
+
+func installGlobals(clock *Clock, timezone string) func() {
+	restoreClock := clock.Install()
+	previousTimezone := tools.Timezone
+	tools.Timezone = timezone
+
+	return func() {
+		restoreClock()
+		tools.Timezone = previousTimezone
+	}
+}
@@ -0,0 +1,1268 @@
+package simulation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert"
+	"github.com/daniilsolovey/BetBotGo/internal/config"
+	"github.com/daniilsolovey/BetBotGo/internal/constants"
+	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/daniilsolovey/BetBotGo/internal/delivery"
+	"github.com/daniilsolovey/BetBotGo/internal/i18n"
+	"github.com/daniilsolovey/BetBotGo/internal/operator"
+	"github.com/daniilsolovey/BetBotGo/internal/requester"
+	"github.com/daniilsolovey/BetBotGo/internal/statistics"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+func getTestConfig() *config.Config {
+	var testConfig config.Config
+	testConfig.Timezone = "Europe/Moscow"
+	testConfig.OddsDrift.Threshold = 0.15
+	testConfig.Signals.InvalidationWindow = 15 * time.Minute
+	testConfig.Signals.UpdateThreshold = 0.2
+	testConfig.Signals.EditInterval = 30 * time.Second
+	testConfig.Discovery.Lookahead = 24 * time.Hour
+	testConfig.Discovery.MonitoringHorizon = 6 * time.Hour
+	testConfig.Leader.RenewInterval = 10 * time.Second
+	testConfig.Outbox.PollInterval = time.Second
+	testConfig.Outbox.BatchSize = 100
+	testConfig.Outbox.PerChatInterval = time.Second
+	testConfig.Outbox.GlobalPerSecond = 30
+	testConfig.Outbox.MaxAttempts = 3
+	testConfig.Outbox.RetryBackoff = 5 * time.Second
+	testConfig.Outbox.MaxRetryBackoff = time.Minute
+	testConfig.Access.CodeValidity = 30 * 24 * time.Hour
+	return &testConfig
+}
+
+func getTestMatches(day time.Time) []Match {
+	return []Match{
+		{
+			ID:         "1",
+			LeagueID:   "10",
+			LeagueName: "Italy A1",
+			LeagueCC:   "it",
+			Home:       "Modena",
+			Away:       "Verona",
+			StartTime:  day.Add(18 * time.Hour),
+			HomeOdd:    "1.20",
+			AwayOdd:    "4.00",
+			Timeline: []Snapshot{
+				{After: 0, HomeOdd: "1.15", AwayOdd: "5.00", Score: "3-2"},
+				{After: 25 * time.Minute, HomeOdd: "1.70", AwayOdd: "2.10", Score: "20-25,3-2"},
+				{After: 50 * time.Minute, HomeOdd: "1.10", AwayOdd: "6.00", Score: "20-25,25-20,1-0"},
+			},
+		},
+		{
+			ID:         "2",
+			LeagueID:   "20",
+			LeagueName: "Poland Plus Liga",
+			LeagueCC:   "pl",
+			Home:       "Jastrzebski",
+			Away:       "Cuprum",
+			StartTime:  day.Add(19 * time.Hour),
+			HomeOdd:    "1.25",
+			AwayOdd:    "3.50",
+			Timeline: []Snapshot{
+				{After: 25 * time.Minute, HomeOdd: "1.05", AwayOdd: "8.00", Score: "25-20,5-3"},
+				{After: 50 * time.Minute, HomeOdd: "1.01", AwayOdd: "9.00", Score: "25-20,25-18,2-1"},
+			},
+		},
+		{
+			ID:         "3",
+			LeagueID:   "20",
+			LeagueName: "Poland Plus Liga",
+			LeagueCC:   "pl",
+			Home:       "Olsztyn",
+			Away:       "Katowice",
+			StartTime:  day.Add(20 * time.Hour),
+			HomeOdd:    "1.80",
+			AwayOdd:    "1.95",
+			Timeline: []Snapshot{
+				{After: 25 * time.Minute, HomeOdd: "2.50", AwayOdd: "1.40", Score: "20-25,3-2"},
+			},
+		},
+	}
+}
+
+func getTexts(messages []Message) []string {
+	var texts []string
+	for _, message := range messages {
+		texts = append(texts, message.Text)
+	}
+
+	return texts
+}
+
+func TestSimulation_MatchDay_SendsSignalAndStatistics(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	sunday := time.Date(2021, 9, 5, 0, 0, 0, 0, location)
+	simulation := NewSimulation(
+		getTestConfig(),
+		sunday.Add(10*time.Hour),
+		getTestMatches(sunday),
+	)
+
+	simulation.Start()
+	simulation.RunUntil(sunday.Add(24*time.Hour + 10*time.Minute))
+	simulation.Stop()
+
+	assert.Equal(t, 2, len(simulation.Store.Events))
+	assert.Equal(t, 2, len(simulation.Store.Leagues))
+
+	assert.Equal(t, 1, len(simulation.Store.Signals))
+	signal := simulation.Store.Signals[0]
+	assert.Equal(t, "1", signal.EventID)
+	assert.Equal(t, constants.SIGNAL_TYPE_BET, signal.SignalType)
+	assert.False(t, signal.CreatedAt.Before(sunday.Add(18*time.Hour+25*time.Minute)))
+	assert.True(t, signal.CreatedAt.Before(sunday.Add(18*time.Hour+26*time.Minute)))
+
+	assert.Equal(t, 1, len(simulation.Store.LiveEventsResults))
+	liveEventResult := simulation.Store.LiveEventsResults[0]
+	assert.Equal(t, "1", liveEventResult.EventID)
+	assert.Equal(t, "20-25,25-20,1-0", liveEventResult.Score)
+	assert.Equal(t, constants.WINNER_HOME, liveEventResult.WinnerInSecondSet)
+
+	assert.Equal(t, 1, len(simulation.Store.Statistic))
+	assert.Equal(t, "1", simulation.Store.Statistic[0].EventID)
+	assert.Equal(t, statistics.PLAYER_IS_WIN, simulation.Store.Statistic[0].PlayerIsWin)
+
+	messages := simulation.Transport.GetMessages()
+	assert.Equal(t, 7, len(messages))
+	assert.True(t, strings.Contains(messages[0].Text, "<code>#1</code>"))
+	assert.Equal(t, tb.ModeHTML, messages[0].ParseMode)
+	assert.Equal(t, "1", messages[0].Buttons[0][0].Data)
+	assert.True(t, strings.HasPrefix(messages[1].Text, "Результаты за вчера:\n  win: 1\n  lose: 0\n  average odd: 1,70"))
+	assert.True(t, strings.HasPrefix(messages[2].Text, "Результаты за прошлую неделю:\n  win: 1\n  lose: 0\n"))
+	assert.Equal(t, []string{
+		"📈 Прибыль при ставке в 1 единицу с 30.08.2021 по 05.09.2021",
+		"📊 Выигрыши 🟩 и проигрыши 🟥 по дням с 30.08.2021 по 05.09.2021",
+		"🏐 Процент выигрышей по лигам с 30.08.2021 по 05.09.2021, в скобках количество сигналов",
+		"🎯 Выигрыши 🟩 и проигрыши 🟥 по коэффициенту фаворита с 30.08.2021 по 05.09.2021",
+	}, getTexts(messages[3:]))
+	for _, message := range messages[3:] {
+		assert.True(t, strings.HasPrefix(string(message.Photo), "\x89PNG"))
+	}
+
+	for _, message := range messages {
+		assert.Equal(t, "1", message.Recipient)
+	}
+
+	assert.Equal(t, int64(0), simulation.Operator.GetActiveRoutinesCount())
+}
+
+func TestSimulation_AdminSkipsEvent_SignalIsNotSent(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	testConfig := getTestConfig()
+	testConfig.Telegram.Admins = []int64{42}
+
+	sunday := time.Date(2021, 9, 5, 0, 0, 0, 0, location)
+	simulation := NewSimulation(
+		testConfig,
+		sunday.Add(10*time.Hour),
+		getTestMatches(sunday),
+	)
+
+	simulation.Start()
+	simulation.RunUntil(sunday.Add(17 * time.Hour))
+
+	admin := &tb.User{ID: 42, Username: "admin", LanguageCode: "en-US"}
+	err = simulation.Operator.Skip(&tb.Message{
+		Sender:  admin,
+		Chat:    &tb.Chat{ID: 42},
+		Payload: "1",
+	})
+	assert.NoError(t, err)
+
+	simulation.RunUntil(sunday.Add(23 * time.Hour))
+	simulation.Stop()
+
+	assert.Equal(t, 0, len(simulation.Store.Signals))
+	assert.Equal(t, 1, len(simulation.Store.AdminActions))
+	assert.Equal(t, "/skip", simulation.Store.AdminActions[0].Command)
+	assert.Equal(t, "1", simulation.Store.AdminActions[0].Argument)
+
+	messages := simulation.Transport.GetMessages()
+	assert.Equal(t, 1, len(messages))
+	assert.Equal(t, "42", messages[0].Recipient)
+	assert.Equal(t, "Event 1 skipped, live monitoring routine canceled", messages[0].Text)
+}
+
+func TestSimulation_TeamBannedByOtherInstance_SignalIsNotSent(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	sunday := time.Date(2021, 9, 5, 0, 0, 0, 0, location)
+	simulation := NewSimulation(
+		getTestConfig(),
+		sunday.Add(10*time.Hour),
+		getTestMatches(sunday),
+	)
+
+	simulation.Start()
+	simulation.RunUntil(sunday.Add(18 * time.Hour))
+
+	err = simulation.Store.InsertEventRule(database.EventRule{
+		Kind:  constants.RULE_BAN_TEAM,
+		Value: "verona",
+	})
+	assert.NoError(t, err)
+
+	simulation.RunUntil(sunday.Add(23 * time.Hour))
+	simulation.Stop()
+
+	assert.Equal(t, 0, len(simulation.Store.Signals))
+	assert.Equal(t, 0, len(simulation.Transport.GetMessages()))
+	assert.Equal(t, int64(0), simulation.Operator.GetActiveRoutinesCount())
+}
+
+func TestSimulation_SignalFanOut_DeactivatesBlockedSubscriber(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	sunday := time.Date(2021, 9, 5, 0, 0, 0, 0, location)
+	simulation := NewSimulation(
+		getTestConfig(),
+		sunday.Add(10*time.Hour),
+		getTestMatches(sunday),
+	)
+
+	err = simulation.Operator.Start(&tb.Message{
+		Chat: &tb.Chat{ID: 2, Type: tb.ChatPrivate, Username: "subscriber"},
+	})
+	assert.NoError(t, err)
+	simulation.Transport.Block("1")
+
+	simulation.Start()
+	simulation.RunUntil(sunday.Add(19 * time.Hour))
+	simulation.Stop()
+
+	assert.Equal(t, 1, len(simulation.Store.Signals))
+
+	subscribers, err := simulation.Store.GetActiveSubscribers()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(subscribers))
+	assert.Equal(t, int64(2), subscribers[0].ChatID)
+	assert.Equal(t, "subscriber", subscribers[0].Username)
+
+	messages := simulation.Transport.GetMessages()
+	assert.Equal(t, 2, len(messages))
+	assert.Equal(t, "2", messages[0].Recipient)
+	assert.Equal(t, "2", messages[1].Recipient)
+	assert.True(t, strings.Contains(messages[1].Text, "<code>#1</code>"))
+}
+
+func TestSimulation_AccessCode_RedeemRemindAndExpire(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	testConfig := getTestConfig()
+	testConfig.Telegram.Admins = []int64{42}
+	testConfig.Access.CodesRequired = true
+	testConfig.Access.ReminderBefore = 72 * time.Hour
+
+	monday := time.Date(2021, 9, 6, 0, 0, 0, 0, location)
+	simulation := NewSimulation(testConfig, monday.Add(10*time.Hour), nil)
+	simulation.Store.Subscribers = nil
+
+	admin := &tb.User{ID: 42, Username: "admin"}
+	err = simulation.Operator.CreateCode(&tb.Message{
+		Sender:  admin,
+		Chat:    &tb.Chat{ID: 42},
+		Payload: "5 1",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(simulation.Store.AccessCodes))
+	code := simulation.Store.AccessCodes[0].Code
+
+	user := &tb.User{ID: 2, LanguageCode: "en"}
+	err = simulation.Operator.Start(&tb.Message{Sender: user, Chat: &tb.Chat{ID: 2}})
+	assert.NoError(t, err)
+	err = simulation.Operator.Start(&tb.Message{Sender: user, Chat: &tb.Chat{ID: 2}, Payload: code})
+	assert.NoError(t, err)
+	err = simulation.Operator.Start(&tb.Message{Chat: &tb.Chat{ID: 3}, Payload: code})
+	assert.NoError(t, err)
+
+	subscribers, err := simulation.Store.GetActiveSubscribers()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(subscribers))
+	assert.Equal(t, int64(2), subscribers[0].ChatID)
+	assert.Equal(t, code, subscribers[0].SecretKey)
+
+	simulation.Start()
+	simulation.RunUntil(monday.AddDate(0, 0, 6))
+	simulation.Stop()
+
+	subscribers, err = simulation.Store.GetActiveSubscribers()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(subscribers))
+
+	var texts []string
+	for _, message := range simulation.Transport.GetMessages() {
+		if strings.HasPrefix(message.Text, "Results") {
+			continue
+		}
+
+		if message.Recipient == "2" || message.Recipient == "3" {
+			texts = append(texts, message.Recipient+": "+strings.Split(message.Text, " until ")[0])
+		}
+	}
+
+	assert.Equal(t, []string{
+		"2: " + i18n.Translate(i18n.LANGUAGE_EN, i18n.TEXT_ABOUT_ACCESS_CODE_REQUIRED),
+		"2: Access granted",
+		"3: " + i18n.Translate(i18n.LANGUAGE_RU, i18n.TEXT_ABOUT_ACCESS_CODE_USED_UP),
+		"2: Your access expires at Sep 11, 2021 10:00 MSK, ask admin for a new access code",
+		"2: " + i18n.Translate(i18n.LANGUAGE_EN, i18n.TEXT_ABOUT_ACCESS_EXPIRED),
+	}, texts)
+	assert.Equal(t, monday.AddDate(0, 0, 2).Add(10*time.Hour), simulation.Store.RemindedAt[2])
+}
+
+func TestSimulation_AccessCode_AccessStartsAtRedemption(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	testConfig := getTestConfig()
+	testConfig.Telegram.Admins = []int64{42}
+	testConfig.Access.CodesRequired = true
+	testConfig.Access.CodeValidity = 7 * 24 * time.Hour
+
+	monday := time.Date(2021, 9, 6, 10, 0, 0, 0, location)
+	simulation := NewSimulation(testConfig, monday, nil)
+	simulation.Store.Subscribers = nil
+
+	err = simulation.Operator.CreateCode(&tb.Message{
+		Sender:  &tb.User{ID: 42, Username: "admin"},
+		Chat:    &tb.Chat{ID: 42},
+		Payload: "5 2",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(simulation.Store.AccessCodes))
+	code := simulation.Store.AccessCodes[0].Code
+	assert.Equal(t, monday.AddDate(0, 0, 7), simulation.Store.AccessCodes[0].ExpiresAt)
+
+	redeemedAt := monday.AddDate(0, 0, 3)
+	simulation.RunUntil(redeemedAt)
+	err = simulation.Operator.Start(&tb.Message{Chat: &tb.Chat{ID: 2}, Payload: code})
+	assert.NoError(t, err)
+
+	simulation.RunUntil(monday.AddDate(0, 0, 8))
+	err = simulation.Operator.Start(&tb.Message{Chat: &tb.Chat{ID: 3}, Payload: code})
+	assert.NoError(t, err)
+	simulation.Stop()
+
+	assert.Equal(t, 1, len(simulation.Store.Subscribers))
+	subscriber := simulation.Store.Subscribers[0]
+	assert.Equal(t, int64(2), subscriber.ChatID)
+	assert.NotNil(t, subscriber.SecretKeyExpiredAt)
+	assert.True(t, redeemedAt.AddDate(0, 0, 5).Equal(*subscriber.SecretKeyExpiredAt))
+}
+
+func TestSimulation_Preferences_SignalIsFilteredByLeague(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	sunday := time.Date(2021, 9, 5, 0, 0, 0, 0, location)
+	simulation := NewSimulation(
+		getTestConfig(),
+		sunday.Add(10*time.Hour),
+		getTestMatches(sunday),
+	)
+
+	err = simulation.Operator.Start(&tb.Message{
+		Chat: &tb.Chat{ID: 2, Type: tb.ChatPrivate, Username: "subscriber"},
+	})
+	assert.NoError(t, err)
+
+	err = simulation.Operator.Settings(&tb.Message{
+		Chat:    &tb.Chat{ID: 2},
+		Payload: "leagues 999",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"999"}, simulation.Store.Preferences[2].Leagues)
+
+	err = simulation.Operator.Settings(&tb.Message{
+		Chat:    &tb.Chat{ID: RECIPIENT_ID},
+		Payload: "leagues 10",
+	})
+	assert.NoError(t, err)
+
+	simulation.Start()
+	simulation.RunUntil(sunday.Add(19 * time.Hour))
+	simulation.Stop()
+
+	assert.Equal(t, 1, len(simulation.Store.Signals))
+	assert.Equal(t, 1, len(simulation.Store.SignalDeliveries))
+	assert.Equal(t, int64(1), simulation.Store.SignalDeliveries[0].ChatID)
+
+	messages := simulation.Transport.GetMessages()
+	assert.Equal(t, 4, len(messages))
+	assert.Equal(t, "2", messages[1].Recipient)
+	assert.True(t, strings.HasPrefix(messages[1].Text, "Ваши настройки:\n  leagues: 999\n"))
+	assert.Equal(t, "1", messages[3].Recipient)
+	assert.True(t, strings.Contains(messages[3].Text, "<code>#1</code>"))
+}
+
+func TestSimulation_Settings_ChangeByButton(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	sunday := time.Date(2021, 9, 5, 0, 0, 0, 0, location)
+	simulation := NewSimulation(getTestConfig(), sunday.Add(10*time.Hour), nil)
+
+	user := &tb.User{ID: RECIPIENT_ID, LanguageCode: "en"}
+	chat := &tb.Chat{ID: RECIPIENT_ID, Type: tb.ChatPrivate}
+	err = simulation.Operator.Settings(&tb.Message{Sender: user, Chat: chat, Text: "/settings"})
+	assert.NoError(t, err)
+
+	messages := simulation.Transport.GetMessages()
+	assert.Equal(t, 1, len(messages))
+	assert.Equal(t, 3, len(messages[0].Buttons))
+	button := messages[0].Buttons[1][0]
+	assert.Equal(t, operator.SETTING_MIN_ODD, button.Data)
+
+	text, err := simulation.Operator.Setting(&tb.Callback{
+		Sender:  user,
+		Message: &tb.Message{Chat: chat},
+		Data:    button.Data,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "", text)
+
+	// text in another chat doesn't change the setting
+	err = simulation.Operator.HandleText(&tb.Message{Sender: user, Chat: &tb.Chat{ID: -100}, Text: "1.9"})
+	assert.NoError(t, err)
+	err = simulation.Operator.HandleText(&tb.Message{Sender: user, Chat: chat, Text: "1.6"})
+	assert.NoError(t, err)
+	err = simulation.Operator.HandleText(&tb.Message{Sender: user, Chat: chat, Text: "1.7"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1.6, simulation.Store.Preferences[RECIPIENT_ID].MinLiveOdd)
+
+	messages = simulation.Transport.GetMessages()
+	assert.Equal(t, 3, len(messages))
+	assert.Equal(t, "Send new value of min_odd", messages[1].Text)
+	assert.True(t, strings.Contains(messages[2].Text, "  min_odd: 1.60\n"))
+	assert.Equal(t, 3, len(messages[2].Buttons))
+}
+
+func TestSimulation_Bets_PlaceAndSettle(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	sunday := time.Date(2021, 9, 5, 0, 0, 0, 0, location)
+	simulation := NewSimulation(
+		getTestConfig(),
+		sunday.Add(10*time.Hour),
+		getTestMatches(sunday),
+	)
+
+	simulation.Start()
+	simulation.RunUntil(sunday.Add(18*time.Hour + 30*time.Minute))
+
+	user := &tb.User{ID: 7}
+	chat := &tb.Chat{ID: RECIPIENT_ID}
+	text, err := simulation.Operator.Placed(&tb.Callback{
+		Sender:  user,
+		Message: &tb.Message{Chat: chat},
+		Data:    "1",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Ставка отмечена", text)
+
+	text, err = simulation.Operator.Placed(&tb.Callback{
+		Sender:  user,
+		Message: &tb.Message{Chat: chat},
+		Data:    "1",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Ставка уже отмечена", text)
+
+	err = simulation.Operator.HandleText(&tb.Message{Sender: user, Chat: chat, Text: "100 1,85"})
+	assert.NoError(t, err)
+
+	simulation.RunUntil(sunday.Add(23 * time.Hour))
+
+	assert.Equal(t, 1, len(simulation.Store.Bets))
+	bet := simulation.Store.Bets[0]
+	assert.Equal(t, database.BET_STATUS_WON, bet.Status)
+	assert.Equal(t, 100.0, bet.Stake)
+	assert.Equal(t, 1.85, bet.Odd)
+	assert.InDelta(t, 85.0, bet.Profit, 0.0001)
+
+	messages := simulation.Transport.GetMessages()
+	assert.Equal(t, 4, len(messages))
+	assert.True(t, strings.HasPrefix(messages[1].Text, "Ставка на хозяева записана: сумма 1,00, коэффициент 1,70"))
+	assert.Equal(t, "Ставка обновлена: сумма 100,00, коэффициент 1,85", messages[2].Text)
+	assert.Equal(t, "Ставка рассчитана: хозяева - выиграна\n  event_id: 1\n  прибыль: +85,00\n", messages[3].Text)
+
+	err = simulation.Operator.MyBets(&tb.Message{Sender: user, Chat: chat})
+	assert.NoError(t, err)
+
+	messages = simulation.Transport.GetMessages()
+	assert.True(t, strings.HasPrefix(
+		messages[4].Text,
+		"Ваши ставки:\n  всего: 1\n  выиграно: 1\n  проиграно: 0\n  открыто: 0\n  поставлено: 100,00\n  прибыль: +85,00\n  roi: 85,0%\n",
+	))
+
+	// the second set is over, so the bet is settled at once
+	text, err = simulation.Operator.Placed(&tb.Callback{
+		Sender:  &tb.User{ID: 8},
+		Message: &tb.Message{Chat: &tb.Chat{ID: 8}},
+		Data:    "1",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Ставка отмечена", text)
+
+	simulation.RunUntil(sunday.Add(23*time.Hour + time.Minute))
+	simulation.Stop()
+
+	assert.Equal(t, database.BET_STATUS_WON, simulation.Store.Bets[1].Status)
+
+	messages = simulation.Transport.GetMessages()
+	assert.Equal(t, 6, len(messages))
+	assert.Equal(t, "8", messages[5].Recipient)
+	assert.Equal(t, "Ставка рассчитана: хозяева - выиграна\n  event_id: 1\n  прибыль: +0,70\n", messages[5].Text)
+}
+
+func TestSimulation_Language_SignalAndReportsAreTranslated(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	sunday := time.Date(2021, 9, 5, 0, 0, 0, 0, location)
+	simulation := NewSimulation(
+		getTestConfig(),
+		sunday.Add(10*time.Hour),
+		getTestMatches(sunday),
+	)
+
+	err = simulation.Operator.SetLanguage(&tb.Message{
+		Chat:    &tb.Chat{ID: RECIPIENT_ID},
+		Payload: "de",
+	})
+	assert.NoError(t, err)
+
+	err = simulation.Operator.SetLanguage(&tb.Message{
+		Chat:    &tb.Chat{ID: RECIPIENT_ID},
+		Payload: "en",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, i18n.LANGUAGE_EN, simulation.Store.ChatLanguages[RECIPIENT_ID])
+
+	simulation.Start()
+	simulation.RunUntil(sunday.Add(24*time.Hour + time.Minute))
+	simulation.Stop()
+
+	messages := simulation.Transport.GetMessages()
+	assert.Equal(t, 9, len(messages))
+	assert.Equal(t, "Использование: /language <en|ru>", messages[0].Text)
+	assert.Equal(t, "Language for messages changed to English", messages[1].Text)
+	assert.True(t, strings.HasPrefix(messages[2].Text, "✅ <b>🏐 Signal: bet on "))
+	assert.True(t, strings.Contains(messages[2].Text, "<b>✅ Signal won</b>"))
+	assert.Equal(t, "ℹ️ Details", messages[2].Buttons[0][0].Text)
+	assert.True(t, strings.HasPrefix(messages[3].Text, "Results for yesterday:\n  win: 1\n  lose: 0\n  average odd: 1.70"))
+	assert.True(t, strings.HasPrefix(messages[4].Text, "Results for previous week:\n"))
+	assert.True(t, strings.HasPrefix(messages[5].Text, "📈 Profit with flat stake of 1 unit from "))
+}
+
+func TestSimulation_Statistics_WeeklyReportIsNotSentAgainAfterRestart(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	monday := time.Date(2021, 9, 6, 10, 0, 0, 0, location)
+	simulation := NewSimulation(getTestConfig(), monday, nil)
+
+	simulation.Start()
+	simulation.RunUntil(monday.Add(time.Minute))
+
+	// restarted instance runs the weekly loop again on the same monday
+	err = simulation.Statistics.GetStatisticOnPreviousWeekAndNotify()
+	assert.NoError(t, err)
+
+	simulation.RunUntil(monday.Add(2 * time.Minute))
+	simulation.Stop()
+
+	var reports int
+	for _, message := range simulation.Transport.GetMessages() {
+		if strings.HasPrefix(message.Text, "Результаты за прошлую неделю") ||
+			strings.HasPrefix(message.Text, "Results for previous week") {
+			reports++
+		}
+	}
+
+	assert.Equal(t, 1, reports)
+	assert.Equal(t, 1, len(simulation.Store.ReportDeliveries))
+}
+
+func TestSimulation_InfoCommands_LiveStatsAndHistory(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	sunday := time.Date(2021, 9, 5, 0, 0, 0, 0, location)
+	simulation := NewSimulation(
+		getTestConfig(),
+		sunday.Add(10*time.Hour),
+		getTestMatches(sunday),
+	)
+
+	chat := &tb.Chat{ID: 5}
+	simulation.Start()
+	simulation.RunUntil(sunday.Add(11 * time.Hour))
+
+	err = simulation.Operator.Today(&tb.Message{Chat: chat})
+	assert.NoError(t, err)
+
+	simulation.RunUntil(sunday.Add(18*time.Hour + 10*time.Minute))
+
+	err = simulation.Operator.Live(&tb.Message{Chat: chat})
+	assert.NoError(t, err)
+
+	simulation.RunUntil(sunday.Add(18*time.Hour + 30*time.Minute))
+
+	err = simulation.Operator.History(&tb.Message{Chat: chat, Payload: "5"})
+	assert.NoError(t, err)
+
+	simulation.RunUntil(sunday.Add(23 * time.Hour))
+
+	err = simulation.Operator.Live(&tb.Message{Chat: chat})
+	assert.NoError(t, err)
+	err = simulation.Operator.Stats(&tb.Message{Chat: chat, Payload: "day"})
+	assert.NoError(t, err)
+	err = simulation.Operator.Stats(&tb.Message{Chat: chat, Payload: "2021-09-06 2021-09-07"})
+	assert.NoError(t, err)
+	err = simulation.Operator.History(&tb.Message{Chat: chat})
+	assert.NoError(t, err)
+	err = simulation.Operator.History(&tb.Message{Chat: chat, Payload: "100"})
+	assert.NoError(t, err)
+
+	simulation.Stop()
+
+	var texts []string
+	for _, message := range simulation.Transport.GetMessages() {
+		if message.Recipient == "5" {
+			texts = append(texts, message.Text)
+		}
+	}
+
+	assert.Equal(t, []string{
+		"Отобранные матчи на 05.09.2021:\n" +
+			"  18:00 Modena - Verona (Italy A1), фаворит: хозяева, коэффициенты: 1,20 / 4,00\n" +
+			"  19:00 Jastrzebski - Cuprum (Poland Plus Liga), фаворит: хозяева, коэффициенты: 1,25 / 3,50\n",
+		"Матчи в live-мониторинге:\n" +
+			"  Modena - Verona (Italy A1), фаворит: хозяева, счёт: 3-2, сет 1, коэффициенты: 1,15 / 5,00\n",
+		"Последние сигналы:\n" +
+			"  05.09.2021 18:25 MSK Modena - Verona, ставка на хозяева по 1,70: ⏳ в игре\n",
+		"Сейчас нет матчей в live-мониторинге",
+		"Статистика с 05.09.2021 по 05.09.2021:\n  win: 1\n  lose: 0\n  average odd: 1,70\n",
+		"Статистика с 06.09.2021 по 07.09.2021:\n  win: 0\n  lose: 0\n  average odd: 0,00\n",
+		"Последние сигналы:\n" +
+			"  05.09.2021 18:25 MSK Modena - Verona, ставка на хозяева по 1,70: ✅ зашла (20-25,25-20,1-0)\n",
+		"Использование: /history [количество от 1 до 50]",
+	}, texts)
+}
+
+func TestSimulation_Charts_SentOnDemand(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	sunday := time.Date(2021, 9, 5, 0, 0, 0, 0, location)
+	simulation := NewSimulation(
+		getTestConfig(),
+		sunday.Add(10*time.Hour),
+		getTestMatches(sunday),
+	)
+
+	chat := &tb.Chat{ID: 5}
+	simulation.Start()
+	simulation.RunUntil(sunday.Add(23 * time.Hour))
+
+	err = simulation.Operator.Charts(&tb.Message{Chat: chat})
+	assert.NoError(t, err)
+	err = simulation.Operator.Charts(&tb.Message{Chat: chat, Payload: "2021-09-06 2021-09-07"})
+	assert.NoError(t, err)
+	err = simulation.Operator.Charts(&tb.Message{Chat: chat, Payload: "2021-01-01 2021-09-05"})
+	assert.NoError(t, err)
+
+	simulation.Stop()
+
+	var messages []Message
+	for _, message := range simulation.Transport.GetMessages() {
+		if message.Recipient == "5" {
+			messages = append(messages, message)
+		}
+	}
+
+	assert.Equal(t, []string{
+		"📈 Прибыль при ставке в 1 единицу с 30.08.2021 по 05.09.2021",
+		"📊 Выигрыши 🟩 и проигрыши 🟥 по дням с 30.08.2021 по 05.09.2021",
+		"🏐 Процент выигрышей по лигам с 30.08.2021 по 05.09.2021, в скобках количество сигналов",
+		"🎯 Выигрыши 🟩 и проигрыши 🟥 по коэффициенту фаворита с 30.08.2021 по 05.09.2021",
+		"Нет рассчитанных сигналов с 06.09.2021 по 07.09.2021",
+		"Использование: /charts [day|week|month] или /charts <с> <по>, даты в формате 2021-09-05, не больше 92 дней",
+	}, getTexts(messages))
+
+	for _, message := range messages[:4] {
+		assert.True(t, strings.HasPrefix(string(message.Photo), "\x89PNG"))
+	}
+
+	for _, message := range messages[4:] {
+		assert.Equal(t, 0, len(message.Photo))
+	}
+}
+
+func TestSimulation_Outbox_RetriesAndFailsPermanently(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	startTime := time.Date(2021, 9, 7, 10, 0, 0, 0, location)
+	simulation := NewSimulation(getTestConfig(), startTime, nil)
+	simulation.Store.Subscribers = append(
+		simulation.Store.Subscribers,
+		database.Subscriber{ChatID: 2, IsActive: true},
+		database.Subscriber{ChatID: 3, IsActive: true},
+	)
+
+	simulation.Transport.Fail("1", tb.FloodError{
+		APIError:   &tb.APIError{Code: 429, Description: "Too Many Requests"},
+		RetryAfter: 30,
+	})
+	simulation.Transport.Fail("2", errors.New("telegram unknown: Internal Server Error (500)"))
+	simulation.Transport.Block("3")
+
+	messagesDelivery := delivery.NewDelivery(simulation.Store)
+	sentMessages, err := messagesDelivery.SendToSubscribers("signal")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(sentMessages))
+
+	err = messagesDelivery.ReplyToMessages(sentMessages[:1], "cancel")
+	assert.NoError(t, err)
+
+	simulation.Start()
+	simulation.RunUntil(startTime.Add(time.Minute))
+	simulation.Stop()
+
+	// flood control pauses sending to all chats
+	messages := simulation.Transport.GetMessages()
+	assert.Equal(t, 3, len(messages))
+	assert.Equal(t, Message{ID: 1, Recipient: "1", Text: "signal"}, messages[0])
+	assert.Equal(t, Message{ID: 2, Recipient: "1", ReplyTo: 1, Text: "cancel"}, messages[1])
+	assert.Equal(t, Message{ID: 3, Recipient: "2", Text: "signal"}, messages[2])
+
+	outbox := simulation.Store.Outbox
+	assert.Equal(t, startTime.Add(30*time.Second), *outbox[0].SentAt)
+	assert.Equal(t, 2, outbox[0].Attempts)
+	assert.Equal(t, startTime.Add(35*time.Second), *outbox[1].SentAt)
+	assert.Equal(t, database.OUTBOX_STATUS_FAILED, outbox[2].Status)
+	assert.Equal(t, startTime.Add(31*time.Second), *outbox[3].SentAt)
+
+	subscribers, err := simulation.Store.GetActiveSubscribers()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(subscribers))
+
+	counts, err := simulation.Store.CountOutboxMessages()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{
+		database.OUTBOX_STATUS_SENT:   3,
+		database.OUTBOX_STATUS_FAILED: 1,
+	}, counts)
+}
+
+func TestSimulation_SignalMessage_EditedWithLiveScoreAndOutcome(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	sunday := time.Date(2021, 9, 5, 0, 0, 0, 0, location)
+	match := getTestMatches(sunday)[0]
+	match.Timeline = []Snapshot{
+		{After: 0, HomeOdd: "1.15", AwayOdd: "5.00", Score: "3-2"},
+		{After: 25 * time.Minute, HomeOdd: "1.70", AwayOdd: "2.10", Score: "20-25,3-2"},
+		{After: 35 * time.Minute, HomeOdd: "1.75", AwayOdd: "2.00", Score: "20-25,12-10"},
+		{After: 35*time.Minute + 10*time.Second, HomeOdd: "1.80", AwayOdd: "1.95", Score: "20-25,13-10"},
+		{After: 35*time.Minute + 20*time.Second, HomeOdd: "1.85", AwayOdd: "1.90", Score: "20-25,14-10"},
+		{After: 50 * time.Minute, HomeOdd: "1.10", AwayOdd: "6.00", Score: "20-25,25-20,1-0"},
+	}
+
+	simulation := NewSimulation(getTestConfig(), sunday.Add(10*time.Hour), []Match{match})
+	simulation.Start()
+
+	simulation.RunUntil(sunday.Add(18*time.Hour + 40*time.Minute))
+	messages := simulation.Transport.GetMessages()
+	assert.Equal(t, 1, len(messages))
+	assert.Equal(t, 2, messages[0].Edits)
+	assert.True(t, strings.Contains(messages[0].Text, "20-25,14-10"))
+	assert.True(t, strings.Contains(messages[0].Text, "<b>1,85</b>"))
+	assert.Equal(t, 2, len(messages[0].Buttons))
+
+	simulation.RunUntil(sunday.Add(20 * time.Hour))
+	simulation.Stop()
+
+	messages = simulation.Transport.GetMessages()
+	assert.Equal(t, 1, len(messages))
+	assert.Equal(t, 3, messages[0].Edits)
+	assert.True(t, strings.HasPrefix(messages[0].Text, "✅ <b>🏐 Сигнал: ставка на Modena</b>"))
+	assert.True(t, strings.Contains(messages[0].Text, "20-25,25-20,1-0"))
+	assert.True(t, strings.Contains(messages[0].Text, "<b>✅ Сигнал зашёл</b>"))
+	assert.Equal(t, [][]tb.InlineButton{
+		{{Unique: "details", Text: "ℹ️ Подробнее", Data: "1"}},
+	}, messages[0].Buttons)
+}
+
+func TestSimulation_Broadcast_RoutesMessagesByCategory(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	config := getTestConfig()
+	config.Broadcast.ChannelID = -100
+	config.Broadcast.AdminChatID = -200
+	config.Broadcast.DiscussionGroupID = -300
+	config.Broadcast.Routes = map[string][]string{
+		delivery.CATEGORY_REPORT: {delivery.TARGET_CHANNEL, delivery.TARGET_DISCUSSION},
+	}
+
+	sunday := time.Date(2021, 9, 5, 0, 0, 0, 0, location)
+	simulation := NewSimulation(config, sunday.Add(10*time.Hour), getTestMatches(sunday))
+	simulation.Start()
+	simulation.RunUntil(sunday.Add(24*time.Hour + 10*time.Minute))
+
+	simulation.Operator.NotifyError(errors.New("bet api is unavailable"))
+	simulation.RunUntil(sunday.Add(24*time.Hour + 11*time.Minute))
+	simulation.Stop()
+
+	texts := map[string][]string{}
+	buttons := map[string]int{}
+	for _, message := range simulation.Transport.GetMessages() {
+		texts[message.Recipient] = append(texts[message.Recipient], message.Text)
+		buttons[message.Recipient] += len(message.Buttons)
+	}
+
+	assert.Equal(t, map[string]int{"1": 1, "-100": 0, "-200": 0, "-300": 0}, buttons)
+
+	assert.Equal(t, 7, len(texts["1"]))
+	assert.Equal(t, texts["1"], texts["-100"])
+
+	assert.Equal(t, texts["1"][1:], texts["-300"])
+
+	assert.Equal(t, []string{"⚠️ Ошибка: bet api is unavailable"}, texts["-200"])
+}
+
+func TestSimulation_Broadcast_RouteToMissingTargetIsRejected(t *testing.T) {
+	_, err := delivery.GetBroadcastRoutes(config.Broadcast{
+		ChannelID: -100,
+		Routes: map[string][]string{
+			delivery.CATEGORY_ERROR: {delivery.TARGET_ADMIN},
+		},
+	})
+	assert.Error(t, err)
+
+	routes, err := delivery.GetBroadcastRoutes(config.Broadcast{ChannelID: -100})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]int64{
+		delivery.CATEGORY_SIGNAL: {-100},
+		delivery.CATEGORY_REPORT: {-100},
+	}, routes)
+}
+
+func TestSimulation_Roles_CommandsRequireRole(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	testConfig := getTestConfig()
+	testConfig.Telegram.Owner = 10
+	testConfig.Telegram.Admins = []int64{20}
+
+	sunday := time.Date(2021, 9, 5, 0, 0, 0, 0, location)
+	simulation := NewSimulation(testConfig, sunday.Add(10*time.Hour), nil)
+
+	owner := &tb.User{ID: 10, LanguageCode: "en"}
+	admin := &tb.User{ID: 20, LanguageCode: "en"}
+	guest := &tb.User{ID: 30, LanguageCode: "en"}
+	getMessage := func(user *tb.User, text string) *tb.Message {
+		return &tb.Message{
+			Sender:  user,
+			Chat:    &tb.Chat{ID: int64(user.ID)},
+			Text:    text,
+			Payload: strings.TrimSpace(strings.TrimPrefix(text, strings.Fields(text)[0])),
+		}
+	}
+
+	assert.True(t, simulation.Operator.Authorize(getMessage(guest, "/help"), database.ROLE_GUEST))
+	assert.False(t, simulation.Operator.Authorize(getMessage(guest, "/today"), database.ROLE_SUBSCRIBER))
+	assert.True(t, simulation.Operator.Authorize(&tb.Message{
+		Sender: guest,
+		Chat:   &tb.Chat{ID: RECIPIENT_ID},
+		Text:   "/today",
+	}, database.ROLE_SUBSCRIBER))
+	assert.False(t, simulation.Operator.Authorize(getMessage(guest, "/skip 1"), database.ROLE_ADMIN))
+	assert.True(t, simulation.Operator.Authorize(getMessage(admin, "/skip 1"), database.ROLE_ADMIN))
+
+	err = simulation.Operator.SetRole(getMessage(admin, "/role 30 admin"))
+	assert.NoError(t, err)
+	err = simulation.Operator.SetRole(getMessage(admin, "/role 30 subscriber"))
+	assert.NoError(t, err)
+	assert.True(t, simulation.Operator.Authorize(getMessage(guest, "/today"), database.ROLE_SUBSCRIBER))
+
+	err = simulation.Operator.SetRole(getMessage(owner, "/role 30 admin"))
+	assert.NoError(t, err)
+	assert.True(t, simulation.Operator.Authorize(getMessage(guest, "/skip 1"), database.ROLE_ADMIN))
+
+	err = simulation.Operator.SetRole(getMessage(admin, "/role 10 guest"))
+	assert.NoError(t, err)
+	assert.Equal(t, database.ROLE_OWNER, simulation.Store.Roles[10].Role)
+
+	err = simulation.Operator.SetRole(getMessage(owner, "/role 30 guest"))
+	assert.NoError(t, err)
+	assert.False(t, simulation.Operator.Authorize(&tb.Message{
+		Sender: guest,
+		Chat:   &tb.Chat{ID: RECIPIENT_ID},
+		Text:   "/today",
+	}, database.ROLE_SUBSCRIBER))
+
+	var texts []string
+	for _, message := range simulation.Transport.GetMessages() {
+		texts = append(texts, message.Recipient+": "+message.Text)
+	}
+
+	assert.Equal(t, []string{
+		"30: Not enough rights, required role: subscriber",
+		"30: Not enough rights, required role: admin",
+		"20: " + i18n.Translate(i18n.LANGUAGE_EN, i18n.TEXT_ABOUT_ROLE_NOT_ALLOWED),
+		"20: Role of user 30 is subscriber now",
+		"10: Role of user 30 is admin now",
+		"20: " + i18n.Translate(i18n.LANGUAGE_EN, i18n.TEXT_ABOUT_ROLE_NOT_ALLOWED),
+		"10: Role of user 30 is guest now",
+		"1: Not enough rights, required role: subscriber",
+	}, texts)
+	assert.Equal(t, 5, len(simulation.Store.AdminActions))
+}
+
+func TestSimulation_Monitor_EventInPlay(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	testConfig := getTestConfig()
+	testConfig.Telegram.Admins = []int64{20}
+
+	sunday := time.Date(2021, 9, 5, 0, 0, 0, 0, location)
+	simulation := NewSimulation(
+		testConfig,
+		sunday.Add(18*time.Hour+5*time.Minute),
+		getTestMatches(sunday),
+	)
+
+	admin := &tb.User{ID: 20, LanguageCode: "en"}
+	err = simulation.Operator.Monitor(&tb.Message{
+		Sender:  admin,
+		Chat:    &tb.Chat{ID: 20},
+		Text:    "/monitor 1",
+		Payload: "1",
+	})
+	assert.NoError(t, err)
+
+	event, err := simulation.Store.GetEventByID("1")
+	assert.NoError(t, err)
+	assert.NotNil(t, event)
+	assert.Equal(t, constants.FAVORITE_IS_HOME, event.Favorite)
+
+	simulation.RunUntil(sunday.Add(18*time.Hour + 30*time.Minute))
+	simulation.Stop()
+
+	assert.Equal(t, 1, len(simulation.Store.Signals))
+	assert.True(t, strings.HasPrefix(
+		simulation.Transport.GetMessages()[0].Text,
+		"Event 1 added to live monitoring",
+	))
+}
+
+func TestSimulation_Roles_ChatWideCommandsInGroupRequireSubscriber(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	testConfig := getTestConfig()
+	testConfig.Telegram.Admins = []int64{20}
+
+	sunday := time.Date(2021, 9, 5, 0, 0, 0, 0, location)
+	simulation := NewSimulation(testConfig, sunday.Add(10*time.Hour), nil)
+
+	group := &tb.Chat{ID: -100, Type: tb.ChatGroup}
+	err = simulation.Operator.Start(&tb.Message{Chat: group})
+	assert.NoError(t, err)
+
+	admin := &tb.User{ID: 20, LanguageCode: "en"}
+	subscriber := &tb.User{ID: RECIPIENT_ID, LanguageCode: "en"}
+	guest := &tb.User{ID: 30, LanguageCode: "en"}
+	getMessage := func(user *tb.User, chat *tb.Chat, text string) *tb.Message {
+		return &tb.Message{Sender: user, Chat: chat, Text: text}
+	}
+
+	assert.True(t, simulation.Operator.Authorize(getMessage(guest, group, "/today"), database.ROLE_SUBSCRIBER))
+	assert.False(t, simulation.Operator.Authorize(getMessage(guest, group, "/stop"), database.ROLE_GUEST))
+	assert.False(t, simulation.Operator.Authorize(getMessage(guest, group, "/language ru"), database.ROLE_GUEST))
+	assert.False(t, simulation.Operator.Authorize(getMessage(guest, group, "/timezone UTC"), database.ROLE_SUBSCRIBER))
+	assert.True(t, simulation.Operator.Authorize(getMessage(subscriber, group, "/stop"), database.ROLE_GUEST))
+	assert.True(t, simulation.Operator.Authorize(getMessage(admin, group, "/language ru"), database.ROLE_GUEST))
+
+	private := &tb.Chat{ID: int64(guest.ID), Type: tb.ChatPrivate}
+	assert.True(t, simulation.Operator.Authorize(getMessage(guest, private, "/stop"), database.ROLE_GUEST))
+	assert.True(t, simulation.Operator.Authorize(getMessage(guest, private, "/language ru"), database.ROLE_GUEST))
+}
+
+func TestSimulation_Digest_SentWithUpdatesToSubscribers(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	testConfig := getTestConfig()
+	testConfig.Digest.Enabled = true
+	testConfig.Digest.Hour = 9
+	testConfig.Discovery.Lookahead = 6 * time.Hour
+
+	sunday := time.Date(2021, 9, 5, 0, 0, 0, 0, location)
+	simulation := NewSimulation(
+		testConfig,
+		sunday.Add(10*time.Hour),
+		getTestMatches(sunday),
+	)
+
+	err = simulation.Operator.Start(&tb.Message{
+		Chat: &tb.Chat{ID: 2, Type: tb.ChatPrivate, Username: "subscriber"},
+	})
+	assert.NoError(t, err)
+
+	err = simulation.Operator.Settings(&tb.Message{
+		Chat:    &tb.Chat{ID: 2},
+		Payload: "reports daily,weekly",
+	})
+	assert.NoError(t, err)
+
+	err = simulation.Operator.Start(&tb.Message{
+		Chat: &tb.Chat{ID: 3, Type: tb.ChatPrivate, Username: "new_york"},
+	})
+	assert.NoError(t, err)
+	simulation.Store.ChatTimezones[3] = "America/New_York"
+
+	simulation.Start()
+	simulation.RunUntil(sunday.Add(14 * time.Hour))
+
+	texts := map[string][]string{}
+	for _, message := range simulation.Transport.GetMessages() {
+		if strings.Contains(message.Text, "андидаты") {
+			texts[message.Recipient] = append(texts[message.Recipient], message.Text)
+		}
+	}
+
+	assert.Equal(t, map[string][]string{
+		"1": {
+			"☀️ Кандидаты на 05.09.2021:\n" +
+				"\n🏆 Italy A1\n" +
+				"  18:00 Modena - Verona, фаворит: Modena, коэффициент: 1,20\n",
+			"🆕 Новые кандидаты на 05.09.2021:\n" +
+				"\n🏆 Poland Plus Liga\n" +
+				"  19:00 Jastrzebski - Cuprum, фаворит: Jastrzebski, коэффициент: 1,25\n",
+		},
+	}, texts)
+
+	simulation.RunUntil(sunday.Add(16*time.Hour + 5*time.Minute))
+	simulation.Stop()
+
+	texts = map[string][]string{}
+	for _, message := range simulation.Transport.GetMessages() {
+		if strings.Contains(message.Text, "андидаты") {
+			texts[message.Recipient] = append(texts[message.Recipient], message.Text)
+		}
+	}
+
+	assert.Equal(t, 2, len(texts["1"]))
+	assert.Equal(t, []string{
+		"☀️ Кандидаты на 05.09.2021:\n" +
+			"\n🏆 Italy A1\n" +
+			"  11:00 Modena - Verona, фаворит: Modena, коэффициент: 1,20\n" +
+			"\n🏆 Poland Plus Liga\n" +
+			"  12:00 Jastrzebski - Cuprum, фаворит: Jastrzebski, коэффициент: 1,25\n",
+	}, texts["3"])
+
+	assert.Equal(t, 4, len(simulation.Store.DigestEvents))
+}
+
+func TestSimulation_SendSignalOnce_RollsBackFailedAndSkipsDuplicate(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	startTime := time.Date(2021, 9, 7, 10, 0, 0, 0, location)
+	simulation := NewSimulation(getTestConfig(), startTime, nil)
+	messagesDelivery := delivery.NewDelivery(simulation.Store)
+
+	sent, err := simulation.Store.SendSignalOnce(
+		"1",
+		constants.STRATEGY_SECOND_SET,
+		constants.SIGNAL_TYPE_BET,
+		func(tx database.DatabaseInterface) error {
+			_, err := messagesDelivery.WithDatabase(tx).SendToSubscribers("signal")
+			assert.NoError(t, err)
+			return errors.New("unable to send signal")
+		},
+	)
+	assert.Error(t, err)
+	assert.False(t, sent)
+	assert.Equal(t, 0, len(simulation.Store.Signals))
+	assert.Equal(t, 0, len(simulation.Store.Outbox))
+
+	for i := 0; i < 2; i++ {
+		sent, err = simulation.Store.SendSignalOnce(
+			"1",
+			constants.STRATEGY_SECOND_SET,
+			constants.SIGNAL_TYPE_BET,
+			func(tx database.DatabaseInterface) error {
+				_, err := messagesDelivery.WithDatabase(tx).SendToSubscribers("signal")
+				return err
+			},
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, i == 0, sent)
+	}
+
+	simulation.Start()
+	simulation.RunUntil(startTime.Add(time.Minute))
+	simulation.Stop()
+
+	assert.Equal(t, 1, len(simulation.Store.Signals))
+	assert.Equal(t, []string{"signal"}, getTexts(simulation.Transport.GetMessages()))
+}
+
+func TestSimulation_Outbox_KeepsChatOrderOnRetryAndDeletesOldMessages(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	testConfig := getTestConfig()
+	testConfig.Outbox.Retention = time.Hour
+
+	startTime := time.Date(2021, 9, 7, 10, 0, 0, 0, location)
+	simulation := NewSimulation(testConfig, startTime, nil)
+	simulation.Transport.Fail("1", errors.New("telegram unknown: Internal Server Error (500)"))
+
+	messagesDelivery := delivery.NewDelivery(simulation.Store)
+	for _, text := range []string{"first", "second"} {
+		_, err = messagesDelivery.SendToSubscribers(text)
+		assert.NoError(t, err)
+	}
+
+	simulation.Start()
+	simulation.RunUntil(startTime.Add(time.Minute))
+
+	assert.Equal(t, []string{"first", "second"}, getTexts(simulation.Transport.GetMessages()))
+	assert.Equal(t, 2, len(simulation.Store.Outbox))
+
+	simulation.RunUntil(startTime.Add(2*time.Hour + time.Minute))
+	simulation.Stop()
+
+	assert.Equal(t, 0, len(simulation.Store.Outbox))
+}
+
+func TestSimulation_OddsDrift_SentOnlyToAdminChat(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	testConfig := getTestConfig()
+	testConfig.Broadcast.ChannelID = -100
+	testConfig.Broadcast.AdminChatID = -200
+
+	sunday := time.Date(2021, 9, 5, 0, 0, 0, 0, location)
+	simulation := NewSimulation(testConfig, sunday.Add(10*time.Hour), nil)
+
+	event := requester.EventWithOdds{
+		EventID:         "1",
+		HomeCommandName: "Modena",
+		AwayCommandName: "Verona",
+		EventStartTime:  sunday.Add(18 * time.Hour),
+	}
+	err = simulation.Operator.SendMessageAboutOddsDriftToTelegram(
+		simulation.Store,
+		event,
+		operator.OddsDrift{Kind: constants.SIGNAL_TYPE_DRIFT},
+	)
+	assert.NoError(t, err)
+
+	simulation.Start()
+	simulation.RunUntil(sunday.Add(10*time.Hour + time.Minute))
+	simulation.Stop()
+
+	messages := simulation.Transport.GetMessages()
+	assert.Equal(t, 1, len(messages))
+	assert.Equal(t, "-200", messages[0].Recipient)
+	assert.True(t, strings.HasPrefix(messages[0].Text, "Изменение коэффициентов до матча"))
+}
+
+func TestSimulation_Scheduler_RepeatedErrorIsNotifiedOnce(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	testConfig := getTestConfig()
+	testConfig.Broadcast.AdminChatID = -200
+
+	sunday := time.Date(2021, 9, 5, 0, 0, 0, 0, location)
+	simulation := NewSimulation(testConfig, sunday.Add(10*time.Hour), nil)
+	simulation.BetApi.UpcomingEventsErr = errors.New("bet api is unavailable")
+
+	simulation.Start()
+	simulation.RunUntil(sunday.Add(10*time.Hour + 29*time.Minute))
+	assert.Equal(t, 1, len(simulation.Transport.GetMessages()))
+
+	simulation.RunUntil(sunday.Add(10*time.Hour + 31*time.Minute))
+	simulation.Stop()
+
+	messages := simulation.Transport.GetMessages()
+	assert.Equal(t, 2, len(messages))
+	assert.Equal(t, "-200", messages[1].Recipient)
+	assert.True(t, strings.Contains(messages[1].Text, "bet api is unavailable"))
+}
+
+func TestSimulation_Outbox_PhotoIsStoredAndUploadedOnce(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	testConfig := getTestConfig()
+	testConfig.Outbox.Retention = time.Hour
+
+	startTime := time.Date(2021, 9, 7, 10, 0, 0, 0, location)
+	simulation := NewSimulation(testConfig, startTime, nil)
+	simulation.Store.Subscribers = append(simulation.Store.Subscribers, database.Subscriber{
+		ChatID:   2,
+		Type:     "private",
+		IsActive: true,
+		JoinedAt: startTime,
+	})
+
+	messagesDelivery := delivery.NewDelivery(simulation.Store)
+	photoID, err := messagesDelivery.AddPhoto([]byte("\x89PNG"))
+	assert.NoError(t, err)
+
+	_, err = messagesDelivery.SendMessageToSubscribersFunc(func(database.Subscriber) delivery.Message {
+		return delivery.Message{Text: "chart", PhotoID: photoID}
+	})
+	assert.NoError(t, err)
+
+	simulation.Start()
+	simulation.RunUntil(startTime.Add(time.Minute))
+
+	messages := simulation.Transport.GetMessages()
+	assert.Equal(t, 2, len(messages))
+	for _, message := range messages {
+		assert.Equal(t, "chart", message.Text)
+		assert.Equal(t, []byte("\x89PNG"), message.Photo)
+	}
+
+	assert.Equal(t, 1, simulation.Transport.Uploads)
+	assert.Equal(t, 1, len(simulation.Store.OutboxPhotos))
+
+	simulation.RunUntil(startTime.Add(2*time.Hour + time.Minute))
+	simulation.Stop()
+
+	assert.Equal(t, 0, len(simulation.Store.Outbox))
+	assert.Equal(t, 0, len(simulation.Store.OutboxPhotos))
+}
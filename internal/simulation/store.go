@@ -0,0 +1,1234 @@
+package simulation
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/daniilsolovey/BetBotGo/internal/requester"
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+)
+
+type Signal struct {
+	EventID    string
+	Strategy   string
+	SignalType string
+	CreatedAt  time.Time
+}
+
+type SignalDelivery struct {
+	database.SignalDelivery
+	DeliveredAt time.Time
+}
+
+type DigestDelivery struct {
+	ChatID  int64
+	EventID string
+}
+
+// ReportDelivery is keyed by unix time of the period start, so equal times in
+// different locations are the same period.
+type ReportDelivery struct {
+	Report      string
+	PeriodStart int64
+}
+
+type OutboxPhoto struct {
+	Data      []byte
+	FileID    string
+	CreatedAt time.Time
+}
+
+type OddsHistory struct {
+	EventID   string
+	HomeOdd   float64
+	AwayOdd   float64
+	CreatedAt time.Time
+}
+
+// Store keeps rows of all tables in memory and implements
+// database.DatabaseInterface with the same semantics as postgres queries.
+type Store struct {
+	mutex             sync.Mutex
+	Events            map[string]requester.EventWithOdds
+	OddsHistory       []OddsHistory
+	Signals           []Signal
+	LiveEventsResults []requester.LiveEventResult
+	Statistic         []database.StatisticResultOfPreviousDay
+	Leagues           map[string]database.League
+	ChatTimezones     map[int64]string
+	ChatLanguages     map[int64]string
+	EventRules        []database.EventRule
+	AdminActions      []database.AdminAction
+	Subscribers       []database.Subscriber
+	RemindedAt        map[int64]time.Time
+	AccessCodes       []database.AccessCode
+	Preferences       map[int64]database.Preferences
+	SignalDeliveries  []SignalDelivery
+	Bets              []database.Bet
+	Outbox            []database.OutboxMessage
+	OutboxPhotos      map[int64]OutboxPhoto
+	Roles             map[int64]database.UserRole
+	DigestEvents      map[DigestDelivery]time.Time
+	ReportDeliveries  map[ReportDelivery]time.Time
+
+	outboxSequence      int64
+	outboxPhotoSequence int64
+}
+
+func NewStore() *Store {
+	return &Store{
+		Events:           map[string]requester.EventWithOdds{},
+		Leagues:          map[string]database.League{},
+		ChatTimezones:    map[int64]string{},
+		ChatLanguages:    map[int64]string{},
+		RemindedAt:       map[int64]time.Time{},
+		Preferences:      map[int64]database.Preferences{},
+		Roles:            map[int64]database.UserRole{},
+		DigestEvents:     map[DigestDelivery]time.Time{},
+		OutboxPhotos:     map[int64]OutboxPhoto{},
+		ReportDeliveries: map[ReportDelivery]time.Time{},
+	}
+}
+
+func (store *Store) InsertEventsForToday(events []requester.EventWithOdds) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, event := range events {
+		if _, ok := store.Events[event.EventID]; ok {
+			continue
+		}
+
+		store.Events[event.EventID] = event
+	}
+
+	return nil
+}
+
+func (store *Store) GetEventByID(eventID string) (*requester.EventWithOdds, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	event, ok := store.Events[eventID]
+	if !ok {
+		return nil, nil
+	}
+
+	return &event, nil
+}
+
+func (store *Store) GetUpcomingEventsForDay(day time.Time) ([]requester.EventWithOdds, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	beginningOfDay := tools.BeginningOfDay(day)
+	var result []requester.EventWithOdds
+	for _, event := range store.Events {
+		if isInRange(event.EventStartTime, beginningOfDay, beginningOfDay.AddDate(0, 0, 1)) {
+			result = append(result, event)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].EventStartTime.Before(result[j].EventStartTime)
+	})
+
+	return result, nil
+}
+
+func (store *Store) InsertEventOddsHistory(eventID string, homeOdd, awayOdd float64) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.OddsHistory = append(store.OddsHistory, OddsHistory{
+		EventID:   eventID,
+		HomeOdd:   homeOdd,
+		AwayOdd:   awayOdd,
+		CreatedAt: tools.TimeNow(),
+	})
+
+	return nil
+}
+
+// SendSignalOnce reserves signal before sending, reservation and rows added
+// by send are removed if sending is failed, like transaction in
+// database.Database does.
+func (store *Store) SendSignalOnce(
+	eventID, strategy, signalType string,
+	send func(database.DatabaseInterface) error,
+) (bool, error) {
+	var sent bool
+	err := store.inTransaction(func(transaction *Transaction) error {
+		store.mutex.Lock()
+		for _, signal := range store.Signals {
+			if signal.EventID == eventID &&
+				signal.Strategy == strategy &&
+				signal.SignalType == signalType {
+				store.mutex.Unlock()
+				return nil
+			}
+		}
+
+		signal := Signal{
+			EventID:    eventID,
+			Strategy:   strategy,
+			SignalType: signalType,
+			CreatedAt:  tools.TimeNow(),
+		}
+		store.Signals = append(store.Signals, signal)
+		store.mutex.Unlock()
+
+		transaction.onRollback(func() {
+			store.mutex.Lock()
+			defer store.mutex.Unlock()
+			for i := range store.Signals {
+				if store.Signals[i] == signal {
+					store.Signals = append(store.Signals[:i], store.Signals[i+1:]...)
+					break
+				}
+			}
+		})
+
+		err := send(transaction)
+		if err != nil {
+			return err
+		}
+
+		sent = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return sent, nil
+}
+
+// Transaction is passed to functions run by InTransaction, it removes queued
+// outbox messages, signal deliveries, digest events and report deliveries if
+// function fails.
+type Transaction struct {
+	*Store
+	rollback []func()
+}
+
+func (store *Store) InTransaction(do func(database.DatabaseInterface) error) error {
+	return store.inTransaction(func(transaction *Transaction) error {
+		return do(transaction)
+	})
+}
+
+func (store *Store) inTransaction(do func(*Transaction) error) error {
+	transaction := &Transaction{Store: store}
+	err := do(transaction)
+	if err != nil {
+		for i := len(transaction.rollback) - 1; i >= 0; i-- {
+			transaction.rollback[i]()
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (transaction *Transaction) onRollback(rollback func()) {
+	transaction.rollback = append(transaction.rollback, rollback)
+}
+
+func (transaction *Transaction) EnqueueOutboxMessage(message database.OutboxMessage) (int64, error) {
+	id, created := transaction.Store.enqueueOutboxMessage(message)
+	if created {
+		transaction.onRollback(func() {
+			transaction.Store.deleteOutboxMessage(id)
+		})
+	}
+
+	return id, nil
+}
+
+func (transaction *Transaction) InsertSignalDelivery(delivery database.SignalDelivery) error {
+	err := transaction.Store.InsertSignalDelivery(delivery)
+	if err != nil {
+		return err
+	}
+
+	transaction.onRollback(func() {
+		store := transaction.Store
+		store.mutex.Lock()
+		defer store.mutex.Unlock()
+		for i := range store.SignalDeliveries {
+			if store.SignalDeliveries[i].SignalDelivery == delivery {
+				store.SignalDeliveries = append(store.SignalDeliveries[:i], store.SignalDeliveries[i+1:]...)
+				break
+			}
+		}
+	})
+
+	return nil
+}
+
+func (transaction *Transaction) InsertDigestEvents(
+	chatID int64,
+	eventIDs []string,
+	sentAt time.Time,
+) error {
+	store := transaction.Store
+	store.mutex.Lock()
+	var inserted []DigestDelivery
+	for _, eventID := range eventIDs {
+		delivery := DigestDelivery{ChatID: chatID, EventID: eventID}
+		if _, ok := store.DigestEvents[delivery]; !ok {
+			store.DigestEvents[delivery] = sentAt
+			inserted = append(inserted, delivery)
+		}
+	}
+	store.mutex.Unlock()
+
+	transaction.onRollback(func() {
+		store.mutex.Lock()
+		defer store.mutex.Unlock()
+		for _, delivery := range inserted {
+			delete(store.DigestEvents, delivery)
+		}
+	})
+
+	return nil
+}
+
+func (transaction *Transaction) InsertReportDelivery(
+	report string,
+	periodStart time.Time,
+	sentAt time.Time,
+) (bool, error) {
+	inserted, err := transaction.Store.InsertReportDelivery(report, periodStart, sentAt)
+	if err != nil || !inserted {
+		return inserted, err
+	}
+
+	transaction.onRollback(func() {
+		store := transaction.Store
+		store.mutex.Lock()
+		defer store.mutex.Unlock()
+		delete(store.ReportDeliveries, ReportDelivery{Report: report, PeriodStart: periodStart.Unix()})
+	})
+
+	return true, nil
+}
+
+func (store *Store) InsertLiveEventResult(event requester.EventWithOdds) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	odds := event.ResultEventWithOdds.Odds.Odds91_1[0]
+	lastHomeOdd, err := strconv.ParseFloat(odds.HomeOd, 64)
+	if err != nil {
+		return err
+	}
+
+	lastAwayOdd, err := strconv.ParseFloat(odds.AwayOd, 64)
+	if err != nil {
+		return err
+	}
+
+	store.LiveEventsResults = append(store.LiveEventsResults, requester.LiveEventResult{
+		EventID:           event.EventID,
+		Favorite:          event.Favorite,
+		LastHomeOdd:       lastHomeOdd,
+		LastAwayOdd:       lastAwayOdd,
+		Score:             odds.SS,
+		WinnerInSecondSet: event.WinnerInSecondSet,
+		CreatedAt:         tools.TimeNow(),
+	})
+
+	return nil
+}
+
+func (store *Store) UpdateLiveEventsResultsScoreAndWinnerFields(eventID, setData, winner string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for i := range store.LiveEventsResults {
+		if store.LiveEventsResults[i].EventID == eventID {
+			store.LiveEventsResults[i].Score = setData
+			store.LiveEventsResults[i].WinnerInSecondSet = winner
+		}
+	}
+
+	return nil
+}
+
+func (store *Store) GetLiveEventsResultsOnPreviousDate() ([]requester.LiveEventResult, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return nil, err
+	}
+
+	previousDay := tools.BeginningOfDay(timeNow.AddDate(0, 0, -1))
+	var result []requester.LiveEventResult
+	for _, event := range store.LiveEventsResults {
+		if isInRange(event.CreatedAt, previousDay, previousDay.AddDate(0, 0, 1)) {
+			result = append(result, event)
+		}
+	}
+
+	return result, nil
+}
+
+func (store *Store) InsertEventsResultsToStatistic(events []requester.LiveEventResult) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, event := range events {
+		if store.isStatisticContainsEvent(event.EventID) {
+			continue
+		}
+
+		playerIsWin := "false"
+		if event.WinnerInSecondSet == event.Favorite {
+			playerIsWin = "true"
+		}
+
+		store.Statistic = append(store.Statistic, database.StatisticResultOfPreviousDay{
+			EventID:           event.EventID,
+			PlayerIsWin:       playerIsWin,
+			Score:             event.Score,
+			WinnerInSecondSet: event.WinnerInSecondSet,
+			CreatedAt:         tools.TimeNow(),
+		})
+	}
+
+	return nil
+}
+
+func (store *Store) GetStatisticOnPreviousWeek() ([]database.StatisticResultOfPreviousDay, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return nil, err
+	}
+
+	weekAgo := tools.BeginningOfDay(timeNow.AddDate(0, 0, -7))
+	var result []database.StatisticResultOfPreviousDay
+	for _, item := range store.Statistic {
+		if !item.CreatedAt.Before(weekAgo) {
+			result = append(result, item)
+		}
+	}
+
+	return result, nil
+}
+
+func (store *Store) InsertLeagues(leagues []database.League) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, league := range leagues {
+		if _, ok := store.Leagues[league.ID]; ok {
+			continue
+		}
+
+		store.Leagues[league.ID] = league
+	}
+
+	return nil
+}
+
+func (store *Store) GetLeagues() ([]database.League, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	var result []database.League
+	for _, league := range store.Leagues {
+		result = append(result, league)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
+
+func (store *Store) SetChatTimezone(chatID int64, timezone string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.ChatTimezones[chatID] = timezone
+	return nil
+}
+
+func (store *Store) GetChatTimezone(chatID int64) (string, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	return store.ChatTimezones[chatID], nil
+}
+
+func (store *Store) SetChatLanguage(chatID int64, language string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.ChatLanguages[chatID] = language
+	return nil
+}
+
+func (store *Store) GetChatLanguage(chatID int64) (string, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	return store.ChatLanguages[chatID], nil
+}
+
+func (store *Store) InsertEventRule(rule database.EventRule) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, storedRule := range store.EventRules {
+		if storedRule.Kind == rule.Kind && storedRule.Value == rule.Value {
+			return nil
+		}
+	}
+
+	rule.ID = len(store.EventRules) + 1
+	rule.CreatedAt = tools.TimeNow()
+	store.EventRules = append(store.EventRules, rule)
+	return nil
+}
+
+func (store *Store) DeleteEventRule(kind, value string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	var rules []database.EventRule
+	for _, rule := range store.EventRules {
+		if rule.Kind != kind || rule.Value != value {
+			rules = append(rules, rule)
+		}
+	}
+
+	store.EventRules = rules
+	return nil
+}
+
+func (store *Store) GetEventRules() ([]database.EventRule, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	return append([]database.EventRule{}, store.EventRules...), nil
+}
+
+func (store *Store) InsertAdminAction(action database.AdminAction) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	action.ID = len(store.AdminActions) + 1
+	action.CreatedAt = tools.TimeNow()
+	store.AdminActions = append(store.AdminActions, action)
+	return nil
+}
+
+func (store *Store) UpsertSubscriber(subscriber database.Subscriber) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.upsertSubscriber(subscriber)
+	return nil
+}
+
+func (store *Store) DeactivateSubscriber(chatID int64) (bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for i := range store.Subscribers {
+		if store.Subscribers[i].ChatID == chatID && store.Subscribers[i].IsActive {
+			store.Subscribers[i].IsActive = false
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (store *Store) GetActiveSubscribers() ([]database.Subscriber, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	timeNow := tools.TimeNow()
+	var result []database.Subscriber
+	for _, subscriber := range store.Subscribers {
+		if subscriber.IsActive &&
+			(subscriber.SecretKeyExpiredAt == nil || subscriber.SecretKeyExpiredAt.After(timeNow)) {
+			result = append(result, subscriber)
+		}
+	}
+
+	return result, nil
+}
+
+func (store *Store) GetSubscriber(chatID int64) (*database.Subscriber, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, subscriber := range store.Subscribers {
+		if subscriber.ChatID == chatID {
+			return &subscriber, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (store *Store) GetSubscribersToRemind(until time.Time) ([]database.Subscriber, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	timeNow := tools.TimeNow()
+	var result []database.Subscriber
+	for _, subscriber := range store.Subscribers {
+		if _, ok := store.RemindedAt[subscriber.ChatID]; ok {
+			continue
+		}
+
+		if subscriber.IsActive &&
+			subscriber.SecretKeyExpiredAt != nil &&
+			subscriber.SecretKeyExpiredAt.After(timeNow) &&
+			!subscriber.SecretKeyExpiredAt.After(until) {
+			result = append(result, subscriber)
+		}
+	}
+
+	return result, nil
+}
+
+func (store *Store) MarkSubscriberReminded(chatID int64) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.RemindedAt[chatID] = tools.TimeNow()
+	return nil
+}
+
+func (store *Store) DeactivateExpiredSubscribers() ([]database.Subscriber, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	timeNow := tools.TimeNow()
+	var result []database.Subscriber
+	for i, subscriber := range store.Subscribers {
+		if subscriber.IsActive &&
+			subscriber.SecretKeyExpiredAt != nil &&
+			!subscriber.SecretKeyExpiredAt.After(timeNow) {
+			store.Subscribers[i].IsActive = false
+			result = append(result, store.Subscribers[i])
+		}
+	}
+
+	return result, nil
+}
+
+func (store *Store) InsertAccessCode(code database.AccessCode) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	code.CreatedAt = tools.TimeNow()
+	store.AccessCodes = append(store.AccessCodes, code)
+	return nil
+}
+
+func (store *Store) RedeemAccessCode(
+	code string,
+	subscriber database.Subscriber,
+) (time.Time, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for i := range store.AccessCodes {
+		accessCode := &store.AccessCodes[i]
+		if accessCode.Code != code {
+			continue
+		}
+
+		timeNow := tools.TimeNow()
+		err := database.CheckAccessCode(*accessCode, accessCode.Redemptions, subscriber.ChatID, timeNow)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		expiresAt := database.GetAccessExpiry(*accessCode, accessCode.Redemptions, subscriber.ChatID, timeNow)
+		accessCode.Redemptions = append(accessCode.Redemptions, database.AccessCodeRedemption{
+			Code:       code,
+			ChatID:     subscriber.ChatID,
+			RedeemedAt: timeNow,
+		})
+
+		subscriber.SecretKey = code
+		subscriber.SecretKeyExpiredAt = &expiresAt
+		store.upsertSubscriber(subscriber)
+		delete(store.RemindedAt, subscriber.ChatID)
+
+		return expiresAt, nil
+	}
+
+	return time.Time{}, database.ErrAccessCodeNotFound
+}
+
+func (store *Store) GetSubscriberPreferences(chatID int64) (database.Preferences, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	preferences, ok := store.Preferences[chatID]
+	if !ok {
+		return database.GetDefaultPreferences(chatID), nil
+	}
+
+	return preferences, nil
+}
+
+func (store *Store) SetSubscriberPreferences(preferences database.Preferences) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.Preferences[preferences.ChatID] = preferences
+	return nil
+}
+
+func (store *Store) InsertSignalDelivery(delivery database.SignalDelivery) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.SignalDeliveries = append(store.SignalDeliveries, SignalDelivery{
+		SignalDelivery: delivery,
+		DeliveredAt:    tools.TimeNow(),
+	})
+	return nil
+}
+
+func (store *Store) CountSignalDeliveries(chatID int64, from time.Time) (int, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	var count int
+	for _, delivery := range store.SignalDeliveries {
+		if delivery.ChatID == chatID && !delivery.DeliveredAt.Before(from) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (store *Store) GetLiveEventResult(eventID string) (*requester.LiveEventResult, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for i := len(store.LiveEventsResults) - 1; i >= 0; i-- {
+		if store.LiveEventsResults[i].EventID == eventID {
+			result := store.LiveEventsResults[i]
+			return &result, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (store *Store) InsertBet(bet database.Bet) (bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, existing := range store.Bets {
+		if existing.UserID == bet.UserID && existing.EventID == bet.EventID {
+			return false, nil
+		}
+	}
+
+	bet.ID = int64(len(store.Bets) + 1)
+	bet.Status = database.BET_STATUS_OPEN
+	bet.CreatedAt = tools.TimeNow()
+	store.Bets = append(store.Bets, bet)
+	return true, nil
+}
+
+func (store *Store) UpdateBetStakeAndOdd(userID int64, eventID string, stake, odd float64) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for i := range store.Bets {
+		bet := &store.Bets[i]
+		if bet.UserID == userID && bet.EventID == eventID && bet.Status == database.BET_STATUS_OPEN {
+			bet.Stake = stake
+			bet.Odd = odd
+		}
+	}
+
+	return nil
+}
+
+func (store *Store) SettleOpenBets() ([]database.Bet, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	winners := map[string]string{}
+	for _, result := range store.LiveEventsResults {
+		if result.WinnerInSecondSet != "" {
+			winners[result.EventID] = result.WinnerInSecondSet
+		}
+	}
+
+	var result []database.Bet
+	for i := range store.Bets {
+		bet := store.Bets[i]
+		winner, ok := winners[bet.EventID]
+		if !ok || bet.Status != database.BET_STATUS_OPEN {
+			continue
+		}
+
+		store.Bets[i] = database.SettleBet(bet, winner, tools.TimeNow())
+		result = append(result, store.Bets[i])
+	}
+
+	return result, nil
+}
+
+func (store *Store) GetBets(userID int64) ([]database.Bet, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	var result []database.Bet
+	for i := len(store.Bets) - 1; i >= 0; i-- {
+		if store.Bets[i].UserID == userID {
+			result = append(result, store.Bets[i])
+		}
+	}
+
+	return result, nil
+}
+
+func (store *Store) EnqueueOutboxMessage(message database.OutboxMessage) (int64, error) {
+	id, _ := store.enqueueOutboxMessage(message)
+	return id, nil
+}
+
+// enqueueOutboxMessage returns false when pending edit has been updated
+// instead of adding new message.
+func (store *Store) enqueueOutboxMessage(message database.OutboxMessage) (int64, bool) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if message.EditOfID != 0 {
+		for i := range store.Outbox {
+			edit := &store.Outbox[i]
+			if edit.EditOfID == message.EditOfID && edit.Status == database.OUTBOX_STATUS_PENDING {
+				edit.Text = message.Text
+				edit.HTML = message.HTML
+				edit.Buttons = message.Buttons
+				return edit.ID, false
+			}
+		}
+	}
+
+	store.outboxSequence++
+	message.ID = store.outboxSequence
+	message.Status = database.OUTBOX_STATUS_PENDING
+	message.NextAttemptAt = message.CreatedAt
+	store.Outbox = append(store.Outbox, message)
+	return message.ID, true
+}
+
+func (store *Store) getOutboxMessage(id int64) *database.OutboxMessage {
+	for i := range store.Outbox {
+		if store.Outbox[i].ID == id {
+			return &store.Outbox[i]
+		}
+	}
+
+	return nil
+}
+
+func (store *Store) deleteOutboxMessage(id int64) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for i := range store.Outbox {
+		if store.Outbox[i].ID == id {
+			store.Outbox = append(store.Outbox[:i], store.Outbox[i+1:]...)
+			return
+		}
+	}
+}
+
+func (store *Store) GetPendingOutboxMessages(timeNow time.Time, limit int) ([]database.OutboxMessage, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	var messages []database.OutboxMessage
+	pendingChats := map[int64]bool{}
+	for _, message := range store.Outbox {
+		if message.Status != database.OUTBOX_STATUS_PENDING {
+			continue
+		}
+
+		isOlderPending := pendingChats[message.ChatID]
+		pendingChats[message.ChatID] = true
+		if isOlderPending || message.NextAttemptAt.After(timeNow) {
+			continue
+		}
+
+		if replyTo := store.getOutboxMessage(message.ReplyToID); replyTo != nil {
+			message.ReplyToMessageID = replyTo.MessageID
+			message.ReplyToStatus = replyTo.Status
+		}
+
+		if editOf := store.getOutboxMessage(message.EditOfID); editOf != nil {
+			message.EditOfMessageID = editOf.MessageID
+			message.EditOfStatus = editOf.Status
+		}
+
+		if photo, ok := store.OutboxPhotos[message.PhotoID]; ok {
+			message.PhotoFileID = photo.FileID
+			if photo.FileID == "" {
+				message.Photo = photo.Data
+			}
+		}
+
+		messages = append(messages, message)
+	}
+
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].NextAttemptAt.Before(messages[j].NextAttemptAt)
+	})
+
+	if len(messages) > limit {
+		messages = messages[:limit]
+	}
+
+	return messages, nil
+}
+
+func (store *Store) MarkOutboxMessageSent(id int64, messageID int, sentAt time.Time) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	message := store.getOutboxMessage(id)
+	if message == nil {
+		return nil
+	}
+
+	message.Status = database.OUTBOX_STATUS_SENT
+	message.Attempts++
+	message.MessageID = messageID
+	message.SentAt = &sentAt
+	return nil
+}
+
+func (store *Store) MarkOutboxMessageRetry(id int64, nextAttemptAt time.Time, lastError string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	message := store.getOutboxMessage(id)
+	if message == nil {
+		return nil
+	}
+
+	message.Attempts++
+	message.NextAttemptAt = nextAttemptAt
+	message.LastError = lastError
+	return nil
+}
+
+func (store *Store) MarkOutboxMessageFailed(id int64, lastError string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	message := store.getOutboxMessage(id)
+	if message == nil {
+		return nil
+	}
+
+	message.Status = database.OUTBOX_STATUS_FAILED
+	message.Attempts++
+	message.LastError = lastError
+	return nil
+}
+
+func (store *Store) DeleteOldOutboxMessages(before time.Time) (int64, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	referenced := map[int64]bool{}
+	for _, message := range store.Outbox {
+		if message.Status == database.OUTBOX_STATUS_PENDING {
+			referenced[message.ReplyToID] = true
+			referenced[message.EditOfID] = true
+		}
+	}
+
+	var outbox []database.OutboxMessage
+	for _, message := range store.Outbox {
+		if message.Status != database.OUTBOX_STATUS_PENDING &&
+			message.CreatedAt.Before(before) &&
+			!referenced[message.ID] {
+			continue
+		}
+
+		outbox = append(outbox, message)
+	}
+
+	deleted := int64(len(store.Outbox) - len(outbox))
+	store.Outbox = outbox
+	return deleted, nil
+}
+
+func (store *Store) InsertOutboxPhoto(data []byte, createdAt time.Time) (int64, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.outboxPhotoSequence++
+	store.OutboxPhotos[store.outboxPhotoSequence] = OutboxPhoto{
+		Data:      data,
+		CreatedAt: createdAt,
+	}
+
+	return store.outboxPhotoSequence, nil
+}
+
+func (store *Store) SetOutboxPhotoFileID(id int64, fileID string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	photo, ok := store.OutboxPhotos[id]
+	if !ok {
+		return nil
+	}
+
+	photo.FileID = fileID
+	store.OutboxPhotos[id] = photo
+	return nil
+}
+
+func (store *Store) DeleteUnusedOutboxPhotos(before time.Time) (int64, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	used := map[int64]bool{}
+	for _, message := range store.Outbox {
+		used[message.PhotoID] = true
+	}
+
+	var deleted int64
+	for id, photo := range store.OutboxPhotos {
+		if !used[id] && photo.CreatedAt.Before(before) {
+			delete(store.OutboxPhotos, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+func (store *Store) CountOutboxMessages() (map[string]int, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	counts := map[string]int{}
+	for _, message := range store.Outbox {
+		counts[message.Status]++
+	}
+
+	return counts, nil
+}
+
+func (store *Store) upsertSubscriber(subscriber database.Subscriber) {
+	delete(store.RemindedAt, subscriber.ChatID)
+	for i := range store.Subscribers {
+		if store.Subscribers[i].ChatID == subscriber.ChatID {
+			store.Subscribers[i].Type = subscriber.Type
+			store.Subscribers[i].Username = subscriber.Username
+			store.Subscribers[i].IsActive = true
+			store.Subscribers[i].SecretKey = subscriber.SecretKey
+			store.Subscribers[i].SecretKeyExpiredAt = subscriber.SecretKeyExpiredAt
+			return
+		}
+	}
+
+	subscriber.IsActive = true
+	subscriber.JoinedAt = tools.TimeNow()
+	store.Subscribers = append(store.Subscribers, subscriber)
+}
+
+func (store *Store) isStatisticContainsEvent(eventID string) bool {
+	for _, item := range store.Statistic {
+		if item.EventID == eventID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (store *Store) GetLiveEventsResults(from, to time.Time) ([]requester.LiveEventResult, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	var results []requester.LiveEventResult
+	for _, result := range store.LiveEventsResults {
+		if result.WinnerInSecondSet != "" && isInRange(result.CreatedAt, from, to) {
+			results = append(results, store.withEvent(result))
+		}
+	}
+
+	return results, nil
+}
+
+func (store *Store) GetLastLiveEventsResults(limit int) ([]requester.LiveEventResult, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	var results []requester.LiveEventResult
+	for i := len(store.LiveEventsResults) - 1; i >= 0 && len(results) < limit; i-- {
+		results = append(results, store.withEvent(store.LiveEventsResults[i]))
+	}
+
+	return results, nil
+}
+
+// withEvent fills names of teams and league as database joins them from
+// events table.
+func (store *Store) withEvent(result requester.LiveEventResult) requester.LiveEventResult {
+	event, ok := store.Events[result.EventID]
+	if ok {
+		result.HomeCommandName = event.HomeCommandName
+		result.AwayCommandName = event.AwayCommandName
+		result.LeagueName = event.League.Name
+	}
+
+	return result
+}
+
+func isInRange(t, from, to time.Time) bool {
+	return !t.Before(from) && t.Before(to)
+}
+
+func (store *Store) GetUserRole(userID int64) (string, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	return store.Roles[userID].Role, nil
+}
+
+func (store *Store) GetUserRoles() ([]database.UserRole, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	var roles []database.UserRole
+	for _, role := range store.Roles {
+		roles = append(roles, role)
+	}
+
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].UserID < roles[j].UserID
+	})
+
+	return roles, nil
+}
+
+func (store *Store) SetUserRole(role database.UserRole) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	role.UpdatedAt = tools.TimeNow()
+	store.Roles[role.UserID] = role
+	return nil
+}
+
+func (store *Store) AddUserRole(role database.UserRole) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if _, ok := store.Roles[role.UserID]; ok {
+		return nil
+	}
+
+	role.UpdatedAt = tools.TimeNow()
+	store.Roles[role.UserID] = role
+	return nil
+}
+
+func (store *Store) SetOwner(userID int64) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	timeNow := tools.TimeNow()
+	for id, role := range store.Roles {
+		if role.Role == database.ROLE_OWNER && id != userID {
+			role.Role = database.ROLE_ADMIN
+			role.UpdatedAt = timeNow
+			store.Roles[id] = role
+		}
+	}
+
+	store.Roles[userID] = database.UserRole{
+		UserID:    userID,
+		Role:      database.ROLE_OWNER,
+		UpdatedAt: timeNow,
+	}
+	return nil
+}
+
+func (store *Store) GetDigestEventIDs(chatID int64, since time.Time) ([]string, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	var eventIDs []string
+	for delivery, sentAt := range store.DigestEvents {
+		if delivery.ChatID == chatID && !sentAt.Before(since) {
+			eventIDs = append(eventIDs, delivery.EventID)
+		}
+	}
+
+	sort.Strings(eventIDs)
+	return eventIDs, nil
+}
+
+func (store *Store) InsertDigestEvents(
+	chatID int64,
+	eventIDs []string,
+	sentAt time.Time,
+) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, eventID := range eventIDs {
+		delivery := DigestDelivery{ChatID: chatID, EventID: eventID}
+		if _, ok := store.DigestEvents[delivery]; !ok {
+			store.DigestEvents[delivery] = sentAt
+		}
+	}
+
+	return nil
+}
+
+func (store *Store) InsertReportDelivery(
+	report string,
+	periodStart time.Time,
+	sentAt time.Time,
+) (bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delivery := ReportDelivery{Report: report, PeriodStart: periodStart.Unix()}
+	if _, ok := store.ReportDeliveries[delivery]; ok {
+		return false, nil
+	}
+
+	store.ReportDeliveries[delivery] = sentAt
+	return true, nil
+}
@@ -0,0 +1,184 @@
+package simulation
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/daniilsolovey/BetBotGo/internal/transport"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+type Message struct {
+	ID        int
+	Edits     int
+	Recipient string
+	ReplyTo   int
+	Text      string
+	ParseMode tb.ParseMode
+	Buttons   [][]tb.InlineButton
+	Photo     []byte
+}
+
+// Transport records messages instead of sending them to telegram, messages to
+// blocked recipients are failed like telegram does. Uploads counts photos
+// which have been sent without file id.
+type Transport struct {
+	mutex    sync.Mutex
+	Messages []Message
+	Blocked  map[string]bool
+	Failures map[string][]error
+	Uploads  int
+
+	files map[string][]byte
+}
+
+func (transport *Transport) SendMessage(recipient tb.Recipient, text string) error {
+	_, err := transport.addMessage(recipient, text, nil, nil)
+	return err
+}
+
+func (transport *Transport) SendMessageWithOptions(
+	recipient tb.Recipient,
+	text string,
+	options *tb.SendOptions,
+) (int, error) {
+	return transport.addMessage(recipient, text, options, nil)
+}
+
+// SendPhoto records photo with caption as text of the message, uploaded photo
+// gets file id which can be sent again.
+func (transport *Transport) SendPhoto(
+	recipient tb.Recipient,
+	photo transport.Photo,
+	caption string,
+	options *tb.SendOptions,
+) (int, string, error) {
+	transport.mutex.Lock()
+	data := photo.Data
+	if photo.FileID != "" {
+		data = transport.files[photo.FileID]
+	}
+	transport.mutex.Unlock()
+
+	id, err := transport.addMessage(recipient, caption, options, data)
+	if err != nil || photo.FileID != "" {
+		return id, photo.FileID, err
+	}
+
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+
+	if transport.files == nil {
+		transport.files = map[string][]byte{}
+	}
+
+	transport.Uploads++
+	fileID := "file-" + strconv.Itoa(transport.Uploads)
+	transport.files[fileID] = data
+
+	return id, fileID, nil
+}
+
+// EditMessage replaces text and buttons of the recorded message and counts
+// edits.
+func (transport *Transport) EditMessage(
+	recipient tb.Recipient,
+	messageID int,
+	text string,
+	options *tb.SendOptions,
+) error {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+
+	if messageID < 1 || messageID > len(transport.Messages) ||
+		transport.Messages[messageID-1].Recipient != recipient.Recipient() {
+		return tb.NewAPIError(400, "Bad Request: message to edit not found")
+	}
+
+	message := &transport.Messages[messageID-1]
+	if message.Text == text {
+		return tb.ErrMessageNotModified
+	}
+
+	message.Text = text
+	message.Edits++
+	message.Buttons = nil
+	if options != nil {
+		message.ParseMode = options.ParseMode
+		if options.ReplyMarkup != nil {
+			message.Buttons = options.ReplyMarkup.InlineKeyboard
+		}
+	}
+
+	return nil
+}
+
+func (transport *Transport) Block(recipient string) {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+
+	if transport.Blocked == nil {
+		transport.Blocked = map[string]bool{}
+	}
+
+	transport.Blocked[recipient] = true
+}
+
+// Fail makes the next message to the recipient fail with given error.
+func (transport *Transport) Fail(recipient string, err error) {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+
+	if transport.Failures == nil {
+		transport.Failures = map[string][]error{}
+	}
+
+	transport.Failures[recipient] = append(transport.Failures[recipient], err)
+}
+
+func (transport *Transport) GetMessages() []Message {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+
+	return append([]Message{}, transport.Messages...)
+}
+
+func (transport *Transport) addMessage(
+	recipient tb.Recipient,
+	text string,
+	options *tb.SendOptions,
+	photo []byte,
+) (int, error) {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+
+	if transport.Blocked[recipient.Recipient()] {
+		return 0, tb.ErrBlockedByUser
+	}
+
+	if failures := transport.Failures[recipient.Recipient()]; len(failures) != 0 {
+		transport.Failures[recipient.Recipient()] = failures[1:]
+		return 0, failures[0]
+	}
+
+	message := Message{
+		ID:        len(transport.Messages) + 1,
+		Recipient: recipient.Recipient(),
+		Text:      text,
+		Photo:     photo,
+	}
+	if options != nil {
+		message.ParseMode = options.ParseMode
+		if options.ReplyTo != nil {
+			message.ReplyTo = options.ReplyTo.ID
+		}
+
+		if options.ReplyMarkup != nil {
+			message.Buttons = options.ReplyMarkup.InlineKeyboard
+		}
+	}
+
+	transport.Messages = append(transport.Messages, message)
+
+	return message.ID, nil
+}
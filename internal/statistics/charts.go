@@ -0,0 +1,320 @@
+package statistics
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/charts"
+	"github.com/daniilsolovey/BetBotGo/internal/constants"
+	"github.com/daniilsolovey/BetBotGo/internal/i18n"
+	"github.com/daniilsolovey/BetBotGo/internal/requester"
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
+	"github.com/reconquest/karma-go"
+)
+
+const (
+	CHART_PROFIT  = "profit"
+	CHART_DAILY   = "daily"
+	CHART_LEAGUES = "leagues"
+	CHART_ODDS    = "odds"
+
+	CHART_DATE_FORMAT = "02.01"
+	CHART_MAX_LEAGUES = 10
+	CHART_MAX_DAYS    = 92
+)
+
+var (
+	CHARTS = []string{CHART_PROFIT, CHART_DAILY, CHART_LEAGUES, CHART_ODDS}
+
+	// CHART_ODDS_BUCKETS are upper bounds of odds distribution buckets,
+	// the last bucket has no upper bound.
+	CHART_ODDS_BUCKETS = []float64{1.2, 1.4, 1.6, 1.8, 2.0}
+
+	chartCaptions = map[string]string{
+		CHART_PROFIT:  i18n.TEXT_CHART_PROFIT,
+		CHART_DAILY:   i18n.TEXT_CHART_DAILY,
+		CHART_LEAGUES: i18n.TEXT_CHART_LEAGUES,
+		CHART_ODDS:    i18n.TEXT_CHART_ODDS,
+	}
+)
+
+// Chart is rendered PNG image, caption is translated for every reader with
+// GetChartCaption.
+type Chart struct {
+	Name  string
+	Image []byte
+}
+
+// GetCharts renders charts of settled results within [from, to), results are
+// grouped by days in given location.
+func GetCharts(
+	results []requester.LiveEventResult,
+	from time.Time,
+	to time.Time,
+	location *time.Location,
+) ([]Chart, error) {
+	var renderedCharts []Chart
+	for _, name := range CHARTS {
+		chart, err := GetChart(name, results, from, to, location)
+		if err != nil {
+			return nil, err
+		}
+
+		renderedCharts = append(renderedCharts, chart)
+	}
+
+	return renderedCharts, nil
+}
+
+func IsKnownChart(name string) bool {
+	_, ok := chartCaptions[name]
+	return ok
+}
+
+func GetChart(
+	name string,
+	results []requester.LiveEventResult,
+	from time.Time,
+	to time.Time,
+	location *time.Location,
+) (Chart, error) {
+	var chart charts.Chart
+	switch name {
+	case CHART_PROFIT:
+		chart = getProfitChart(results, from.In(location), to.In(location))
+	case CHART_DAILY:
+		chart = getDailyChart(results, from.In(location), to.In(location))
+	case CHART_LEAGUES:
+		chart = getLeaguesChart(results)
+	case CHART_ODDS:
+		chart = getOddsChart(results)
+	default:
+		return Chart{}, fmt.Errorf("unknown chart: %q", name)
+	}
+
+	image, err := charts.Render(chart)
+	if err != nil {
+		return Chart{}, karma.Format(
+			err,
+			"unable to render chart: %s",
+			name,
+		)
+	}
+
+	return Chart{Name: name, Image: image}, nil
+}
+
+func GetChartCaption(
+	language string,
+	name string,
+	from time.Time,
+	to time.Time,
+) string {
+	return i18n.Translate(
+		language,
+		chartCaptions[name],
+		i18n.FormatDate(language, from),
+		i18n.FormatDate(language, to.Add(-time.Nanosecond)),
+	)
+}
+
+// getProfitChart draws profit with flat stake of one unit accumulated by the
+// end of every day.
+func getProfitChart(
+	results []requester.LiveEventResult,
+	from time.Time,
+	to time.Time,
+) charts.Chart {
+	days := getDays(from, to)
+	profits := make([]float64, len(days))
+	for _, result := range results {
+		day := getDayIndex(days, result.CreatedAt)
+		if day < 0 {
+			continue
+		}
+
+		if handleResultOfPreviousDay(result) {
+			profits[day] += getFavoriteOdd(result) - 1
+		} else {
+			profits[day]--
+		}
+	}
+
+	for i := 1; i < len(profits); i++ {
+		profits[i] += profits[i-1]
+	}
+
+	for i := range profits {
+		profits[i] = roundNumber(profits[i], 0.01)
+	}
+
+	return charts.Chart{
+		Kind:        charts.KIND_LINE,
+		Labels:      getDayLabels(days),
+		Series:      []charts.Series{{Color: charts.COLOR_BLUE, Values: profits}},
+		ValueFormat: "%+.1f",
+	}
+}
+
+func getDailyChart(
+	results []requester.LiveEventResult,
+	from time.Time,
+	to time.Time,
+) charts.Chart {
+	days := getDays(from, to)
+	wins := make([]float64, len(days))
+	loses := make([]float64, len(days))
+	for _, result := range results {
+		day := getDayIndex(days, result.CreatedAt)
+		if day < 0 {
+			continue
+		}
+
+		if handleResultOfPreviousDay(result) {
+			wins[day]++
+		} else {
+			loses[day]++
+		}
+	}
+
+	return charts.Chart{
+		Kind:   charts.KIND_BARS,
+		Labels: getDayLabels(days),
+		Series: []charts.Series{
+			{Color: charts.COLOR_GREEN, Values: wins},
+			{Color: charts.COLOR_RED, Values: loses},
+		},
+		ValueFormat: "%.0f",
+		MinStep:     1,
+	}
+}
+
+// getLeaguesChart draws hit rate of leagues with the most bets, number of
+// bets is shown next to the league name.
+func getLeaguesChart(results []requester.LiveEventResult) charts.Chart {
+	type league struct {
+		name string
+		bets int
+		wins int
+	}
+
+	leaguesByName := map[string]*league{}
+	var leagues []*league
+	for _, result := range results {
+		item, ok := leaguesByName[result.LeagueName]
+		if !ok {
+			item = &league{name: result.LeagueName}
+			leaguesByName[result.LeagueName] = item
+			leagues = append(leagues, item)
+		}
+
+		item.bets++
+		if handleResultOfPreviousDay(result) {
+			item.wins++
+		}
+	}
+
+	sort.SliceStable(leagues, func(i, j int) bool {
+		if leagues[i].bets != leagues[j].bets {
+			return leagues[i].bets > leagues[j].bets
+		}
+
+		return leagues[i].name < leagues[j].name
+	})
+
+	if len(leagues) > CHART_MAX_LEAGUES {
+		leagues = leagues[:CHART_MAX_LEAGUES]
+	}
+
+	var labels []string
+	var hitRates []float64
+	for _, item := range leagues {
+		labels = append(labels, fmt.Sprintf("%s (%d)", item.name, item.bets))
+		hitRates = append(hitRates, roundNumber(float64(item.wins)*100/float64(item.bets), 0.1))
+	}
+
+	return charts.Chart{
+		Kind:        charts.KIND_HORIZONTAL_BARS,
+		Labels:      labels,
+		Series:      []charts.Series{{Color: charts.COLOR_BLUE, Values: hitRates}},
+		ValueFormat: "%.0f%%",
+		MinStep:     1,
+	}
+}
+
+func getOddsChart(results []requester.LiveEventResult) charts.Chart {
+	labels := []string{fmt.Sprintf("<%.1f", CHART_ODDS_BUCKETS[0])}
+	for i := 1; i < len(CHART_ODDS_BUCKETS); i++ {
+		labels = append(labels, fmt.Sprintf("%.1f-%.1f", CHART_ODDS_BUCKETS[i-1], CHART_ODDS_BUCKETS[i]))
+	}
+
+	labels = append(labels, fmt.Sprintf(">=%.1f", CHART_ODDS_BUCKETS[len(CHART_ODDS_BUCKETS)-1]))
+
+	wins := make([]float64, len(labels))
+	loses := make([]float64, len(labels))
+	for _, result := range results {
+		bucket := sort.Search(len(CHART_ODDS_BUCKETS), func(i int) bool {
+			return getFavoriteOdd(result) < CHART_ODDS_BUCKETS[i]
+		})
+
+		if handleResultOfPreviousDay(result) {
+			wins[bucket]++
+		} else {
+			loses[bucket]++
+		}
+	}
+
+	return charts.Chart{
+		Kind:   charts.KIND_BARS,
+		Labels: labels,
+		Series: []charts.Series{
+			{Color: charts.COLOR_GREEN, Values: wins},
+			{Color: charts.COLOR_RED, Values: loses},
+		},
+		ValueFormat: "%.0f",
+		MinStep:     1,
+	}
+}
+
+func getFavoriteOdd(result requester.LiveEventResult) float64 {
+	if result.Favorite == constants.FAVORITE_IS_AWAY {
+		return result.LastAwayOdd
+	}
+
+	return result.LastHomeOdd
+}
+
+// getDays returns beginnings of days within [from, to).
+func getDays(from time.Time, to time.Time) []time.Time {
+	var days []time.Time
+	for day := tools.BeginningOfDay(from); day.Before(to); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+
+	return days
+}
+
+func getDayIndex(days []time.Time, createdAt time.Time) int {
+	if len(days) == 0 {
+		return -1
+	}
+
+	day := tools.BeginningOfDay(createdAt.In(days[0].Location()))
+	for i := range days {
+		if days[i].Equal(day) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func getDayLabels(days []time.Time) []string {
+	var labels []string
+	for _, day := range days {
+		labels = append(labels, day.Format(CHART_DATE_FORMAT))
+	}
+
+	return labels
+}
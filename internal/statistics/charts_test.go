@@ -0,0 +1,108 @@
+package statistics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/daniilsolovey/BetBotGo/internal/charts"
+	"github.com/daniilsolovey/BetBotGo/internal/requester"
+)
+
+func getTestChartResults(from time.Time) []requester.LiveEventResult {
+	return []requester.LiveEventResult{
+		{
+			EventID:           "1",
+			Favorite:          "home",
+			LastHomeOdd:       1.5,
+			LeagueName:        "Italy A1",
+			WinnerInSecondSet: "home",
+			CreatedAt:         from.Add(20 * time.Hour),
+		},
+		{
+			EventID:           "2",
+			Favorite:          "away",
+			LastAwayOdd:       1.9,
+			LeagueName:        "Poland Plus Liga",
+			WinnerInSecondSet: "home",
+			CreatedAt:         from.Add(21 * time.Hour),
+		},
+		{
+			EventID:           "3",
+			Favorite:          "away",
+			LastAwayOdd:       2.2,
+			LeagueName:        "Poland Plus Liga",
+			WinnerInSecondSet: "away",
+			CreatedAt:         from.Add(50 * time.Hour),
+		},
+		{
+			EventID:           "4",
+			Favorite:          "home",
+			LastHomeOdd:       1.1,
+			LeagueName:        "Poland Plus Liga",
+			WinnerInSecondSet: "home",
+			CreatedAt:         from.Add(-time.Hour),
+		},
+	}
+}
+
+func TestStatistics_getProfitChart_AccumulatesProfitByDay(
+	t *testing.T,
+) {
+	location, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	from := time.Date(2021, 9, 1, 0, 0, 0, 0, location)
+	chart := getProfitChart(getTestChartResults(from), from, from.AddDate(0, 0, 3))
+
+	assert.Equal(t, charts.KIND_LINE, chart.Kind)
+	assert.Equal(t, []string{"01.09", "02.09", "03.09"}, chart.Labels)
+	assert.InDeltaSlice(t, []float64{-0.5, -0.5, 0.7}, chart.Series[0].Values, 0.001)
+}
+
+func TestStatistics_getDailyChart_CountsWinsAndLosesByDay(
+	t *testing.T,
+) {
+	location, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	from := time.Date(2021, 9, 1, 0, 0, 0, 0, location)
+	chart := getDailyChart(getTestChartResults(from), from, from.AddDate(0, 0, 3))
+
+	assert.Equal(t, []string{"01.09", "02.09", "03.09"}, chart.Labels)
+	assert.Equal(t, []float64{1, 0, 1}, chart.Series[0].Values)
+	assert.Equal(t, []float64{1, 0, 0}, chart.Series[1].Values)
+}
+
+func TestStatistics_getLeaguesChart_SortsLeaguesByNumberOfBets(
+	t *testing.T,
+) {
+	chart := getLeaguesChart(getTestChartResults(time.Now()))
+
+	assert.Equal(t, charts.KIND_HORIZONTAL_BARS, chart.Kind)
+	assert.Equal(t, []string{"Poland Plus Liga (3)", "Italy A1 (1)"}, chart.Labels)
+	assert.InDeltaSlice(t, []float64{66.7, 100}, chart.Series[0].Values, 0.001)
+}
+
+func TestStatistics_getOddsChart_CountsResultsByOddsBuckets(
+	t *testing.T,
+) {
+	chart := getOddsChart(getTestChartResults(time.Now()))
+
+	assert.Equal(t, []string{"<1.2", "1.2-1.4", "1.4-1.6", "1.6-1.8", "1.8-2.0", ">=2.0"}, chart.Labels)
+	assert.Equal(t, []float64{1, 0, 1, 0, 0, 1}, chart.Series[0].Values)
+	assert.Equal(t, []float64{0, 0, 0, 0, 1, 0}, chart.Series[1].Values)
+}
+
+func TestStatistics_GetChart_UnknownChart(
+	t *testing.T,
+) {
+	_, err := GetChart("pie", nil, time.Now(), time.Now(), time.UTC)
+	assert.Error(t, err)
+	assert.False(t, IsKnownChart("pie"))
+
+	for _, name := range CHARTS {
+		assert.True(t, IsKnownChart(name))
+	}
+}
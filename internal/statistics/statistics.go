@@ -1,27 +1,21 @@
 package statistics
 
 import (
-	"fmt"
 	"math"
 
 	"github.com/daniilsolovey/BetBotGo/internal/constants"
 	"github.com/daniilsolovey/BetBotGo/internal/database"
-	"github.com/daniilsolovey/BetBotGo/internal/operator"
+	"github.com/daniilsolovey/BetBotGo/internal/delivery"
+	"github.com/daniilsolovey/BetBotGo/internal/i18n"
+	"github.com/daniilsolovey/BetBotGo/internal/notifier"
 	"github.com/daniilsolovey/BetBotGo/internal/requester"
-	"github.com/daniilsolovey/BetBotGo/internal/transport"
+	"github.com/daniilsolovey/BetBotGo/internal/tools"
 	"github.com/reconquest/karma-go"
+	"github.com/reconquest/pkg/log"
 )
 
 const (
-	PLAYER_IS_WIN                   = "true"
-	TEXT_STATISTICS_ON_PREVIOUS_DAY = "Результаты за вчера:\n" +
-		"  win: %d\n" +
-		"  lose: %d\n" +
-		"  average odd: %f\n"
-	TEXT_STATISTICS_ON_PREVIOUS_WEEK = "Результаты за прошлую неделю:\n" +
-		"  win: %d\n" +
-		"  lose: %d\n" +
-		"  average odd: %f\n"
+	PLAYER_IS_WIN = "true"
 )
 
 type ResultOfPreviousDay struct {
@@ -31,21 +25,29 @@ type ResultOfPreviousDay struct {
 }
 
 type Statistics struct {
-	database  *database.Database
-	transport transport.Transport
+	database database.DatabaseInterface
+	delivery *delivery.Delivery
+	notifier *notifier.Router
 }
 
-func NewStatistics(
-	database *database.Database,
-	transport transport.Transport,
-) *Statistics {
+func NewStatistics(database database.DatabaseInterface) *Statistics {
 	statistics := &Statistics{
-		database:  database,
-		transport: transport,
+		database: database,
+		delivery: delivery.NewDelivery(database),
 	}
 	return statistics
 }
 
+// SetNotifier enables sending of reports to additional channels.
+func (statistics *Statistics) SetNotifier(router *notifier.Router) {
+	statistics.notifier = router
+}
+
+// SetBroadcastRoutes enables sending of reports to broadcast chats.
+func (statistics *Statistics) SetBroadcastRoutes(routes map[string][]int64) {
+	statistics.delivery.SetBroadcastRoutes(routes)
+}
+
 func (statistics *Statistics) GetStatisticOnPreviousDayAndNotify() error {
 	events, err := statistics.getLiveEventsResultsOnPreviousDateAndWriteToStatistic()
 	if err != nil {
@@ -56,14 +58,16 @@ func (statistics *Statistics) GetStatisticOnPreviousDayAndNotify() error {
 	}
 
 	handledEvents := handleResultsOfPreviousDay(events)
-	text := fmt.Sprintf(
-		TEXT_STATISTICS_ON_PREVIOUS_DAY,
-		handledEvents.Win,
-		handledEvents.Lose,
-		handledEvents.AverageOdd,
+	err = statistics.delivery.SendReportToSubscribersFunc(
+		constants.REPORT_DAILY,
+		func(subscriber database.Subscriber) string {
+			return getTextAboutResults(
+				i18n.TEXT_STATISTICS_ON_PREVIOUS_DAY,
+				handledEvents,
+				statistics.delivery.GetLanguage(subscriber.ChatID),
+			)
+		},
 	)
-
-	err = statistics.transport.SendMessage(operator.TEMP_RECIPIENT, text)
 	if err != nil {
 		return karma.Format(
 			err,
@@ -71,10 +75,26 @@ func (statistics *Statistics) GetStatisticOnPreviousDayAndNotify() error {
 		)
 	}
 
+	statistics.notifier.Notify(notifier.Notification{
+		Kind: notifier.KIND_REPORT,
+		Text: getTextAboutResults(i18n.TEXT_STATISTICS_ON_PREVIOUS_DAY, handledEvents, i18n.DefaultLanguage),
+	})
+
 	return nil
 }
 
+// GetStatisticOnPreviousWeekAndNotify sends the weekly report once per week,
+// the week is recorded in the same transaction as queued messages, so the
+// report is not sent again after restart.
 func (statistics *Statistics) GetStatisticOnPreviousWeekAndNotify() error {
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to get current time for statistic on previous week",
+		)
+	}
+
 	results, err := statistics.database.GetStatisticOnPreviousWeek()
 	if err != nil {
 		return karma.Format(
@@ -83,22 +103,120 @@ func (statistics *Statistics) GetStatisticOnPreviousWeekAndNotify() error {
 		)
 	}
 
+	weekStart := tools.BeginningOfDay(timeNow).AddDate(0, 0, -7)
 	handledResults := handleResultsOfPreviousWeek(results)
-	text := fmt.Sprintf(
-		TEXT_STATISTICS_ON_PREVIOUS_WEEK,
-		handledResults.Win,
-		handledResults.Lose,
-		handledResults.AverageOdd,
-	)
 
-	err = statistics.transport.SendMessage(operator.TEMP_RECIPIENT, text)
+	var sent bool
+	err = statistics.database.InTransaction(func(tx database.DatabaseInterface) error {
+		var err error
+		sent, err = tx.InsertReportDelivery(constants.REPORT_WEEKLY, weekStart, timeNow)
+		if err != nil || !sent {
+			return err
+		}
+
+		return statistics.delivery.WithDatabase(tx).SendReportToSubscribersFunc(
+			constants.REPORT_WEEKLY,
+			func(subscriber database.Subscriber) string {
+				return getTextAboutResults(
+					i18n.TEXT_STATISTICS_ON_PREVIOUS_WEEK,
+					handledResults,
+					statistics.delivery.GetLanguage(subscriber.ChatID),
+				)
+			},
+		)
+	})
 	if err != nil {
 		return karma.Format(
 			err,
-			"unable to send statistic on previous day to telegram",
+			"unable to send statistic on previous week to telegram",
 		)
 	}
 
+	if !sent {
+		log.Infof(nil, "statistic on previous week is already sent, week: %s", weekStart)
+		return nil
+	}
+
+	statistics.notifier.Notify(notifier.Notification{
+		Kind: notifier.KIND_REPORT,
+		Text: getTextAboutResults(i18n.TEXT_STATISTICS_ON_PREVIOUS_WEEK, handledResults, i18n.DefaultLanguage),
+	})
+
+	// text report is already queued, so failed charts are not retried to
+	// avoid sending it twice
+	err = statistics.sendChartsOnPreviousWeek()
+	if err != nil {
+		log.Error(err)
+	}
+
+	return nil
+}
+
+// sendChartsOnPreviousWeek sends charts of seven days before today after the
+// weekly report, nothing is sent when there are no settled signals.
+func (statistics *Statistics) sendChartsOnPreviousWeek() error {
+	timeNow, err := tools.GetCurrentTime()
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to get current time for charts on previous week",
+		)
+	}
+
+	to := tools.BeginningOfDay(timeNow)
+	from := to.AddDate(0, 0, -7)
+
+	results, err := statistics.database.GetLiveEventsResults(from, to)
+	if err != nil {
+		return karma.Format(
+			err,
+			"unable to get live events results for charts on previous week",
+		)
+	}
+
+	if len(results) == 0 {
+		return nil
+	}
+
+	renderedCharts, err := GetCharts(results, from, to, timeNow.Location())
+	if err != nil {
+		return err
+	}
+
+	for _, chart := range renderedCharts {
+		chart := chart
+		photoID, err := statistics.delivery.AddPhoto(chart.Image)
+		if err != nil {
+			return karma.Format(
+				err,
+				"unable to store chart on previous week: %s",
+				chart.Name,
+			)
+		}
+
+		err = statistics.delivery.SendReportMessageToSubscribersFunc(
+			constants.REPORT_WEEKLY,
+			func(subscriber database.Subscriber) delivery.Message {
+				return delivery.Message{
+					Text: GetChartCaption(
+						statistics.delivery.GetLanguage(subscriber.ChatID),
+						chart.Name,
+						from,
+						to,
+					),
+					PhotoID: photoID,
+				}
+			},
+		)
+		if err != nil {
+			return karma.Format(
+				err,
+				"unable to send chart on previous week: %s",
+				chart.Name,
+			)
+		}
+	}
+
 	return nil
 }
 
@@ -140,6 +258,11 @@ func handleResultsOfPreviousWeek(
 	return result
 }
 
+// GetResults aggregates settled results of signalled events.
+func GetResults(events []requester.LiveEventResult) ResultOfPreviousDay {
+	return handleResultsOfPreviousDay(events)
+}
+
 func handleResultsOfPreviousDay(
 	events []requester.LiveEventResult,
 ) ResultOfPreviousDay {
@@ -176,6 +299,10 @@ func getAverageOdd(events []requester.LiveEventResult) float64 {
 		}
 	}
 
+	if len(allOdds) == 0 {
+		return 0
+	}
+
 	var sum float64
 	for i := 0; i < len(allOdds); i++ {
 		sum += allOdds[i]
@@ -188,3 +315,13 @@ func getAverageOdd(events []requester.LiveEventResult) float64 {
 func roundNumber(number, unit float64) float64 {
 	return math.Round(number/unit) * unit
 }
+
+func getTextAboutResults(key string, result ResultOfPreviousDay, language string) string {
+	return i18n.Translate(
+		language,
+		key,
+		result.Win,
+		result.Lose,
+		i18n.FormatNumber(language, result.AverageOdd, 2),
+	)
+}
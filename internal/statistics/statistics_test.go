@@ -80,3 +80,20 @@ func TestStatistics_getAverageOdd_ReturnRightAverageOddForAwayFavorite(
 
 	assert.Equal(t, float64(7.33), result)
 }
+
+func TestStatistics_getAverageOdd_ReturnZeroWithoutWins(
+	t *testing.T,
+) {
+	events := []requester.LiveEventResult{
+		{
+			EventID:           "1",
+			Favorite:          "home",
+			LastHomeOdd:       1.7,
+			LastAwayOdd:       2.1,
+			WinnerInSecondSet: "away",
+		},
+	}
+
+	assert.Equal(t, 0.0, getAverageOdd(events))
+	assert.Equal(t, 0.0, getAverageOdd(nil))
+}
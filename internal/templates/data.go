@@ -0,0 +1,31 @@
+package templates
+
+import (
+	"time"
+)
+
+const (
+	OUTCOME_WON  = "won"
+	OUTCOME_LOST = "lost"
+)
+
+type Odds struct {
+	Home float64
+	Away float64
+}
+
+// Signal is rendered with Outcome when the second set is settled.
+type Signal struct {
+	EventID      string
+	LeagueName   string
+	CountryCode  string
+	HomeName     string
+	AwayName     string
+	FavoriteName string
+	Score        string
+	CurrentSet   int
+	LiveOdds     Odds
+	OpeningOdds  Odds
+	StartTime    time.Time
+	Outcome      string
+}
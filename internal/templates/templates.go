@@ -0,0 +1,140 @@
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/i18n"
+	"github.com/reconquest/karma-go"
+)
+
+const (
+	TEMPLATE_SIGNAL         = "signal.tmpl"
+	TEMPLATE_SIGNAL_DETAILS = "signal_details.tmpl"
+
+	TEMPLATES_PATTERN = "*.tmpl"
+)
+
+//go:embed templates/*/*.tmpl
+var defaults embed.FS
+
+// Templates renders HTML messages for telegram, default templates are
+// embedded into binary for every language and can be overridden by files with
+// the same name in <directory>/<language>/.
+type Templates struct {
+	templates map[string]*template.Template
+}
+
+func NewTemplates(directory string) (*Templates, error) {
+	result := &Templates{
+		templates: map[string]*template.Template{},
+	}
+
+	for _, language := range i18n.GetLanguages() {
+		templates, err := newLanguageTemplates(language, directory)
+		if err != nil {
+			return nil, karma.Describe("language", language).Reason(err)
+		}
+
+		result.templates[language] = templates
+	}
+
+	return result, nil
+}
+
+func newLanguageTemplates(language string, directory string) (*template.Template, error) {
+	templates, err := template.New("").
+		Funcs(getFuncs(language)).
+		ParseFS(defaults, "templates/"+language+"/"+TEMPLATES_PATTERN)
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to parse default templates",
+		)
+	}
+
+	if directory == "" {
+		return templates, nil
+	}
+
+	pattern := filepath.Join(directory, language, TEMPLATES_PATTERN)
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, karma.Format(
+			err,
+			"unable to list templates: %s",
+			pattern,
+		)
+	}
+
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, karma.Format(
+				err,
+				"unable to read template: %s",
+				path,
+			)
+		}
+
+		_, err = templates.New(filepath.Base(path)).Parse(string(data))
+		if err != nil {
+			return nil, karma.Format(
+				err,
+				"unable to parse template: %s",
+				path,
+			)
+		}
+	}
+
+	return templates, nil
+}
+
+// Render renders template of the language, templates of default language are
+// used for unsupported languages.
+func (templates *Templates) Render(
+	language string,
+	name string,
+	data interface{},
+) (string, error) {
+	languageTemplates, ok := templates.templates[language]
+	if !ok {
+		languageTemplates = templates.templates[i18n.DefaultLanguage]
+	}
+
+	var buffer bytes.Buffer
+	err := languageTemplates.ExecuteTemplate(&buffer, name, data)
+	if err != nil {
+		return "", karma.Format(
+			err,
+			"unable to render template: %s",
+			name,
+		)
+	}
+
+	return strings.TrimSpace(buffer.String()), nil
+}
+
+func getFuncs(language string) template.FuncMap {
+	return template.FuncMap{
+		// probability returns implied probability of the odd in percents.
+		"probability": func(odd float64) float64 {
+			if odd <= 0 {
+				return 0
+			}
+
+			return 100 / odd
+		},
+		"number": func(value float64, precision int) string {
+			return i18n.FormatNumber(language, value, precision)
+		},
+		"time": func(t time.Time) string {
+			return i18n.FormatTime(language, t)
+		},
+	}
+}
@@ -0,0 +1,137 @@
+package templates
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert"
+	"github.com/daniilsolovey/BetBotGo/internal/i18n"
+)
+
+const (
+	TEST_GOLDEN_PATH = "../../testdata/templates"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func getTestSignal() Signal {
+	location := time.FixedZone("MSK", 3*60*60)
+	return Signal{
+		EventID:      "3949821",
+		LeagueName:   "Italy A1",
+		CountryCode:  "it",
+		HomeName:     "Modena",
+		AwayName:     "Verona <B&W>",
+		FavoriteName: "Modena",
+		Score:        "20-25,25-20,1-0",
+		CurrentSet:   3,
+		LiveOdds:     Odds{Home: 1.7, Away: 2.1},
+		OpeningOdds:  Odds{Home: 1.25, Away: 3.75},
+		StartTime:    time.Date(2021, 9, 5, 18, 0, 0, 0, location),
+	}
+}
+
+func assertGolden(t *testing.T, name string, actual string) {
+	path := filepath.Join(TEST_GOLDEN_PATH, name)
+	if *update {
+		err := ioutil.WriteFile(path, []byte(actual), 0644)
+		assert.NoError(t, err)
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), actual)
+}
+
+func TestTemplates_Render_Signal(
+	t *testing.T,
+) {
+	templates, err := NewTemplates("")
+	assert.NoError(t, err)
+
+	for _, language := range i18n.GetLanguages() {
+		text, err := templates.Render(language, TEMPLATE_SIGNAL, getTestSignal())
+		assert.NoError(t, err)
+		assertGolden(t, filepath.Join(language, "signal.golden"), text)
+	}
+}
+
+func TestTemplates_Render_SignalWithOutcome(
+	t *testing.T,
+) {
+	templates, err := NewTemplates("")
+	assert.NoError(t, err)
+
+	signal := getTestSignal()
+	for _, language := range i18n.GetLanguages() {
+		signal.Outcome = OUTCOME_WON
+		text, err := templates.Render(language, TEMPLATE_SIGNAL, signal)
+		assert.NoError(t, err)
+		assertGolden(t, filepath.Join(language, "signal_won.golden"), text)
+
+		signal.Outcome = OUTCOME_LOST
+		text, err = templates.Render(language, TEMPLATE_SIGNAL, signal)
+		assert.NoError(t, err)
+		assertGolden(t, filepath.Join(language, "signal_lost.golden"), text)
+	}
+}
+
+func TestTemplates_Render_SignalDetails(
+	t *testing.T,
+) {
+	templates, err := NewTemplates("")
+	assert.NoError(t, err)
+
+	for _, language := range i18n.GetLanguages() {
+		text, err := templates.Render(language, TEMPLATE_SIGNAL_DETAILS, getTestSignal())
+		assert.NoError(t, err)
+		assertGolden(t, filepath.Join(language, "signal_details.golden"), text)
+	}
+}
+
+func TestTemplates_Render_UnsupportedLanguageUsesDefault(
+	t *testing.T,
+) {
+	templates, err := NewTemplates("")
+	assert.NoError(t, err)
+
+	expected, err := templates.Render(i18n.DefaultLanguage, TEMPLATE_SIGNAL, getTestSignal())
+	assert.NoError(t, err)
+
+	text, err := templates.Render("de", TEMPLATE_SIGNAL, getTestSignal())
+	assert.NoError(t, err)
+	assert.Equal(t, expected, text)
+}
+
+func TestTemplates_NewTemplates_OverrideFromDirectory(
+	t *testing.T,
+) {
+	directory := t.TempDir()
+	err := os.Mkdir(filepath.Join(directory, i18n.LANGUAGE_EN), 0755)
+	assert.NoError(t, err)
+
+	err = ioutil.WriteFile(
+		filepath.Join(directory, i18n.LANGUAGE_EN, TEMPLATE_SIGNAL),
+		[]byte(`{{ html .FavoriteName }} {{ number .LiveOdds.Home 1 }} {{ number (probability .LiveOdds.Home) 0 }}%`),
+		0644,
+	)
+	assert.NoError(t, err)
+
+	templates, err := NewTemplates(directory)
+	assert.NoError(t, err)
+
+	text, err := templates.Render(i18n.LANGUAGE_EN, TEMPLATE_SIGNAL, getTestSignal())
+	assert.NoError(t, err)
+	assert.Equal(t, "Modena 1.7 59%", text)
+
+	text, err = templates.Render(i18n.LANGUAGE_RU, TEMPLATE_SIGNAL, getTestSignal())
+	assert.NoError(t, err)
+	assertGolden(t, filepath.Join(i18n.LANGUAGE_RU, "signal.golden"), text)
+
+	_, err = templates.Render(i18n.LANGUAGE_EN, TEMPLATE_SIGNAL_DETAILS, getTestSignal())
+	assert.NoError(t, err)
+}
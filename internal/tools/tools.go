@@ -1,37 +1,57 @@
 package tools
 
 import (
+	"context"
 	"sort"
 	"time"
 
 	"github.com/reconquest/karma-go"
 )
 
-var TimeNow = time.Now
+const (
+	DEFAULT_TIMEZONE = "Europe/Moscow"
+)
+
+var (
+	TimeNow  = time.Now
+	Sleep    = sleep
+	Go       = goroutine
+	Timezone = DEFAULT_TIMEZONE
+)
 
-func GetCurrentMoscowTime() (time.Time, error) {
-	moscowTime, err := GetTimeMoscowLocation()
+func GetCurrentTime() (time.Time, error) {
+	location, err := GetLocation()
 	if err != nil {
 		return time.Time{}, karma.Format(
 			err,
-			"unable to get moscow location",
+			"unable to get operating location",
 		)
 	}
 
-	return TimeNow().In(moscowTime), nil
+	return TimeNow().In(location), nil
 
 }
 
-func GetTimeMoscowLocation() (*time.Location, error) {
-	moscowTime, err := time.LoadLocation("Europe/Moscow")
+func GetLocation() (*time.Location, error) {
+	return LoadLocation(Timezone)
+}
+
+func LoadLocation(timezone string) (*time.Location, error) {
+	location, err := time.LoadLocation(timezone)
 	if err != nil {
 		return nil, karma.Format(
 			err,
-			"unable to load location: Europe/Moscow",
+			"unable to load location: %s",
+			timezone,
 		)
 	}
 
-	return moscowTime, nil
+	return location, nil
+}
+
+func BeginningOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
 }
 
 func Find(a []string, x string) bool {
@@ -44,3 +64,19 @@ func Find(a []string, x string) bool {
 
 	return false
 }
+
+func goroutine(routine func()) {
+	go routine()
+}
+
+func sleep(ctx context.Context, duration time.Duration) bool {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
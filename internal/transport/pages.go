@@ -0,0 +1,60 @@
+package transport
+
+import (
+	"strings"
+	"unicode/utf16"
+)
+
+// MESSAGE_LENGTH_LIMIT is the maximum length of telegram message text in
+// UTF-16 code units.
+const MESSAGE_LENGTH_LIMIT = 4096
+
+// SplitMessage splits text into pages which fit into limit, pages are split
+// by lines when possible so list items are not torn apart.
+func SplitMessage(text string, limit int) []string {
+	if getLength(text) <= limit {
+		return []string{text}
+	}
+
+	var (
+		pages []string
+		page  string
+	)
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if page != "" && getLength(page)+getLength(line) > limit {
+			pages = append(pages, strings.TrimRight(page, "\n"))
+			page = ""
+		}
+
+		for getLength(line) > limit {
+			head, tail := splitAt(line, limit)
+			pages = append(pages, head)
+			line = tail
+		}
+
+		page += line
+	}
+
+	if strings.TrimSpace(page) != "" {
+		pages = append(pages, strings.TrimRight(page, "\n"))
+	}
+
+	return pages
+}
+
+func getLength(text string) int {
+	return len(utf16.Encode([]rune(text)))
+}
+
+// splitAt splits text so head is not longer than limit, runes are not torn.
+func splitAt(text string, limit int) (string, string) {
+	var length int
+	for i, r := range text {
+		length += len(utf16.Encode([]rune{r}))
+		if length > limit {
+			return text[:i], text[i:]
+		}
+	}
+
+	return text, ""
+}
@@ -0,0 +1,28 @@
+package transport
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestTransport_SplitMessage_SplitByLines(
+	t *testing.T,
+) {
+	assert.Equal(t, []string{"short"}, SplitMessage("short", 10))
+
+	pages := SplitMessage("line 1\nline 2\nline 3\n", 14)
+	assert.Equal(t, []string{"line 1\nline 2", "line 3"}, pages)
+}
+
+func TestTransport_SplitMessage_SplitLongLine(
+	t *testing.T,
+) {
+	pages := SplitMessage(strings.Repeat("ж", 5)+"\nok", 2)
+	assert.Equal(t, []string{"жж", "жж", "ж", "ok"}, pages)
+
+	for _, page := range SplitMessage(strings.Repeat("🏐", 3), 4) {
+		assert.True(t, getLength(page) <= 4)
+	}
+}
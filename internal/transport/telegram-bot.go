@@ -1,12 +1,24 @@
 package transport
 
 import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/reconquest/karma-go"
 	"github.com/reconquest/pkg/log"
 	tb "gopkg.in/tucnak/telebot.v2"
 )
 
+// Authorizer is called before every command handler, it returns false when
+// sender of the message has no required role.
+type Authorizer func(message *tb.Message, role string) bool
+
 type Telegram struct {
-	bot *tb.Bot
+	bot        *tb.Bot
+	authorizer Authorizer
 }
 
 type Recipient struct {
@@ -28,14 +40,240 @@ func (telegram *Telegram) SendMessage(recipient tb.Recipient, message string) er
 	return nil
 }
 
+func (telegram *Telegram) SendMessageWithOptions(
+	recipient tb.Recipient,
+	message string,
+	options *tb.SendOptions,
+) (int, error) {
+	sentMessage, err := telegram.bot.Send(recipient, message, options)
+	if err != nil {
+		return 0, err
+	}
+
+	return sentMessage.ID, nil
+}
+
+// EditMessage replaces text of the sent message, inline keyboard is replaced
+// with the one from options.
+func (telegram *Telegram) EditMessage(
+	recipient tb.Recipient,
+	messageID int,
+	message string,
+	options *tb.SendOptions,
+) error {
+	chatID, err := strconv.ParseInt(recipient.Recipient(), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	_, err = telegram.bot.Edit(
+		tb.StoredMessage{MessageID: strconv.Itoa(messageID), ChatID: chatID},
+		message,
+		options,
+	)
+	return err
+}
+
+// SendPhoto sends PNG or JPEG image with caption, caption is formatted
+// according to options. Message id and file id of the photo are returned, the
+// file id can be used to send the photo again without upload.
+func (telegram *Telegram) SendPhoto(
+	recipient tb.Recipient,
+	photo Photo,
+	caption string,
+	options *tb.SendOptions,
+) (int, string, error) {
+	if options == nil {
+		options = &tb.SendOptions{}
+	}
+
+	file := tb.File{FileID: photo.FileID}
+	if photo.FileID == "" {
+		file = tb.FromReader(bytes.NewReader(photo.Data))
+	}
+
+	sentMessage, err := telegram.bot.Send(
+		recipient,
+		&tb.Photo{File: file, Caption: caption},
+		options,
+	)
+	if err != nil {
+		return 0, "", err
+	}
+
+	var fileID string
+	if sentMessage.Photo != nil {
+		fileID = sentMessage.Photo.FileID
+	}
+
+	return sentMessage.ID, fileID, nil
+}
+
+func (telegram *Telegram) SetAuthorizer(authorizer Authorizer) {
+	telegram.authorizer = authorizer
+}
+
+// Handle registers command handler which is called only for senders with
+// given role, all commands are rejected until authorizer is set.
 func (telegram *Telegram) Handle(
 	cmd string,
+	role string,
 	fn func(*tb.Message) error,
 ) {
 	telegram.bot.Handle(cmd, func(message *tb.Message) {
+		if telegram.authorizer == nil {
+			log.Errorf(nil, "authorizer is not set, rejecting %s", cmd)
+			return
+		}
+
+		if !telegram.authorizer(message, role) {
+			return
+		}
+
 		err := fn(message)
 		if err != nil {
 			log.Errorf(nil, "error while processing %s: %s", cmd, err)
 		}
 	})
 }
+
+// HandleCallback handles presses of inline buttons with given unique name,
+// returned text is shown to user as notification. Presses are authorized like
+// commands, the sender of the callback needs given role in the chat of the
+// message with the button.
+func (telegram *Telegram) HandleCallback(
+	unique string,
+	role string,
+	fn func(*tb.Callback) (string, error),
+) {
+	telegram.bot.Handle(&tb.InlineButton{Unique: unique}, func(callback *tb.Callback) {
+		var text string
+		if telegram.authorizeCallback(unique, role, callback) {
+			var err error
+			text, err = fn(callback)
+			if err != nil {
+				log.Errorf(nil, "error while processing callback %s: %s", unique, err)
+			}
+		}
+
+		err := telegram.bot.Respond(callback, &tb.CallbackResponse{Text: text})
+		if err != nil {
+			log.Errorf(err, "unable to respond to callback %s", unique)
+		}
+	})
+}
+
+func (telegram *Telegram) authorizeCallback(
+	unique string,
+	role string,
+	callback *tb.Callback,
+) bool {
+	if telegram.authorizer == nil {
+		log.Errorf(nil, "authorizer is not set, rejecting callback %s", unique)
+		return false
+	}
+
+	// buttons of inline messages have no chat to authorize in
+	if callback.Message == nil || callback.Message.Chat == nil {
+		return false
+	}
+
+	return telegram.authorizer(&tb.Message{
+		Sender: callback.Sender,
+		Chat:   callback.Message.Chat,
+		Text:   unique,
+	}, role)
+}
+
+// CheckPostRights returns error when the bot is unable to post messages to the
+// chat.
+func (telegram *Telegram) CheckPostRights(chatID int64) error {
+	chat, err := telegram.bot.ChatByID(strconv.FormatInt(chatID, 10))
+	if err != nil {
+		return karma.Format(err, "unable to get chat: %d", chatID)
+	}
+
+	// bot can't be a member of private chat, user has to start it instead
+	if chat.Type == tb.ChatPrivate {
+		return nil
+	}
+
+	member, err := telegram.bot.ChatMemberOf(chat, telegram.bot.Me)
+	if err != nil {
+		return karma.Format(err, "unable to get bot membership in chat: %d", chatID)
+	}
+
+	return checkPostRights(chat, member)
+}
+
+func checkPostRights(chat *tb.Chat, member *tb.ChatMember) error {
+	switch member.Role {
+	case tb.Creator:
+		return nil
+	case tb.Left, tb.Kicked:
+		return fmt.Errorf("bot is not a member of chat: %s", chat.Title)
+	}
+
+	if chat.Type == tb.ChatChannel {
+		if member.Role != tb.Administrator || !member.CanPostMessages {
+			return fmt.Errorf("bot is not allowed to post to channel: %s", chat.Title)
+		}
+
+		return nil
+	}
+
+	if member.Role == tb.Restricted && !member.CanSendMessages {
+		return fmt.Errorf("bot is not allowed to send messages to chat: %s", chat.Title)
+	}
+
+	return nil
+}
+
+// IsChatUnavailable reports that messages can't be delivered to the chat until
+// user starts the bot again.
+func IsChatUnavailable(err error) bool {
+	switch err {
+	case tb.ErrBlockedByUser,
+		tb.ErrUserIsDeactivated,
+		tb.ErrNotStartedByUser,
+		tb.ErrBotKickedFromGroup,
+		tb.ErrBotKickedFromSuperGroup,
+		tb.ErrChatNotFound:
+		return true
+	}
+
+	return strings.Contains(err.Error(), "Forbidden")
+}
+
+// GetRetryAfter returns how long telegram asked to wait before the next request
+// when the error is caused by flood control.
+func GetRetryAfter(err error) (time.Duration, bool) {
+	switch err := err.(type) {
+	case tb.FloodError:
+		return time.Duration(err.RetryAfter) * time.Second, true
+	case *tb.FloodError:
+		return time.Duration(err.RetryAfter) * time.Second, true
+	}
+
+	return 0, false
+}
+
+// IsPermanentError reports that sending of the message will fail on retry
+// too, e.g. chat is unavailable or message is rejected as bad request.
+func IsPermanentError(err error) bool {
+	if IsChatUnavailable(err) {
+		return true
+	}
+
+	if apiErr, ok := err.(*tb.APIError); ok {
+		return apiErr.Code == 400
+	}
+
+	return strings.Contains(err.Error(), "(400)")
+}
+
+// IsMessageNotModified reports that edited message already has the same text
+// and keyboard.
+func IsMessageNotModified(err error) bool {
+	return strings.Contains(err.Error(), "message is not modified")
+}
@@ -0,0 +1,86 @@
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alecthomas/assert"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+func TestTransport_CheckPostRights_Channel(
+	t *testing.T,
+) {
+	channel := &tb.Chat{Type: tb.ChatChannel, Title: "signals"}
+
+	assert.NoError(t, checkPostRights(channel, &tb.ChatMember{Role: tb.Creator}))
+	assert.NoError(t, checkPostRights(channel, &tb.ChatMember{
+		Role:   tb.Administrator,
+		Rights: tb.Rights{CanPostMessages: true},
+	}))
+
+	assert.Error(t, checkPostRights(channel, &tb.ChatMember{Role: tb.Administrator}))
+	assert.Error(t, checkPostRights(channel, &tb.ChatMember{Role: tb.Member}))
+	assert.Error(t, checkPostRights(channel, &tb.ChatMember{Role: tb.Kicked}))
+}
+
+func TestTransport_CheckPostRights_Group(
+	t *testing.T,
+) {
+	group := &tb.Chat{Type: tb.ChatSuperGroup, Title: "admins"}
+
+	assert.NoError(t, checkPostRights(group, &tb.ChatMember{Role: tb.Member}))
+	assert.NoError(t, checkPostRights(group, &tb.ChatMember{
+		Role:   tb.Restricted,
+		Rights: tb.Rights{CanSendMessages: true},
+	}))
+
+	assert.Error(t, checkPostRights(group, &tb.ChatMember{Role: tb.Restricted}))
+	assert.Error(t, checkPostRights(group, &tb.ChatMember{Role: tb.Left}))
+}
+
+func TestTransport_HandleCallback_RequireRole(
+	t *testing.T,
+) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, request *http.Request) {
+			_, _ = writer.Write([]byte(`{"ok":true,"result":true}`))
+		},
+	))
+	defer server.Close()
+
+	bot, err := tb.NewBot(tb.Settings{URL: server.URL, Offline: true, Synchronous: true})
+	assert.NoError(t, err)
+
+	var authorized []int64
+	telegram := NewBot(bot)
+	telegram.SetAuthorizer(func(message *tb.Message, role string) bool {
+		assert.Equal(t, "subscriber", role)
+		authorized = append(authorized, message.Chat.ID)
+		return message.Sender.ID == 1
+	})
+
+	var handled []int
+	telegram.HandleCallback("placed", "subscriber", func(callback *tb.Callback) (string, error) {
+		handled = append(handled, callback.Sender.ID)
+		return "", nil
+	})
+
+	for _, senderID := range []int{1, 2} {
+		bot.ProcessUpdate(tb.Update{Callback: &tb.Callback{
+			Sender:  &tb.User{ID: senderID},
+			Message: &tb.Message{Chat: &tb.Chat{ID: 100}},
+			Data:    "\fplaced|1",
+		}})
+	}
+
+	bot.ProcessUpdate(tb.Update{Callback: &tb.Callback{
+		Sender:    &tb.User{ID: 1},
+		MessageID: "inline",
+		Data:      "\fplaced|1",
+	}})
+
+	assert.Equal(t, []int64{100, 100}, authorized)
+	assert.Equal(t, []int{1}, handled)
+}
@@ -4,6 +4,14 @@ import tb "gopkg.in/tucnak/telebot.v2"
 
 type Transport interface {
 	SendMessage(tb.Recipient, string) error
+	SendMessageWithOptions(tb.Recipient, string, *tb.SendOptions) (int, error)
+	EditMessage(tb.Recipient, int, string, *tb.SendOptions) error
+	SendPhoto(tb.Recipient, Photo, string, *tb.SendOptions) (int, string, error)
 }
 
-
+// Photo is uploaded from Data unless FileID of the photo uploaded before is
+// set.
+type Photo struct {
+	Data   []byte
+	FileID string
+}
@@ -0,0 +1,139 @@
+package transport
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/daniilsolovey/BetBotGo/internal/config"
+	"github.com/reconquest/pkg/log"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+const (
+	MODE_POLLING = "polling"
+	MODE_WEBHOOK = "webhook"
+
+	HEADER_SECRET_TOKEN = "X-Telegram-Bot-Api-Secret-Token"
+
+	WEBHOOK_RETRY_INTERVAL = 10 * time.Second
+)
+
+var secretTokenPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+// Webhook is a poller which registers webhook in telegram and receives
+// updates as http handler, it is served by the http server of handler
+// package instead of own listener.
+type Webhook struct {
+	url         string
+	secretToken string
+	updates     chan tb.Update
+	mutex       sync.Mutex
+}
+
+func NewWebhook(config config.Webhook) (*Webhook, error) {
+	if !strings.HasPrefix(config.PublicURL, "https://") {
+		return nil, fmt.Errorf(
+			"public url of telegram webhook must start with https://, got: %q",
+			config.PublicURL,
+		)
+	}
+
+	if !strings.HasPrefix(config.Path, "/") || config.Path == "/" {
+		return nil, fmt.Errorf(
+			"path of telegram webhook must start with / and be secret, got: %q",
+			config.Path,
+		)
+	}
+
+	if !secretTokenPattern.MatchString(config.SecretToken) {
+		return nil, errors.New(
+			"secret token of telegram webhook must be 1-256 characters: A-Z, a-z, 0-9, _ and -",
+		)
+	}
+
+	return &Webhook{
+		url:         strings.TrimSuffix(config.PublicURL, "/") + config.Path,
+		secretToken: config.SecretToken,
+	}, nil
+}
+
+// Poll registers webhook, retrying until it succeeds, and passes received
+// updates to the bot until it is stopped.
+func (webhook *Webhook) Poll(bot *tb.Bot, updates chan tb.Update, stop chan struct{}) {
+	webhook.setUpdates(updates)
+	defer webhook.setUpdates(nil)
+
+	for {
+		_, err := bot.Raw("setWebhook", map[string]string{
+			"url":          webhook.url,
+			"secret_token": webhook.secretToken,
+		})
+		if err == nil {
+			break
+		}
+
+		log.Errorf(err, "unable to set telegram webhook, retrying in %s", WEBHOOK_RETRY_INTERVAL)
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(WEBHOOK_RETRY_INTERVAL):
+		}
+	}
+
+	log.Info("telegram webhook is set")
+	<-stop
+}
+
+// ServeHTTP accepts update only with valid secret token, telegram retries
+// the update when it is answered with error status.
+func (webhook *Webhook) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	token := request.Header.Get(HEADER_SECRET_TOKEN)
+	if subtle.ConstantTimeCompare([]byte(token), []byte(webhook.secretToken)) != 1 {
+		log.Warningf(nil, "telegram webhook request with invalid secret token from %s", request.RemoteAddr)
+		writer.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	var update tb.Update
+	err := json.NewDecoder(request.Body).Decode(&update)
+	if err != nil {
+		log.Errorf(err, "unable to decode telegram update")
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	updates := webhook.getUpdates()
+	if updates == nil {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	select {
+	case updates <- update:
+		writer.WriteHeader(http.StatusOK)
+	case <-request.Context().Done():
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}
+}
+
+func (webhook *Webhook) setUpdates(updates chan tb.Update) {
+	webhook.mutex.Lock()
+	defer webhook.mutex.Unlock()
+
+	webhook.updates = updates
+}
+
+func (webhook *Webhook) getUpdates() chan tb.Update {
+	webhook.mutex.Lock()
+	defer webhook.mutex.Unlock()
+
+	return webhook.updates
+}
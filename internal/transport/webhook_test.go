@@ -0,0 +1,160 @@
+package transport
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert"
+	"github.com/daniilsolovey/BetBotGo/internal/config"
+	tb "gopkg.in/tucnak/telebot.v2"
+)
+
+const (
+	testToken       = "123:token"
+	testSecretToken = "secret_token-1"
+	testUpdate      = `{"update_id":1,"message":{"message_id":10,"text":"/start",` +
+		`"from":{"id":42,"first_name":"user"},"chat":{"id":42,"type":"private"}}}`
+)
+
+// fakeBotApi records methods called by the bot and answers them as telegram
+// Bot API does.
+type fakeBotApi struct {
+	mutex    sync.Mutex
+	requests map[string][]map[string]interface{}
+}
+
+func (api *fakeBotApi) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	method := strings.TrimPrefix(request.URL.Path, "/bot"+testToken+"/")
+
+	params := map[string]interface{}{}
+	body, _ := ioutil.ReadAll(request.Body)
+	_ = json.Unmarshal(body, &params)
+
+	api.mutex.Lock()
+	api.requests[method] = append(api.requests[method], params)
+	api.mutex.Unlock()
+
+	switch method {
+	case "sendMessage":
+		writer.Write([]byte(`{"ok":true,"result":{"message_id":11,"chat":{"id":42}}}`))
+	default:
+		writer.Write([]byte(`{"ok":true,"result":true}`))
+	}
+}
+
+func (api *fakeBotApi) getRequests(method string) []map[string]interface{} {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	return api.requests[method]
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition is not met in time")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func postUpdate(t *testing.T, url string, secretToken string) int {
+	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(testUpdate))
+	assert.NoError(t, err)
+	request.Header.Set(HEADER_SECRET_TOKEN, secretToken)
+
+	response, err := http.DefaultClient.Do(request)
+	assert.NoError(t, err)
+	defer response.Body.Close()
+
+	return response.StatusCode
+}
+
+func TestTransport_Webhook_ServeHTTP_PassesUpdatesWithSecretToken(
+	t *testing.T,
+) {
+	api := &fakeBotApi{requests: map[string][]map[string]interface{}{}}
+	apiServer := httptest.NewServer(api)
+	defer apiServer.Close()
+
+	webhook, err := NewWebhook(config.Webhook{
+		PublicURL:   "https://bot.example.com/",
+		Path:        "/telegram/secret-path",
+		SecretToken: testSecretToken,
+	})
+	assert.NoError(t, err)
+
+	webhookServer := httptest.NewServer(webhook)
+	defer webhookServer.Close()
+
+	bot, err := tb.NewBot(tb.Settings{
+		URL:     apiServer.URL,
+		Token:   testToken,
+		Poller:  webhook,
+		Offline: true,
+	})
+	assert.NoError(t, err)
+
+	bot.Handle("/start", func(message *tb.Message) {
+		_, err := bot.Send(message.Chat, "started")
+		assert.NoError(t, err)
+	})
+
+	go bot.Start()
+	defer bot.Stop()
+
+	waitFor(t, func() bool {
+		return len(api.getRequests("setWebhook")) == 1
+	})
+	assert.Equal(t, map[string]interface{}{
+		"url":          "https://bot.example.com/telegram/secret-path",
+		"secret_token": testSecretToken,
+	}, api.getRequests("setWebhook")[0])
+
+	assert.Equal(t, http.StatusForbidden, postUpdate(t, webhookServer.URL, ""))
+	assert.Equal(t, http.StatusForbidden, postUpdate(t, webhookServer.URL, "wrong"))
+	assert.Equal(t, http.StatusOK, postUpdate(t, webhookServer.URL, testSecretToken))
+
+	waitFor(t, func() bool {
+		return len(api.getRequests("sendMessage")) == 1
+	})
+	message := api.getRequests("sendMessage")[0]
+	assert.Equal(t, "42", message["chat_id"])
+	assert.Equal(t, "started", message["text"])
+}
+
+func TestTransport_NewWebhook_ValidatesConfig(
+	t *testing.T,
+) {
+	valid := config.Webhook{
+		PublicURL:   "https://bot.example.com",
+		Path:        "/telegram/secret-path",
+		SecretToken: testSecretToken,
+	}
+
+	_, err := NewWebhook(valid)
+	assert.NoError(t, err)
+
+	insecure := valid
+	insecure.PublicURL = "http://bot.example.com"
+	_, err = NewWebhook(insecure)
+	assert.Error(t, err)
+
+	withoutPath := valid
+	withoutPath.Path = "/"
+	_, err = NewWebhook(withoutPath)
+	assert.Error(t, err)
+
+	invalidToken := valid
+	invalidToken.SecretToken = "secret token"
+	_, err = NewWebhook(invalidToken)
+	assert.Error(t, err)
+}
@@ -1,7 +1,13 @@
 package main
 
 import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	tb "gopkg.in/tucnak/telebot.v2"
@@ -9,9 +15,16 @@ import (
 	"github.com/daniilsolovey/BetBotGo/handler"
 	"github.com/daniilsolovey/BetBotGo/internal/config"
 	"github.com/daniilsolovey/BetBotGo/internal/database"
+	"github.com/daniilsolovey/BetBotGo/internal/delivery"
+	"github.com/daniilsolovey/BetBotGo/internal/i18n"
+	"github.com/daniilsolovey/BetBotGo/internal/leader"
+	"github.com/daniilsolovey/BetBotGo/internal/notifier"
 	"github.com/daniilsolovey/BetBotGo/internal/operator"
+	"github.com/daniilsolovey/BetBotGo/internal/outbox"
 	"github.com/daniilsolovey/BetBotGo/internal/requester"
+	"github.com/daniilsolovey/BetBotGo/internal/scheduler"
 	"github.com/daniilsolovey/BetBotGo/internal/statistics"
+	"github.com/daniilsolovey/BetBotGo/internal/templates"
 	"github.com/daniilsolovey/BetBotGo/internal/tools"
 	"github.com/daniilsolovey/BetBotGo/internal/transport"
 	"github.com/docopt/docopt-go"
@@ -35,10 +48,6 @@ Options:
   -h --help                         Show this help.
 `
 
-const (
-	RECEIVING_EVENTS_DURATION = 5 * time.Minute
-)
-
 func main() {
 	args, err := docopt.ParseArgs(
 		usage,
@@ -65,132 +74,251 @@ func main() {
 		log.Fatal(err)
 	}
 
+	_, err = tools.LoadLocation(config.Timezone)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	tools.Timezone = config.Timezone
+
+	if !i18n.IsSupported(config.Language) {
+		log.Fatalf(
+			nil,
+			"unsupported language: %s, supported: %s",
+			config.Language,
+			strings.Join(i18n.GetLanguages(), ", "),
+		)
+	}
+
+	i18n.DefaultLanguage = config.Language
+
 	log.Infof(
 		karma.Describe("database", config.Database.Name),
 		"connecting to the database",
 	)
 
-	database := database.NewDatabase(
+	newDatabase := database.NewDatabase(
 		config.Database.Name, config.Database.Host, config.Database.Port, config.Database.User, config.Database.Password,
 	)
-	err = database.CreateTables()
+	err = newDatabase.CreateTables()
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	defer database.Close()
 	newRequester := requester.NewRequester(config)
 
-	log.Info("creating telegram bot")
+	var poller tb.Poller
+	var webhook *transport.Webhook
+	switch config.Telegram.Mode {
+	case transport.MODE_POLLING:
+		poller = &tb.LongPoller{Timeout: config.Telegram.PollingTimeout}
+	case transport.MODE_WEBHOOK:
+		webhook, err = transport.NewWebhook(config.Telegram.Webhook)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		poller = webhook
+	default:
+		log.Fatalf(
+			nil,
+			"unsupported telegram mode: %s, supported: %s, %s",
+			config.Telegram.Mode,
+			transport.MODE_POLLING,
+			transport.MODE_WEBHOOK,
+		)
+	}
+
+	log.Infof(nil, "creating telegram bot, mode: %s", config.Telegram.Mode)
 	bot, err := tb.NewBot(
 		tb.Settings{
 			Token:  config.Telegram.Token,
-			Poller: &tb.LongPoller{Timeout: 10 * time.Second},
+			Poller: poller,
 		},
 	)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	if webhook == nil {
+		// telegram doesn't return updates to poller while webhook is set
+		err = bot.RemoveWebhook()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	telegramBot := transport.NewBot(bot)
 
+	messageTemplates, err := templates.NewTemplates(config.Templates.Directory)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	log.Info("creating operator")
 	newOperator := operator.NewOperator(
-		config, database, newRequester, telegramBot,
+		config, newDatabase, newRequester, telegramBot, messageTemplates,
 	)
-	newStatistic := statistics.NewStatistics(database, telegramBot)
-
-	var wg sync.WaitGroup
-	wg.Add(1)
-	go func() {
-		log.Info("start cycle with receiving events for today")
-		for {
-			events, err := newOperator.GetEvents()
-			if err != nil {
-				log.Error(err)
-			}
+	newStatistic := statistics.NewStatistics(newDatabase)
 
-			handledEvents := newOperator.HandleEventsByLeagues(events)
+	err = newOperator.BootstrapRoles()
+	if err != nil {
+		log.Fatal(err)
+	}
 
-			err = database.InsertEventsForToday(handledEvents)
-			if err != nil {
-				log.Error(err)
-			}
+	router, err := notifier.NewRouter(config.Notifications)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-			err = newOperator.CreateRoutinesForHandleLiveEvents(handledEvents)
-			if err != nil {
-				log.Error(err)
-			}
+	newOperator.SetNotifier(router)
+	newStatistic.SetNotifier(router)
 
-			time.Sleep(RECEIVING_EVENTS_DURATION)
+	broadcastRoutes, err := delivery.GetBroadcastRoutes(config.Broadcast)
+	if err != nil {
+		log.Fatal(err)
+	}
 
+	for target, chatID := range delivery.GetBroadcastTargets(config.Broadcast) {
+		err := telegramBot.CheckPostRights(chatID)
+		if err != nil {
+			log.Fatalf(
+				err,
+				"unable to use chat %d as broadcast %s",
+				chatID,
+				target,
+			)
 		}
+	}
+
+	newOperator.SetBroadcastRoutes(broadcastRoutes)
+	newStatistic.SetBroadcastRoutes(broadcastRoutes)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	elector := leader.NewElector(
+		newDatabase, config.Leader.LockID, config.Leader.RenewInterval,
+	)
+	elector.Elect()
+	newOperator.SetLeaderCheck(elector.IsLeader)
+	newOperator.SetBotUsername(bot.Me.Username)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		elector.Run(ctx)
 	}()
 
 	wg.Add(1)
 	go func() {
-		log.Info("start cycle with receiving statistic on previous day")
-		for {
-			timeNow, err := tools.GetCurrentMoscowTime()
-			if err != nil {
-				log.Error(err)
-			}
-
-			beginOfDay := roundToBeginningOfDay(timeNow)
-			waitUntill := beginOfDay.Add(24 * time.Hour)
-			waitingTime := waitUntill.Sub(timeNow)
-
-			time.Sleep(waitingTime)
-			err = newStatistic.GetStatisticOnPreviousDayAndNotify()
-			if err != nil {
-				log.Error(err)
-			}
-		}
+		defer wg.Done()
+		router.Run(ctx)
 	}()
 
+	newScheduler := scheduler.NewScheduler(
+		config, newDatabase, newOperator, newStatistic, elector.IsLeader,
+	)
+	newScheduler.Start(ctx, &wg)
+
+	newSender := outbox.NewSender(config, newDatabase, telegramBot, elector.IsLeader)
 	wg.Add(1)
 	go func() {
-		log.Info("start cycle with receiving statistic on previous week")
-		for {
-			timeNow, err := tools.GetCurrentMoscowTime()
-			if err != nil {
-				log.Error(err)
-			}
-
-			beginOfDay := roundToBeginningOfDay(timeNow)
-			waitUntill := beginOfDay.Add(24 * time.Hour)
-			waitingTime := waitUntill.Sub(timeNow)
-
-			weekday := time.Now().Weekday()
-			if weekday == time.Monday {
-				err = newStatistic.GetStatisticOnPreviousWeekAndNotify()
-				if err != nil {
-					log.Error(err)
-				}
-			}
-
-			time.Sleep(waitingTime)
-		}
+		defer wg.Done()
+		newSender.Run(ctx)
 	}()
 
-	newHandler := handler.NewHandler(database, config)
+	newHandler := handler.NewHandler(newDatabase, config)
+	if webhook != nil {
+		newHandler.SetWebhook(webhook)
+	}
+
 	go func() {
-		newHandler.StartServer(config)
+		err := newHandler.StartServer(config)
+		if err != nil && err != http.ErrServerClosed {
+			log.Error(err)
+		}
 	}()
 
-	telegramBot.Handle("/starttest", newOperator.Start)
+	telegramBot.SetAuthorizer(newOperator.Authorize)
+	for _, command := range newOperator.GetCommands() {
+		telegramBot.Handle(command.Name, command.Role, command.Handler)
+	}
+
+	telegramBot.Handle("/starttest", database.ROLE_ADMIN, newOperator.Start)
+	telegramBot.Handle(tb.OnText, database.ROLE_GUEST, newOperator.HandleText)
+	telegramBot.HandleCallback(operator.BUTTON_PLACED, database.ROLE_SUBSCRIBER, newOperator.Placed)
+	telegramBot.HandleCallback(operator.BUTTON_SKIP, database.ROLE_SUBSCRIBER, newOperator.SkipSignal)
+	telegramBot.HandleCallback(operator.BUTTON_DETAILS, database.ROLE_SUBSCRIBER, newOperator.Details)
+	telegramBot.HandleCallback(operator.BUTTON_SETTING, database.ROLE_SUBSCRIBER, newOperator.Setting)
 	log.Infof(nil, "starting to listen and serve telegram bot")
-	bot.Start()
+	go bot.Start()
 
-	wg.Wait()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	receivedSignal := <-signals
+
+	log.Infof(nil, "received signal %s, shutting down", receivedSignal)
+	shutdown(
+		config.ShutdownTimeout,
+		cancel, &wg, bot, newOperator, newHandler, newDatabase,
+	)
 }
 
-func roundToBeginningOfDay(t time.Time) time.Time {
-	moscowLocation, err := tools.GetTimeMoscowLocation()
-	if err != nil {
-		log.Error(err)
+func shutdown(
+	timeout time.Duration,
+	cancel context.CancelFunc,
+	loops *sync.WaitGroup,
+	bot *tb.Bot,
+	operator *operator.Operator,
+	handler *handler.Handler,
+	database *database.Database,
+) {
+	startTime := time.Now()
+	deadline, cancelDeadline := context.WithTimeout(context.Background(), timeout)
+	defer cancelDeadline()
+
+	log.Info("stopping to receive new events and telegram updates")
+	cancel()
+	bot.Stop()
+	operator.Stop()
+
+	loopsStopped := waitContext(deadline, loops.Wait)
+
+	log.Infof(nil, "waiting for %d live routines", operator.GetActiveRoutinesCount())
+	unfinishedRoutines := operator.WaitRoutines(deadline)
+
+	handlerErr := handler.Shutdown(deadline)
+	if handlerErr != nil {
+		log.Errorf(handlerErr, "unable to shutdown http server")
 	}
 
-	year, month, day := t.Date()
-	return time.Date(year, month, day, 0, 0, 0, 0, moscowLocation)
+	databaseClosed := waitContext(deadline, func() {
+		database.Close()
+	})
+
+	log.Infof(
+		karma.
+			Describe("duration", time.Since(startTime).String()).
+			Describe("loops_stopped", loopsStopped).
+			Describe("unfinished_routines", unfinishedRoutines).
+			Describe("http_server_stopped", handlerErr == nil).
+			Describe("database_closed", databaseClosed),
+		"BetBotGo stopped",
+	)
+}
+
+func waitContext(ctx context.Context, wait func()) bool {
+	done := make(chan struct{})
+	go func() {
+		wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
 }